@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"satmine/conformance"
 	docs "satmine/docs"
 	"satmine/rpc"
 	"satmine/satmine"
@@ -35,13 +36,16 @@ var logger *zap.Logger
 
 // Config represents application configuration
 type Config struct {
-	Port       int
-	Socketport int
-	Name       string
-	Ordrpc     string
-	Dbpath     string
-	RecPath    string
-	Hookrpc    string
+	Port        int
+	Socketport  int
+	Name        string
+	Ordrpc      string
+	Dbpath      string
+	RecPath     string
+	Hookrpc     string
+	Reindexpath string
+	Network     string
+	Simworkers  int // Workers running mining simulations, half the CPUs if not set
 }
 
 // AppConfig holds the global configuration
@@ -63,6 +67,160 @@ func cleanUpPreviousData(path string) error {
 	return nil
 }
 
+// openIndexDB opens a badger database with the options used by the indexer
+func openIndexDB(path string) (*badger.DB, error) {
+	opts := badger.DefaultOptions(path)
+	opts.VerifyValueChecksum = true
+	opts.ValueLogFileSize = 2<<30 - 1
+	opts.MemTableSize = 512 << 20
+	return badger.Open(opts)
+}
+
+// runReindex replays the blocks stored in Dbpath into a fresh database at Reindexpath.
+// Usage: go run ./cmd reindex [height]
+func runReindex(args []string) error {
+	targetHeight := -1
+	if len(args) > 0 {
+		height, err := strconv.Atoi(args[0])
+		if err != nil || height < 0 {
+			return fmt.Errorf("invalid reindex target height: %s", args[0])
+		}
+		targetHeight = height
+	}
+
+	if AppConfig.Reindexpath == "" || AppConfig.Reindexpath == AppConfig.Dbpath {
+		return fmt.Errorf("reindexpath must be set and differ from dbpath")
+	}
+
+	// Derived state is rebuilt from scratch, so drop anything left from a previous run
+	if err := cleanUpPreviousData(AppConfig.Reindexpath); err != nil {
+		return err
+	}
+
+	srcDb, err := openIndexDB(AppConfig.Dbpath)
+	if err != nil {
+		return err
+	}
+	defer srcDb.Close()
+
+	dstDb, err := openIndexDB(AppConfig.Reindexpath)
+	if err != nil {
+		return err
+	}
+	defer dstDb.Close()
+
+	lastHeight, err := satmine.NewBTOrdIdx(dstDb).ReindexFrom(satmine.NewBTOrdIdx(srcDb), targetHeight)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Reindexed up to block %d into %s, point dbpath at it to serve the new index", lastHeight, AppConfig.Reindexpath))
+	return nil
+}
+
+// runReplay replays the block stored in Dbpath at a height on the state of the blocks before it, rebuilt in
+// Reindexpath, and prints the state it changes next to the diff kept when the block was indexed. The chain is
+// indexed again from its first stored block on every run, so only blocks close to it can be replayed.
+// Usage: go run ./cmd replay <height>
+func runReplay(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: replay <height>")
+	}
+	height, err := strconv.Atoi(args[0])
+	if err != nil || height < 0 {
+		return fmt.Errorf("invalid replay height: %s", args[0])
+	}
+
+	if AppConfig.Reindexpath == "" || AppConfig.Reindexpath == AppConfig.Dbpath {
+		return fmt.Errorf("reindexpath must be set and differ from dbpath")
+	}
+
+	// The state before the block is rebuilt from scratch, and dropped once the block is replayed
+	if err := cleanUpPreviousData(AppConfig.Reindexpath); err != nil {
+		return err
+	}
+	defer cleanUpPreviousData(AppConfig.Reindexpath)
+
+	srcDb, err := openIndexDB(AppConfig.Dbpath)
+	if err != nil {
+		return err
+	}
+	defer srcDb.Close()
+
+	dstDb, err := openIndexDB(AppConfig.Reindexpath)
+	if err != nil {
+		return err
+	}
+	defer dstDb.Close()
+
+	replay, err := satmine.NewBTOrdIdx(dstDb).ReplayBlock(satmine.NewBTOrdIdx(srcDb), height)
+	if err != nil {
+		return err
+	}
+	replayJSON, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(replayJSON))
+
+	if replay.Stored == nil {
+		logger.Info(fmt.Sprintf("No diff was kept when block %d was indexed", height))
+	} else if !replay.Match {
+		return fmt.Errorf("replay of block %d differs from the diff kept when it was indexed", height)
+	}
+	return nil
+}
+
+// runConformance replays the conformance vectors found under a directory and reports the ones whose state differs.
+// With -update the expected state of every vector is replaced by the state produced by this indexer.
+// Usage: go run ./cmd conformance [-update] [dir]
+func runConformance(args []string) error {
+	dir := "conformance/vectors"
+	update := false
+	for _, arg := range args {
+		if arg == "-update" {
+			update = true
+		} else {
+			dir = arg
+		}
+	}
+
+	vectors, err := conformance.LoadVectors(dir)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, vector := range vectors {
+		result := conformance.Run(vector)
+		if update && result.Err == nil {
+			vector.Expected = result.State
+			if err := vector.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("UPDATE %s\n", vector.Path)
+			continue
+		}
+		if result.Passed() {
+			fmt.Printf("PASS %s\n", vector.Path)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", vector.Path)
+		if result.Err != nil {
+			fmt.Printf("    %s\n", result.Err)
+		}
+		for _, diff := range result.Diffs {
+			fmt.Printf("    %s\n", diff)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d conformance vectors failed", failed, len(vectors))
+	}
+	return nil
+}
+
 // CORS is a middleware to handle Cross-Origin Resource Sharing
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -109,6 +267,13 @@ func main() {
 
 	logger.Info("Configuration: %+v\n", zap.Reflect("config", AppConfig))
 
+	// Select the protocol rules of the indexed chain
+	if err := satmine.SetNetwork(AppConfig.Network); err != nil {
+		logger.Error("Invalid network", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+
 	// //Debug used Clean up previous data if exists
 	// err = cleanUpPreviousData(AppConfig.Dbpath)
 	// if err != nil {
@@ -116,12 +281,38 @@ func main() {
 	// 	panic(err)
 	// }
 
+	// Rebuild the index from the stored blocks instead of serving it
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := runReindex(os.Args[2:]); err != nil {
+			logger.Error("Reindex failed", zap.Error(err))
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Replay one stored block instead of serving the index
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			logger.Error("Replay failed", zap.Error(err))
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Replay the conformance vectors instead of serving the index
+	if len(os.Args) > 1 && os.Args[1] == "conformance" {
+		if err := runConformance(os.Args[2:]); err != nil {
+			logger.Error("Conformance failed", zap.Error(err))
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize the db with the specified database path
-	badOpts := badger.DefaultOptions(AppConfig.Dbpath)
-	badOpts.VerifyValueChecksum = true
-	badOpts.ValueLogFileSize = 2<<30 - 1
-	badOpts.MemTableSize = 512 << 20
-	db, err := badger.Open(badOpts)
+	db, err := openIndexDB(AppConfig.Dbpath)
 	if err != nil {
 		panic(err)
 	}
@@ -132,12 +323,15 @@ func main() {
 	// Create an instance of satmine.BTOrdIdx using the dbManager
 	btOrdIdx := satmine.NewBTOrdIdx(db)
 
+	// Bring the records of an older indexer up to date before serving them
+	if err := btOrdIdx.RunMigrations(); err != nil {
+		logger.Error("Migration failed", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+
 	// Indexing of transaction information records
-	recOpts := badger.DefaultOptions(AppConfig.RecPath)
-	recOpts.VerifyValueChecksum = true
-	recOpts.ValueLogFileSize = 2<<30 - 1
-	recOpts.MemTableSize = 512 << 20
-	recDb, recErr := badger.Open(recOpts)
+	recDb, recErr := openIndexDB(AppConfig.RecPath)
 	if recErr != nil {
 		panic(recErr)
 	}
@@ -147,6 +341,7 @@ func main() {
 	// Retrieve the singleton instance of store.Store and initialize it
 	store := store.Instance()
 	store.Init(btOrdIdx, btRecIdx, fmt.Sprint(AppConfig.Socketport))
+	store.SimJobs = satmine.NewSimulationJobs(AppConfig.Simworkers)
 
 	r := gin.Default()
 	r.Use(CORS())
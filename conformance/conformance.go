@@ -0,0 +1,229 @@
+// filePath: conformance/conformance.go
+
+// Package conformance replays the Mineral protocol test vectors through the indexer and compares the resulting state.
+//
+// A vector is a JSON file holding a sequence of hook events, in the format of docs/ordhook_2.0.json, and the state
+// expected once they are all applied. The vectors only describe protocol inputs and outputs, so other Mineral
+// indexers can replay them as well.
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"satmine/rpc"
+	"satmine/satmine"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Version is the newest vector format understood by the harness.
+const Version = 1
+
+// json is the jsoniter configuration used to read and write vectors, compatible with the standard library.
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Vector is one conformance test case.
+type Vector struct {
+	Version     int                    `json:"version"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Network     string                 `json:"network,omitempty"` // Rules table of the vector, mainnet when empty
+	Blocks      []jsoniter.RawMessage  `json:"blocks"`            // Hook events, applied in order
+	Expected    *satmine.StateSnapshot `json:"expected"`
+
+	Path string `json:"-"` // File the vector was loaded from
+}
+
+// Result is the outcome of replaying one vector.
+type Result struct {
+	Vector *Vector
+	State  *satmine.StateSnapshot // State after the blocks were applied, nil if the replay failed
+	Err    error                  // Why the replay failed
+	Diffs  []string               // Differences between the expected and the actual state
+}
+
+// Passed reports whether the replay succeeded and produced the expected state.
+func (r *Result) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// LoadVectors reads every *.json vector under dir, sorted by path.
+func LoadVectors(dir string) ([]*Vector, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".json" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	vectors := make([]*Vector, 0, len(paths))
+	for _, path := range paths {
+		vector, err := LoadVector(path)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// LoadVector reads the vector stored at path.
+func LoadVector(path string) (*Vector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vector Vector
+	if err := json.Unmarshal(data, &vector); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if vector.Version < 1 || vector.Version > Version {
+		return nil, fmt.Errorf("%s: unsupported vector version %d", path, vector.Version)
+	}
+	vector.Path = path
+	return &vector, nil
+}
+
+// Save writes the vector back to the file it was loaded from.
+func (v *Vector) Save() error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(v.Path, append(data, '\n'), 0644)
+}
+
+// Run replays the blocks of the vector into an empty in-memory index and compares the state with the expected one.
+// The rules table of the vector is selected for the replay, then the previous one is restored.
+func Run(vector *Vector) *Result {
+	result := &Result{Vector: vector}
+
+	previous := satmine.ActiveNetwork()
+	defer satmine.SetNetwork(string(previous))
+	if err := satmine.SetNetwork(vector.Network); err != nil {
+		result.Err = err
+		return result
+	}
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer db.Close()
+	idx := satmine.NewBTOrdIdx(db)
+
+	for i, raw := range vector.Blocks {
+		var event rpc.OrdHookEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			result.Err = fmt.Errorf("block %d: %w", i, err)
+			return result
+		}
+		block, err := event.HookBlock()
+		if err != nil {
+			result.Err = fmt.Errorf("block %d: %w", i, err)
+			return result
+		}
+		if err := idx.WriteBlock(block); err != nil {
+			result.Err = fmt.Errorf("block %s: %w", block.BlockHeight, err)
+			return result
+		}
+	}
+
+	result.State, result.Err = idx.Snapshot()
+	if result.Err != nil {
+		return result
+	}
+	if vector.Expected == nil {
+		result.Diffs = []string{"vector has no expected state"}
+		return result
+	}
+	result.Diffs, result.Err = Diff(vector.Expected, result.State)
+	return result
+}
+
+// Diff lists the differences between two states, one line per differing value, sorted by path.
+func Diff(expected, actual *satmine.StateSnapshot) ([]string, error) {
+	var want, got interface{}
+	if err := roundTrip(expected, &want); err != nil {
+		return nil, err
+	}
+	if err := roundTrip(actual, &got); err != nil {
+		return nil, err
+	}
+	var diffs []string
+	diffValues("", want, got, &diffs)
+	sort.Strings(diffs)
+	return diffs, nil
+}
+
+// roundTrip converts v to its generic JSON form, so states are compared as the vectors store them.
+func roundTrip(v interface{}, out *interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// diffValues appends to diffs the paths at which want and got differ.
+func diffValues(path string, want, got interface{}, diffs *[]string) {
+	wantMap, wantIsMap := want.(map[string]interface{})
+	gotMap, gotIsMap := got.(map[string]interface{})
+	if wantIsMap && gotIsMap {
+		for key, value := range wantMap {
+			diffValues(path+"/"+key, value, gotMap[key], diffs)
+		}
+		for key, value := range gotMap {
+			if _, ok := wantMap[key]; !ok {
+				diffValues(path+"/"+key, nil, value, diffs)
+			}
+		}
+		return
+	}
+
+	wantList, wantIsList := want.([]interface{})
+	gotList, gotIsList := got.([]interface{})
+	if wantIsList && gotIsList {
+		for i := 0; i < len(wantList) || i < len(gotList); i++ {
+			var wantItem, gotItem interface{}
+			if i < len(wantList) {
+				wantItem = wantList[i]
+			}
+			if i < len(gotList) {
+				gotItem = gotList[i]
+			}
+			diffValues(fmt.Sprintf("%s/%d", path, i), wantItem, gotItem, diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: expected %s, got %s", path, describe(want), describe(got)))
+	}
+}
+
+// describe formats a generic JSON value for a diff line.
+func describe(v interface{}) string {
+	if v == nil {
+		return "nothing"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
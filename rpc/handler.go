@@ -310,19 +310,32 @@ func MiningProfitChart(g *gin.Context) {
 	const step = 1     // Assume some fixed step value
 	const max = 280000 // Assume some fixed max value
 
-	//fmt.Println("MiningProfitChart protocol=", protocol)
-
-	// Call GetMiningProfitChart function
-	profitChartJSON, err := store.OrdIdx.GetMiningProfitChart(&protocol, step, max)
+	// The chart is simulated by the bounded simulation workers, and cached
+	job, err := store.SimJobs.Submit(&protocol, step, max)
+	if err == satmine.ErrSimulationQueueFull {
+		g.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	fmt.Printf("GetMiningProfitChart end....................................\n")
+	jobID := job.ID
+	job, err = store.SimJobs.Wait(g.Request.Context(), jobID)
+	if err != nil {
+		// The client is gone, the job goes on only for its other waiters
+		store.SimJobs.Cancel(jobID)
+		g.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if job.Status != satmine.SimulationDone {
+		g.JSON(http.StatusInternalServerError, gin.H{"error": job.Error})
+		return
+	}
+	profitChartJSON := job.Result
 
 	// Directly return the JSON response from GetMiningProfitChart to the frontend
-	g.Data(http.StatusOK, "application/json", []byte(profitChartJSON))
+	g.Data(http.StatusOK, "application/json", profitChartJSON)
 
 }
 
@@ -1295,3 +1308,888 @@ func GetLotteryList(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+// GetInscriptionVerdictResult represents the data structure returned by the GetInscriptionVerdict API endpoint
+type GetInscriptionVerdictResult struct {
+	Code    int                        `json:"code"`
+	Message string                     `json:"message"`
+	Data    satmine.InscriptionVerdict `json:"data"`
+}
+
+// GetInscriptionVerdict godoc
+// @Summary Retrieve the verdict of a protocol inscription
+// @Schemes
+// @Description Tells whether a MRC-721 or MRC-20 inscription was accepted, rejected or ignored, with a machine readable reason. Status(accepted,rejected,ignored)
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param inscriptionId query string true "Inscription ID"
+// @Success 200 {object} GetInscriptionVerdictResult "Verdict of the inscription"
+// @Failure 400 {object} string "Error message if the inscription ID is not provided"
+// @Failure 404 {object} string "Error message if the inscription has no verdict"
+// @Router /mrc20/inscriptionverdict [get]
+func GetInscriptionVerdict(c *gin.Context) {
+	// Retrieve query parameter
+	inscriptionId := c.Query("inscriptionId")
+
+	// Validate required parameters
+	if inscriptionId == "" {
+		c.JSON(http.StatusBadRequest, GetInscriptionVerdictResult{
+			Code:    400,
+			Message: "Inscription ID is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	// Not a protocol inscription, or not indexed yet
+	verdict, err := store.OrdIdx.GetInscriptionVerdict(inscriptionId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetInscriptionVerdictResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetInscriptionVerdictResult{
+		Code:    200,
+		Message: "Success",
+		Data:    verdict,
+	})
+}
+
+// PreviewInscriptionResult represents the data structure returned by the PreviewInscription API endpoint
+type PreviewInscriptionResult struct {
+	Code    int                           `json:"code"`
+	Message string                        `json:"message"`
+	Data    satmine.WebInscriptionPreview `json:"data"`
+}
+
+// PreviewInscription godoc
+// @Summary Preview what inscribing a content would do
+// @Schemes
+// @Description Runs a content through the validation and apply logic of the next block against the current state, without writing anything, and returns the verdict it would get with the collection, balance and miner power it would change
+// @Tags mrc20
+// @Accept plain
+// @Produce json
+// @Param address query string true "Address inscribing the content"
+// @Param content_type query string false "Content type of the inscription"
+// @Param data body string true "Content of the inscription"
+// @Success 200 {object} PreviewInscriptionResult "Would-be effects of the inscription"
+// @Failure 400 {object} string "Error message if the address or the content is missing"
+// @Failure 500 {object} string "Error message if the preview fails"
+// @Router /mrc20/inscriptionpreview [post]
+func PreviewInscription(c *gin.Context) {
+	// Retrieve query parameters
+	address := c.Query("address")
+	contentType := c.Query("content_type")
+
+	// Read request body
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil || address == "" || len(body) == 0 {
+		c.JSON(http.StatusBadRequest, PreviewInscriptionResult{
+			Code:    400,
+			Message: "Address and content are required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	// Nothing the preview applies is written
+	preview, err := store.OrdIdx.PreviewInscription(body, contentType, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, PreviewInscriptionResult{
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, PreviewInscriptionResult{
+		Code:    200,
+		Message: "Success",
+		Data:    preview,
+	})
+}
+
+// GetBlockDiffResult represents the data structure returned by the GetBlockDiff API endpoint
+type GetBlockDiffResult struct {
+	Code    int                  `json:"code"`
+	Message string               `json:"message"`
+	Data    satmine.WebBlockDiff `json:"data"`
+}
+
+// GetBlockDiff godoc
+// @Summary Retrieve the state changed by a block
+// @Schemes
+// @Description Retrieves the changes a committed block made: stored balance deltas per address and tick, ownership moves, miner power changes, genesis data changes, lottery wins and the mining rewards accrued per power class, which reach the balances when settled. Blocks indexed before the diffs were kept have none
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param height query string true "Block Height"
+// @Success 200 {object} GetBlockDiffResult "State changed by the block"
+// @Failure 400 {object} string "Error message if the height is not provided"
+// @Failure 404 {object} string "Error message if the block has no diff"
+// @Router /mrc20/blockdiff [get]
+func GetBlockDiff(c *gin.Context) {
+	// Retrieve query parameter
+	height := c.Query("height")
+
+	// Validate required parameters
+	if height == "" {
+		c.JSON(http.StatusBadRequest, GetBlockDiffResult{
+			Code:    400,
+			Message: "Height is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	// Not indexed yet, or indexed before the diffs were kept
+	diff, err := store.OrdIdx.GetBlockDiff(height)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetBlockDiffResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetBlockDiffResult{
+		Code:    200,
+		Message: "Success",
+		Data:    diff,
+	})
+}
+
+// GetMrc20TransferStateResult represents the data structure returned by the GetMrc20TransferState API endpoint
+type GetMrc20TransferStateResult struct {
+	Code    int                         `json:"code"`
+	Message string                      `json:"message"`
+	Data    satmine.Mrc20TransferRecord `json:"data"`
+}
+
+// GetMrc20TransferState godoc
+// @Summary Retrieve the lifecycle of a MRC-20 transfer inscription
+// @Schemes
+// @Description Retrieves the current state of a MRC-20 transfer inscription and every transition it went through, with the block height and the counterparty of each. State(inscribed,rejected_insufficient_balance,in_transit,settled,returned,burnt)
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param inscriptionId query string true "Inscription ID"
+// @Success 200 {object} GetMrc20TransferStateResult "Lifecycle of the transfer inscription"
+// @Failure 400 {object} string "Error message if the inscription ID is not provided"
+// @Failure 404 {object} string "Error message if the inscription is not a MRC-20 transfer"
+// @Router /mrc20/transferstate [get]
+func GetMrc20TransferState(c *gin.Context) {
+	// Retrieve query parameter
+	inscriptionId := c.Query("inscriptionId")
+
+	// Validate required parameters
+	if inscriptionId == "" {
+		c.JSON(http.StatusBadRequest, GetMrc20TransferStateResult{
+			Code:    400,
+			Message: "Inscription ID is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	record, err := store.OrdIdx.GetMrc20Transfer(inscriptionId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetMrc20TransferStateResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetMrc20TransferStateResult{
+		Code:    200,
+		Message: "Success",
+		Data:    record,
+	})
+}
+
+// GetAddressMrc20TransfersResult represents the data structure returned by the GetAddressMrc20Transfers API endpoint
+type GetAddressMrc20TransfersResult struct {
+	Code    int                          `json:"code"`
+	Message string                       `json:"message"`
+	Data    GetAddressMrc20TransfersData `json:"data"`
+}
+
+// GetAddressMrc20TransfersData defines the structure for the data section of the API response.
+type GetAddressMrc20TransfersData struct {
+	List     []satmine.Mrc20TransferRecord `json:"list"`
+	AllCount int                           `json:"all_count"`
+}
+
+// GetAddressMrc20Transfers godoc
+// @Summary Retrieve a paginated list of the MRC-20 transfer inscriptions of an address
+// @Schemes
+// @Description Retrieves the MRC-20 transfer inscriptions an address sent or received with their lifecycle, optionally limited to the ones in a state. State(inscribed,rejected_insufficient_balance,in_transit,settled,returned,burnt)
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param address query string true "Address"
+// @Param state query string false "State"
+// @Param pageIndex query int false "Page Index" default(0)
+// @Param pageSize query int false "Page Size" default(100)
+// @Success 200 {object} GetAddressMrc20TransfersResult "List of MRC-20 transfer inscriptions"
+// @Failure 400 {object} string "Error message if retrieval fails"
+// @Router /mrc20/addresstransfers [get]
+func GetAddressMrc20Transfers(c *gin.Context) {
+	// Retrieve query parameters
+	address := c.Query("address")
+	state := c.DefaultQuery("state", "")
+	pageIndexStr := c.DefaultQuery("pageIndex", "0")
+	pageSizeStr := c.DefaultQuery("pageSize", "100")
+
+	// Validate required parameters
+	if address == "" {
+		c.JSON(400, GetAddressMrc20TransfersResult{
+			Code:    400,
+			Message: "Address is required",
+		})
+		return
+	}
+
+	// Convert pageIndex and pageSize to integers
+	pageIndex, err := strconv.Atoi(pageIndexStr)
+	if err != nil || pageIndex < 0 {
+		c.JSON(400, GetAddressMrc20TransfersResult{
+			Code:    400,
+			Message: "Invalid page index",
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil || pageSize < 0 {
+		c.JSON(400, GetAddressMrc20TransfersResult{
+			Code:    400,
+			Message: "Invalid page size",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	records, allCount, err := store.OrdIdx.GetAddressMrc20Transfers(address, state, pageIndex, pageSize)
+	if err != nil {
+		c.JSON(501, GetAddressMrc20TransfersResult{
+			Code:    501,
+			Message: err.Error(),
+		})
+		return
+	}
+	if records == nil {
+		records = []satmine.Mrc20TransferRecord{}
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetAddressMrc20TransfersResult{
+		Code:    200,
+		Message: "Success",
+		Data: GetAddressMrc20TransfersData{
+			List:     records,
+			AllCount: allCount,
+		},
+	})
+}
+
+// GetMinerStakesResult represents the data structure returned by the GetMinerStakes API endpoint
+type GetMinerStakesResult struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    satmine.WebMinerStakes `json:"data"`
+}
+
+// GetMinerStakes godoc
+// @Summary Retrieve the stakes on a miner
+// @Schemes
+// @Description Retrieves the MRC-20 tokens staked on an MRC-721 miner and the position of every staker, with the height from which it can be unstaked
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param inscriptionId query string true "Inscription ID"
+// @Success 200 {object} GetMinerStakesResult "Stakes on the miner"
+// @Failure 400 {object} string "Error message if the inscription ID is not provided"
+// @Failure 501 {object} string "Error message if retrieval fails"
+// @Router /mrc20/minerstakes [get]
+func GetMinerStakes(c *gin.Context) {
+	// Retrieve query parameter
+	inscriptionId := c.Query("inscriptionId")
+
+	// Validate required parameters
+	if inscriptionId == "" {
+		c.JSON(http.StatusBadRequest, GetMinerStakesResult{
+			Code:    400,
+			Message: "Inscription ID is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	stakes, err := store.OrdIdx.GetMinerStakes(inscriptionId)
+	if err != nil {
+		c.JSON(501, GetMinerStakesResult{
+			Code:    501,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetMinerStakesResult{
+		Code:    200,
+		Message: "Success",
+		Data:    stakes,
+	})
+}
+
+// GetMinerDelegationResult represents the data structure returned by the GetMinerDelegation API endpoint
+type GetMinerDelegationResult struct {
+	Code    int                        `json:"code"`
+	Message string                     `json:"message"`
+	Data    satmine.WebMinerDelegation `json:"data"`
+}
+
+// GetMinerDelegation godoc
+// @Summary Retrieve the reward address of a miner
+// @Schemes
+// @Description Retrieves the owner of an MRC-721 miner and the address credited with its rewards, with the delegation redirecting them if any
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param inscriptionId query string true "Inscription ID"
+// @Success 200 {object} GetMinerDelegationResult "Reward address of the miner"
+// @Failure 400 {object} string "Error message if the inscription ID is not provided"
+// @Failure 404 {object} string "Error message if the inscription is not indexed"
+// @Router /mrc20/minerdelegation [get]
+func GetMinerDelegation(c *gin.Context) {
+	// Retrieve query parameter
+	inscriptionId := c.Query("inscriptionId")
+
+	// Validate required parameters
+	if inscriptionId == "" {
+		c.JSON(http.StatusBadRequest, GetMinerDelegationResult{
+			Code:    400,
+			Message: "Inscription ID is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	delegation, err := store.OrdIdx.GetMinerDelegation(inscriptionId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetMinerDelegationResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetMinerDelegationResult{
+		Code:    200,
+		Message: "Success",
+		Data:    delegation,
+	})
+}
+
+// GetLotteryProofResult represents the data structure returned by the GetLotteryProof API endpoint
+type GetLotteryProofResult struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Data    satmine.WebLotteryProof `json:"data"`
+}
+
+// GetLotteryProof godoc
+// @Summary Retrieve the derivation of a lottery round
+// @Schemes
+// @Description Retrieves how a lottery round of an MRC-721 collection was drawn: block hash, random winp against winp, miners in order, luck number modulus, draws and winner, checked by recomputing the draw
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param mrc721name query string true "MRC-721 Name"
+// @Param round query int true "Lottery round, from 1"
+// @Success 200 {object} GetLotteryProofResult "Derivation of the lottery round"
+// @Failure 400 {object} string "Error message if the parameters are invalid"
+// @Failure 404 {object} string "Error message if the round does not exist"
+// @Router /mrc20/lotteryproof [get]
+func GetLotteryProof(c *gin.Context) {
+	// Retrieve query parameters
+	mrc721name := c.Query("mrc721name")
+	roundStr := c.Query("round")
+
+	// Validate required parameters
+	if mrc721name == "" {
+		c.JSON(http.StatusBadRequest, GetLotteryProofResult{
+			Code:    400,
+			Message: "MRC-721 Name is required",
+		})
+		return
+	}
+	round, err := strconv.Atoi(roundStr)
+	if err != nil || round < 1 {
+		c.JSON(http.StatusBadRequest, GetLotteryProofResult{
+			Code:    400,
+			Message: "Invalid round",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	proof, err := store.OrdIdx.GetLotteryProof(mrc721name, round)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetLotteryProofResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetLotteryProofResult{
+		Code:    200,
+		Message: "Success",
+		Data:    proof,
+	})
+}
+
+// GetLotteryStatsResult represents the data structure returned by the GetLotteryStats API endpoint
+type GetLotteryStatsResult struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Data    satmine.WebLotteryStats `json:"data"`
+}
+
+// GetLotteryStats godoc
+// @Summary Retrieve lottery statistics for a given MRC-721 name
+// @Schemes
+// @Description Retrieves the jackpot at every win of an MRC-721 collection, the hit rate of its draws against the configured winp and the expected value of the next draw per miner
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param mrc721name query string true "MRC-721 Name"
+// @Success 200 {object} GetLotteryStatsResult "Lottery statistics of the collection"
+// @Failure 400 {object} string "Error message if the MRC-721 name is not provided"
+// @Failure 404 {object} string "Error message if the collection does not exist or has no lottery"
+// @Router /mrc20/lotterystats [get]
+func GetLotteryStats(c *gin.Context) {
+	// Retrieve query parameter
+	mrc721name := c.Query("mrc721name")
+
+	// Validate required parameters
+	if mrc721name == "" {
+		c.JSON(http.StatusBadRequest, GetLotteryStatsResult{
+			Code:    400,
+			Message: "MRC-721 Name is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	stats, err := store.OrdIdx.GetLotteryStats(mrc721name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetLotteryStatsResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetLotteryStatsResult{
+		Code:    200,
+		Message: "Success",
+		Data:    stats,
+	})
+}
+
+// GetAddressLotteryWinsResult represents the data structure returned by the GetAddressLotteryWins API endpoint
+type GetAddressLotteryWinsResult struct {
+	Code    int                           `json:"code"`
+	Message string                        `json:"message"`
+	Data    satmine.WebAddressLotteryWins `json:"data"`
+}
+
+// GetAddressLotteryWins godoc
+// @Summary Retrieve the lottery wins of an address
+// @Schemes
+// @Description Retrieves the lottery wins of an address in every MRC-721 collection, most recent first, with the total won in each collection
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param address query string true "Address"
+// @Success 200 {object} GetAddressLotteryWinsResult "Lottery wins of the address"
+// @Failure 400 {object} string "Error message if the address is not provided"
+// @Failure 500 {object} string "Error message if retrieval fails"
+// @Router /mrc20/addresslotterywins [get]
+func GetAddressLotteryWins(c *gin.Context) {
+	// Retrieve query parameter
+	address := c.Query("address")
+
+	// Validate required parameters
+	if address == "" {
+		c.JSON(http.StatusBadRequest, GetAddressLotteryWinsResult{
+			Code:    400,
+			Message: "Address is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	wins, err := store.OrdIdx.GetAddressLotteryWins(address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, GetAddressLotteryWinsResult{
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetAddressLotteryWinsResult{
+		Code:    200,
+		Message: "Success",
+		Data:    wins,
+	})
+}
+
+// GetMiningProjectionResult represents the data structure returned by the GetMiningProjection API endpoint
+type GetMiningProjectionResult struct {
+	Code    int                         `json:"code"`
+	Message string                      `json:"message"`
+	Data    satmine.WebMiningProjection `json:"data"`
+}
+
+// GetMiningProjection godoc
+// @Summary Project the mining of an MRC-721 collection from its current state
+// @Schemes
+// @Description Projects the emission of an MRC-721 collection from its remaining supply and current miners: the remaining emission, the height mining ends, the emission every step blocks over the horizon, and the expected earnings of a base power miner or of the miners rewarding an address
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param mrc721name query string true "MRC-721 Name"
+// @Param step query int false "Blocks between two points (default 1000)"
+// @Param horizon query int false "Blocks projected after the latest block (default 100000)"
+// @Param address query string false "Address whose miners are projected"
+// @Success 200 {object} GetMiningProjectionResult "Mining projection of the collection"
+// @Failure 400 {object} string "Error message if a parameter is invalid"
+// @Failure 404 {object} string "Error message if the collection does not exist"
+// @Router /mrc20/miningprojection [get]
+func GetMiningProjection(c *gin.Context) {
+	// Retrieve query parameters
+	mrc721name := c.Query("mrc721name")
+	address := c.Query("address")
+
+	// Validate required parameters
+	if mrc721name == "" {
+		c.JSON(http.StatusBadRequest, GetMiningProjectionResult{
+			Code:    400,
+			Message: "MRC-721 Name is required",
+		})
+		return
+	}
+	step, err := strconv.ParseInt(c.DefaultQuery("step", "1000"), 10, 64)
+	if err != nil || step <= 0 {
+		c.JSON(http.StatusBadRequest, GetMiningProjectionResult{
+			Code:    400,
+			Message: "Invalid step",
+		})
+		return
+	}
+	horizon, err := strconv.ParseInt(c.DefaultQuery("horizon", "100000"), 10, 64)
+	if err != nil || horizon <= 0 {
+		c.JSON(http.StatusBadRequest, GetMiningProjectionResult{
+			Code:    400,
+			Message: "Invalid horizon",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	projection, err := store.OrdIdx.GetMiningProjection(mrc721name, step, horizon, address)
+	if err != nil {
+		c.JSON(http.StatusNotFound, GetMiningProjectionResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetMiningProjectionResult{
+		Code:    200,
+		Message: "Success",
+		Data:    projection,
+	})
+}
+
+// MiningSimulationResult represents the data structure returned by the mining simulation API endpoints
+type MiningSimulationResult struct {
+	Code    int                      `json:"code"`
+	Message string                   `json:"message"`
+	Data    satmine.WebSimulationJob `json:"data"`
+}
+
+// SubmitMiningSimulation godoc
+// @Summary Submit a mining profit chart simulation
+// @Schemes
+// @Description Queues the simulation of the mining profit chart of an MRC721Protocol every step blocks up to max, and returns its job. Submitting the parameters of a queued, running or done job returns that job
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param data body string true "MRC721Protocol JSON data"
+// @Param step query int false "Blocks between two points (default 1)"
+// @Param max query int false "Last block simulated (default 280000)"
+// @Success 200 {object} MiningSimulationResult "Simulation job"
+// @Failure 400 {object} string "Error message if the protocol or a parameter is invalid"
+// @Failure 503 {object} string "Error message if too many simulations are queued"
+// @Router /mrc20/miningsimulation [post]
+func SubmitMiningSimulation(c *gin.Context) {
+	// Parse JSON to MRC721Protocol structure
+	var protocol satmine.MRC721Protocol
+	body, err := io.ReadAll(c.Request.Body)
+	if err == nil {
+		err = jsoniter.Unmarshal(body, &protocol)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, MiningSimulationResult{
+			Code:    400,
+			Message: "Error parsing JSON",
+		})
+		return
+	}
+	step, err := strconv.Atoi(c.DefaultQuery("step", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, MiningSimulationResult{
+			Code:    400,
+			Message: "Invalid step",
+		})
+		return
+	}
+	max, err := strconv.Atoi(c.DefaultQuery("max", "280000"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, MiningSimulationResult{
+			Code:    400,
+			Message: "Invalid max",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	job, err := store.SimJobs.Submit(&protocol, step, max)
+	if err == satmine.ErrSimulationQueueFull {
+		c.JSON(http.StatusServiceUnavailable, MiningSimulationResult{
+			Code:    503,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, MiningSimulationResult{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, MiningSimulationResult{
+		Code:    200,
+		Message: "Success",
+		Data:    job,
+	})
+}
+
+// GetMiningSimulation godoc
+// @Summary Retrieve a mining profit chart simulation
+// @Schemes
+// @Description Retrieves the status and progress of a simulation job, and its profit chart once done
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param id query string true "Simulation job ID"
+// @Success 200 {object} MiningSimulationResult "Simulation job"
+// @Failure 400 {object} string "Error message if the job ID is not provided"
+// @Failure 404 {object} string "Error message if the job does not exist or has been forgotten"
+// @Router /mrc20/miningsimulationjob [get]
+func GetMiningSimulation(c *gin.Context) {
+	miningSimulationJob(c, func(id string) (satmine.WebSimulationJob, error) {
+		return store.Instance().SimJobs.Get(id)
+	})
+}
+
+// CancelMiningSimulation godoc
+// @Summary Cancel a mining profit chart simulation
+// @Schemes
+// @Description Withdraws one submission of a queued or running simulation job, and cancels the job once every submission sharing it has withdrawn. A finished job is returned unchanged
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param id query string true "Simulation job ID"
+// @Success 200 {object} MiningSimulationResult "Simulation job"
+// @Failure 400 {object} string "Error message if the job ID is not provided"
+// @Failure 404 {object} string "Error message if the job does not exist or has been forgotten"
+// @Router /mrc20/cancelminingsimulation [post]
+func CancelMiningSimulation(c *gin.Context) {
+	miningSimulationJob(c, func(id string) (satmine.WebSimulationJob, error) {
+		return store.Instance().SimJobs.Cancel(id)
+	})
+}
+
+// miningSimulationJob responds with the simulation job of the id query parameter, as returned by action.
+func miningSimulationJob(c *gin.Context, action func(id string) (satmine.WebSimulationJob, error)) {
+	// Retrieve query parameter
+	id := c.Query("id")
+
+	// Validate required parameters
+	if id == "" {
+		c.JSON(http.StatusBadRequest, MiningSimulationResult{
+			Code:    400,
+			Message: "Job ID is required",
+		})
+		return
+	}
+
+	job, err := action(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, MiningSimulationResult{
+			Code:    404,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, MiningSimulationResult{
+		Code:    200,
+		Message: "Success",
+		Data:    job,
+	})
+}
+
+// GetBurnPlanResult represents the data structure returned by the GetBurnPlan API endpoint
+type GetBurnPlanResult struct {
+	Code    int                 `json:"code"`
+	Message string              `json:"message"`
+	Data    satmine.WebBurnPlan `json:"data"`
+}
+
+// GetBurnPlan godoc
+// @Summary Plan a burn for a miner inscription
+// @Schemes
+// @Description Returns the tokens a miner needs to burn to reach a target power, or the power a budget buys, with the change in its per-block earnings against the current total power of its collection and the blocks until the burn is repaid
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param inscriptionID query string true "Inscription ID"
+// @Param power query string false "Target power, if no budget is given"
+// @Param budget query string false "Tokens to burn, if no target power is given"
+// @Success 200 {object} GetBurnPlanResult "Burn plan for the inscription"
+// @Failure 400 {object} string "Error message if a parameter is invalid or the plan fails"
+// @Router /mrc20/burnplan [get]
+func GetBurnPlan(c *gin.Context) {
+	// Retrieve query parameters
+	inscriptionID := c.Query("inscriptionID")
+	power := c.Query("power")
+	budget := c.Query("budget")
+
+	// Validate required parameters
+	if inscriptionID == "" {
+		c.JSON(http.StatusBadRequest, GetBurnPlanResult{
+			Code:    400,
+			Message: "Inscription ID is required",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	plan, err := store.OrdIdx.GetBurnPlan(inscriptionID, power, budget)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, GetBurnPlanResult{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, GetBurnPlanResult{
+		Code:    200,
+		Message: "Success",
+		Data:    plan,
+	})
+}
+
+// LintMRC721DeployResult represents the data structure returned by the LintMRC721Deploy API endpoint
+type LintMRC721DeployResult struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Data    satmine.WebDeployLint `json:"data"`
+}
+
+// LintMRC721Deploy godoc
+// @Summary Lint a draft MRC-721 deploy
+// @Schemes
+// @Description Checks a draft MRC-721 deploy JSON under the latest rules and returns every rule violation at once, the name and tick collisions with existing collections, the normalised protocol and a summary of its emission
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param data body string true "Draft MRC721Protocol JSON data"
+// @Success 200 {object} LintMRC721DeployResult "Lint of the draft deploy"
+// @Failure 400 {object} string "Error message if the request body cannot be read"
+// @Router /mrc20/deploylint [post]
+func LintMRC721Deploy(c *gin.Context) {
+	// Read request body
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, LintMRC721DeployResult{
+			Code:    400,
+			Message: "Error reading request body",
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	// Create and send success response, the lint holds the problems of the draft
+	c.JSON(http.StatusOK, LintMRC721DeployResult{
+		Code:    200,
+		Message: "Success",
+		Data:    store.OrdIdx.LintMRC721Deploy(body),
+	})
+}
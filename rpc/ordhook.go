@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -126,62 +127,15 @@ func (in *InscriptionNumber) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// @Summary Process OrdHook events
-// @Schemes
-// @Description Parses and processes events related to OrdHook.
-// @Tags OrdHook
-// @Accept json
-// @Produce json
-// @Param event body OrdHookEvent true "Event Payload"
-// @Success 200 {object} map[string]interface{} "A message confirming successful processing"
-// @Failure 400 {object} map[string]interface{} "Error message in case of failure to process the event"
-// @Router /mrc20/hookevents [post]
-func ordHookEvents(c *gin.Context) {
-	//fmt.Println("ordHookEvents()1")
-
-	defer func() {
-		if r := recover(); r != nil {
-			// Handle the panic, convert it to error if needed
-			fmt.Println("ordHookEvents err= ", r)
-			fmt.Printf("Stack Trace:\n%s\n", debug.Stack())
-			os.Exit(1)
-		}
-	}()
-
-	// Check if the request comes from localhost (127.0.0.1)
-	if !isRequestFromLocalhost(c.Request.RemoteAddr) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied. This endpoint is only accessible from localhost."})
-		return
+// HookBlock converts the blocks applied by the event into a single HookBlock, at the height of the first one.
+func (event *OrdHookEvent) HookBlock() (*satmine.HookBlock, error) {
+	if len(event.Apply) == 0 {
+		return nil, errors.New("event does not apply any block")
 	}
 
-	hookBlock := satmine.HookBlock{}
+	hookBlock := &satmine.HookBlock{}
 	hookBlock.Inscriptions = make([]satmine.HookInscription, 0)
 	hookBlock.Transfers = make([]satmine.HookTransfer, 0)
-	// c.Request.Body
-	//fmt.Printf("ordHookEvents Body: %+v\n", c.Request.Body)
-
-	// Use io to read the request body
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read request body"})
-		return
-	}
-
-	// Convert the body to a string and print it
-	//bodyString := string(body)
-	//fmt.Printf("ordHookEvents Body: %s\n", bodyString)
-
-	//fmt.Println("ordHookEvents()2")
-
-	// Unmarshal the JSON data into the OrdHookEvent struct
-	var event OrdHookEvent
-	err = jsoniter.Unmarshal(body, &event)
-	if err != nil {
-		//fmt.Println("jsoniter.Unmarshal(body, &event) err = ", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to unmarshal JSON"})
-		return
-	}
-	//fmt.Println("ordHookEvents()3")
 
 	hookBlock.BlockHeight = fmt.Sprintf("%d", event.Apply[0].BlockIdentifier.Index)
 	hookBlock.BlockHash = event.Apply[0].BlockIdentifier.Hash
@@ -254,8 +208,7 @@ func ordHookEvents(c *gin.Context) {
 							contentBytes, err := hex.DecodeString(hexString)
 							if err != nil {
 								// Handle error (e.g., log it, return a response, etc.)
-								c.JSON(http.StatusBadRequest, gin.H{"error": "op.InscriptionRevealed.ContentBytes to []byte"})
-								return
+								return nil, errors.New("op.InscriptionRevealed.ContentBytes to []byte")
 							}
 							ins.ContentByte = &contentBytes
 
@@ -286,6 +239,66 @@ func ordHookEvents(c *gin.Context) {
 		}
 	}
 
+	return hookBlock, nil
+}
+
+// @Summary Process OrdHook events
+// @Schemes
+// @Description Parses and processes events related to OrdHook.
+// @Tags OrdHook
+// @Accept json
+// @Produce json
+// @Param event body OrdHookEvent true "Event Payload"
+// @Success 200 {object} map[string]interface{} "A message confirming successful processing"
+// @Failure 400 {object} map[string]interface{} "Error message in case of failure to process the event"
+// @Router /mrc20/hookevents [post]
+func ordHookEvents(c *gin.Context) {
+	//fmt.Println("ordHookEvents()1")
+
+	defer func() {
+		if r := recover(); r != nil {
+			// Handle the panic, convert it to error if needed
+			fmt.Println("ordHookEvents err= ", r)
+			fmt.Printf("Stack Trace:\n%s\n", debug.Stack())
+			os.Exit(1)
+		}
+	}()
+
+	// Check if the request comes from localhost (127.0.0.1)
+	if !isRequestFromLocalhost(c.Request.RemoteAddr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied. This endpoint is only accessible from localhost."})
+		return
+	}
+
+	// Use io to read the request body
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read request body"})
+		return
+	}
+
+	// Convert the body to a string and print it
+	//bodyString := string(body)
+	//fmt.Printf("ordHookEvents Body: %s\n", bodyString)
+
+	//fmt.Println("ordHookEvents()2")
+
+	// Unmarshal the JSON data into the OrdHookEvent struct
+	var event OrdHookEvent
+	err = jsoniter.Unmarshal(body, &event)
+	if err != nil {
+		//fmt.Println("jsoniter.Unmarshal(body, &event) err = ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to unmarshal JSON"})
+		return
+	}
+	//fmt.Println("ordHookEvents()3")
+
+	hookBlock, err := event.HookBlock()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	//fmt.Println("ordHookEvents()4")
 
 	//c.JSON(http.StatusOK, gin.H{"message": "Event processed successfully"})
@@ -293,7 +306,7 @@ func ordHookEvents(c *gin.Context) {
 	//fmt.Printf("hookBlock: %+v\n", hookBlock.BlockHeight)
 
 	store := store.Instance()
-	err = store.OrdIdx.WriteBlock(&hookBlock)
+	err = store.OrdIdx.WriteBlock(hookBlock)
 	if err != nil {
 
 		fmt.Printf("hookBlock.BlockHeight is already stored  %s\n", hookBlock.BlockHeight)
@@ -316,3 +329,75 @@ func isRequestFromLocalhost(addr string) bool {
 	ip := strings.Split(addr, ":")[0] // Extract the IP part from the address
 	return ip == "127.0.0.1"
 }
+
+// DryRunBlockResult represents the data structure returned by the DryRunBlock API endpoint
+type DryRunBlockResult struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    []satmine.WebBlockDiff `json:"data"`
+}
+
+// DryRunBlock godoc
+// @Summary Dry-run a block
+// @Schemes
+// @Description Applies a block given as a hook event on top of the latest block, without writing anything, and returns the state it would change: balances, ownership, miner powers, genesis data and lottery wins. The block may be at most 7 blocks ahead of the latest block. Only accessible from localhost
+// @Tags mrc20
+// @Accept json
+// @Produce json
+// @Param data body OrdHookEvent true "Hook event holding the block"
+// @Success 200 {object} DryRunBlockResult "State changed by the block and the empty blocks before it"
+// @Failure 400 {object} string "Error message if the block cannot be read or applied, or is too far ahead"
+// @Failure 403 {object} string "Error message if the request does not come from localhost"
+// @Router /mrc20/dryrunblock [post]
+func DryRunBlock(c *gin.Context) {
+	// Check if the request comes from localhost (127.0.0.1)
+	if !isRequestFromLocalhost(c.Request.RemoteAddr) {
+		c.JSON(http.StatusForbidden, DryRunBlockResult{
+			Code:    403,
+			Message: "Access denied. This endpoint is only accessible from localhost.",
+		})
+		return
+	}
+
+	// Read the hook event holding the block
+	var event OrdHookEvent
+	body, err := io.ReadAll(c.Request.Body)
+	if err == nil {
+		err = jsoniter.Unmarshal(body, &event)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, DryRunBlockResult{
+			Code:    400,
+			Message: "Unable to read the hook event",
+		})
+		return
+	}
+	hookBlock, err := event.HookBlock()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, DryRunBlockResult{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Retrieve the store instance from the global context
+	store := store.Instance()
+
+	// Apply the block in a transaction which is discarded
+	diffs, err := store.OrdIdx.DryRunBlock(hookBlock)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, DryRunBlockResult{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Create and send success response
+	c.JSON(http.StatusOK, DryRunBlockResult{
+		Code:    200,
+		Message: "Success",
+		Data:    diffs,
+	})
+}
@@ -14,11 +14,15 @@ func RegisterRoutes(r *gin.Engine) {
 			eg.GET("/blockbyheight", GetBlockByHeight)
 			eg.GET("/blockbyhash", GetBlockByHash)
 			eg.GET("/blocks", GetBlocks)
+			eg.GET("/blockdiff", GetBlockDiff)
 			eg.GET("/addressinfo", GetAddressInfo)
 			eg.GET("/addressbalance", GetAddressBalance)
 			eg.GET("/addressbalances", GetAddressBalances)
 			eg.GET("/inscription", GetInscription)
 			eg.POST("/miningprofitchart", MiningProfitChart)
+			eg.POST("/miningsimulation", SubmitMiningSimulation)
+			eg.GET("/miningsimulationjob", GetMiningSimulation)
+			eg.POST("/cancelminingsimulation", CancelMiningSimulation)
 			eg.GET("/inscriptionplus", GetInscriptionPlus)
 			eg.GET("/allmrc721", GetAllMrc721)
 			eg.GET("/onemrc721", GetOneMrc721)
@@ -26,6 +30,7 @@ func RegisterRoutes(r *gin.Engine) {
 			eg.GET("/addressmrc721bar", GetAddressMrc721Bar)
 			eg.GET("/mrc721collections", GetMrc721CollectionsHandler)
 			eg.GET("/validatename", ValidateMRCName)
+			eg.POST("/deploylint", LintMRC721Deploy)
 			eg.GET("/genesisprotocol", GetGenesisMRC721ProtocolHandler)
 			eg.GET("/addressmrc20bar", GetAddressMrc20Bar)
 			eg.GET("/addressmrc20list", GetAddressMrc20List)
@@ -34,13 +39,25 @@ func RegisterRoutes(r *gin.Engine) {
 			eg.GET("/scanmissingblocks", ScanMissingBlocks)
 			eg.GET("/genesisdata", GetGenesisData)
 			eg.GET("/burninfo", GetBurnInfo)
+			eg.GET("/burnplan", GetBurnPlan)
 			eg.GET("/mrcallinscription", GetMrcAllInscription)
 			eg.GET("/lotterylist", GetLotteryList)
+			eg.GET("/inscriptionverdict", GetInscriptionVerdict)
+			eg.POST("/inscriptionpreview", PreviewInscription)
+			eg.GET("/transferstate", GetMrc20TransferState)
+			eg.GET("/addresstransfers", GetAddressMrc20Transfers)
+			eg.GET("/minerstakes", GetMinerStakes)
+			eg.GET("/minerdelegation", GetMinerDelegation)
+			eg.GET("/lotteryproof", GetLotteryProof)
+			eg.GET("/lotterystats", GetLotteryStats)
+			eg.GET("/addresslotterywins", GetAddressLotteryWins)
+			eg.GET("/miningprojection", GetMiningProjection)
 
 			eg.POST("/postrecord", PostRecord)
 			eg.GET("/getrecords", GetRecords)
 
 			eg.POST("/hookevents", ordHookEvents)
+			eg.POST("/dryrunblock", DryRunBlock)
 
 			// eg.POST("/GetMockBlock", GetMockBlock)
 			// eg.POST("/WriteMockBlock", WriteMockBlock)
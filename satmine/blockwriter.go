@@ -4,10 +4,10 @@ package satmine
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
 
 	"github.com/dgraph-io/badger/v4"
-	jsoniter "github.com/json-iterator/go"
 	"go.uber.org/zap"
 )
 
@@ -15,14 +15,17 @@ import (
 // retrieval and management of Ordinal information within the Bitcoin blockchain. It efficiently organizes and provides
 // quick access to Ordinal data, aiding in operations like tracking, searching, and analysis of specific Ordinals.
 type BTOrdIdx struct {
-	db     *badger.DB
-	rwLock sync.RWMutex
+	db       *badger.DB
+	rwLock   sync.RWMutex
+	registry *mrc721Registry     // Parsed MRC-721 collections, used by the write path
+	verdict  *InscriptionVerdict // Verdict of the inscription being applied, see verdicts.go
 }
 
 // NewBTOrdIdx initializes a new instance of BTOrdIdx with a given Manager.
 func NewBTOrdIdx(db *badger.DB) *BTOrdIdx {
 	return &BTOrdIdx{
-		db: db,
+		db:       db,
+		registry: newMrc721Registry(),
 	}
 }
 
@@ -84,43 +87,16 @@ func (b *BTOrdIdx) WriteBlock(newBlock *HookBlock) (err error) {
 		}
 		for _, block := range blocks {
 			logger.Info(fmt.Sprintf("make block %s", block.BlockHeight))
-			// Write the list of newly inscribed inscriptions into the KV database.
-			if err := b.addInscriptionList(txn, block); err != nil {
-				return err
-			}
-
-			// Add the inscription transfer list to the key-value store data.
-			if err := b.addTransferList(txn, block); err != nil {
-				return err
-			}
-
-			//Mining using MRC721 inscriptions.
-			if err := b.mineWithMrc721Inscription(txn, block); err != nil {
-				return err
-			}
-
-			// lotteryWithBlockHash conducts a lottery draw based on a given block hash.
-			if err := b.lotteryWithBlockHash(txn, block); err != nil {
-				return err
-			}
-
-			// Serialize the block to JSON using jsoniter
-			blockJSON, err := jsoniter.Marshal(block)
+			diff, err := b.applyBlockDiff(txn, block)
 			if err != nil {
-				logger.Error("Failed to marshal block: ", zap.Error(err))
 				return err
 			}
-			// Write the serialized block to the database
-			if err := txn.Set([]byte("latestblock"), []byte(block.BlockHeight)); err != nil {
-				return err
-			}
-			if err := txn.Set([]byte("block::"+block.BlockHeight), blockJSON); err != nil {
-				return err
-			}
-			if err := txn.Set([]byte("bkhash::"+block.BlockHash), []byte(block.BlockHeight)); err != nil {
+
+			// Keep what the block changed, for the services following the state
+			if err := putBlockDiff(txn, &diff); err != nil {
+				logger.Error("Failed to write block diff: ", zap.Error(err))
 				return err
 			}
-
 		}
 		// Additional transaction operations can be added here
 
@@ -128,11 +104,135 @@ func (b *BTOrdIdx) WriteBlock(newBlock *HookBlock) (err error) {
 	})
 
 	if err != nil {
+		// Nothing was written, forget the collections cached while processing the block
+		b.registry.discard()
+		b.rollbackProtocolHandlers()
 		logger.Error("WriteBlock: ", zap.Error(err))
 		return err
 	}
+	b.registry.commit()
 
 	//logger.Info("Block successful num:", zap.String("BlockHeight", block.BlockHeight))
 
 	return
 }
+
+// maxDryRunGap is the number of empty blocks a dry run fills before its block, block writes wait for all of them.
+const maxDryRunGap = 6
+
+// DryRunBlock applies a block on top of the latest block like WriteBlock, in a transaction which is always
+// discarded, and returns the state changed by the block and by the empty blocks filling the gap before it, in order.
+// A block more than maxDryRunGap blocks ahead of the latest block is refused.
+func (b *BTOrdIdx) DryRunBlock(newBlock *HookBlock) ([]WebBlockDiff, error) {
+	b.rwLock.Lock()
+	defer b.rwLock.Unlock()
+
+	filterBlock, err := b.filterBlockData(newBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := b.db.NewTransaction(true)
+	defer txn.Discard()
+	defer func() {
+		// Nothing is written, forget the collections cached while processing the blocks
+		b.registry.discard()
+		b.rollbackProtocolHandlers()
+	}()
+
+	if err := b.checkBlockContinuity(txn, filterBlock); err != nil {
+		return nil, err
+	}
+	latest, err := getStateValue(txn, "latestblock")
+	if err != nil {
+		return nil, err
+	}
+	if latest.Found {
+		lastHeight, err := strconv.Atoi(string(latest.Value))
+		if err != nil {
+			return nil, err
+		}
+		height, err := strconv.Atoi(filterBlock.BlockHeight)
+		if err != nil {
+			return nil, err
+		}
+		if height-lastHeight-1 > maxDryRunGap {
+			return nil, fmt.Errorf("block %d is more than %d blocks ahead of the latest block %d", height, maxDryRunGap+1, lastHeight)
+		}
+	}
+	err, blocks := b.fillMissingBlocks(txn, filterBlock)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]WebBlockDiff, 0, len(blocks))
+	for _, block := range blocks {
+		diff, err := b.applyBlockDiff(txn, block)
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", block.BlockHeight, err)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// applyBlock applies a block on top of the state of txn: the inscriptions and transfers of the block, its mining and
+// its lottery draws, under the rules in force at its height.
+func (b *BTOrdIdx) applyBlock(txn *badger.Txn, block *HookBlock) error {
+	// Protocol rules in force at this height
+	height, err := strconv.ParseInt(block.BlockHeight, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block height %s: %w", block.BlockHeight, err)
+	}
+	if err := b.useRules(txn, height); err != nil {
+		return err
+	}
+
+	// Write the list of newly inscribed inscriptions into the KV database.
+	if err := b.addInscriptionList(txn, block); err != nil {
+		return err
+	}
+
+	// Add the inscription transfer list to the key-value store data.
+	if err := b.addTransferList(txn, block); err != nil {
+		return err
+	}
+
+	//Mining using MRC721 inscriptions.
+	if err := b.mineWithMrc721Inscription(txn, block); err != nil {
+		return err
+	}
+
+	// lotteryWithBlockHash conducts a lottery draw based on a given block hash.
+	if err := b.lotteryWithBlockHash(txn, block); err != nil {
+		return err
+	}
+
+	// Serialize the block to JSON using jsoniter
+	blockJSON, err := marshalBlock(txn, block)
+	if err != nil {
+		logger.Error("Failed to marshal block: ", zap.Error(err))
+		return err
+	}
+	// Write the serialized block to the database
+	if err := setState(txn, []byte("latestblock"), []byte(block.BlockHeight)); err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("block::"+block.BlockHeight), blockJSON); err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("bkhash::"+block.BlockHash), []byte(block.BlockHeight)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// applyBlockDiff applies a block like applyBlock and returns the state it changed.
+func (b *BTOrdIdx) applyBlockDiff(txn *badger.Txn, block *HookBlock) (WebBlockDiff, error) {
+	recordStateChanges(txn)
+	err := b.applyBlock(txn, block)
+	changes := stopStateChanges(txn)
+	if err != nil {
+		return WebBlockDiff{}, err
+	}
+	return changes.blockDiff(txn, block)
+}
@@ -0,0 +1,123 @@
+// filePath: satmine/burnledger.go
+
+package satmine
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Mrc20BurnRecord is one accepted MRC-20 burn, kept in the burn ledger.
+type Mrc20BurnRecord struct {
+	InscriptionID string `json:"inscription_id"` // The burn inscription
+	Burner        string `json:"burner"`         // Address whose balance was burnt
+	Target        string `json:"target"`         // MRC-721 miner boosted by the burn
+	Mrc721name    string `json:"mrc721name"`     // Collection of the target
+	Tick          string `json:"tick"`
+	Amount        string `json:"amount"`
+	BlockHeight   string `json:"block_height"`
+}
+
+// tickMinerCollection returns the collection emitting tick if target is one of its miners, an empty name otherwise.
+// Burns and stakes can only target such miners.
+func tickMinerCollection(txn *badger.Txn, tick, target string) (string, error) {
+	item, err := txn.Get([]byte("mrc20::geninsc::" + tick))
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	mrc721Name := ""
+	err = item.Value(func(val []byte) error {
+		mrc721Name = string(val)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = txn.Get([]byte("mrc721::name_inscr::" + mrc721Name + "::" + target))
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return mrc721Name, nil
+}
+
+// recordBurn adds an accepted burn to the ledger, to the burn total of its target and to the one of its collection.
+// The collection of a burn indexed before rules.BurnTargets is the one emitting its tick, if any.
+// mrc20::burn::[inscription_id] -> Mrc20BurnRecord
+// mrc721::burn_ledger::[target_inscription_id]::[inscription_id] -> nil
+func (b *BTOrdIdx) recordBurn(txn *badger.Txn, record *Mrc20BurnRecord, amount *big.Int) error {
+	recordJSON, err := jsoniter.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("mrc20::burn::"+record.InscriptionID), recordJSON); err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("mrc721::burn_ledger::"+record.Target+"::"+record.InscriptionID), nil); err != nil {
+		return err
+	}
+
+	// Tokens burnt for the target, stored as big-endian bytes like the mining power
+	burnNum, err := getBurnNum(txn, record.Target)
+	if err != nil {
+		return err
+	}
+	burnNum.Add(burnNum, amount)
+	if err := setState(txn, []byte("mrc721::burn::"+record.Target), burnNum.Bytes()); err != nil {
+		return err
+	}
+
+	// Tokens burnt for the whole collection
+	if record.Mrc721name == "" {
+		return nil
+	}
+	collection, err := b.mrc721Collection(txn, record.Mrc721name)
+	if err != nil {
+		return fmt.Errorf("unknown MRC-721 collection %s: %w", record.Mrc721name, err)
+	}
+	genesisData := collection.Genesis
+	totalBurn, ok := new(big.Int).SetString(genesisData.TotalBurn, 10)
+	if !ok {
+		return fmt.Errorf("invalid total burn of %s: %q", record.Mrc721name, genesisData.TotalBurn)
+	}
+	genesisData.TotalBurn = totalBurn.Add(totalBurn, amount).String()
+	return b.putMrc721Genesis(txn, &genesisData, nil)
+}
+
+// getBurnLedger lists the burns that boosted an MRC-721 miner, in the order of their inscription IDs.
+func getBurnLedger(txn *badger.Txn, target string) ([]Mrc20BurnRecord, error) {
+	var burnIDs []string
+	err := scanPrefix(txn, "mrc721::burn_ledger::"+target+"::", func(key string, val []byte) error {
+		burnIDs = append(burnIDs, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Mrc20BurnRecord, 0, len(burnIDs))
+	for _, burnID := range burnIDs {
+		item, err := txn.Get([]byte("mrc20::burn::" + burnID))
+		if err != nil {
+			return nil, err
+		}
+		var record Mrc20BurnRecord
+		err = item.Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &record)
+		})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,184 @@
+// filePath: satmine/burnplan.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// maxPaybackBlocks bounds the payback search of a collection whose emission never ends.
+const maxPaybackBlocks = 10000000
+
+// WebBurnPlan is the burn bringing a miner to a planned power and what it changes in its mining earnings, against
+// the current total power of the collection. Lottery prizes are left out.
+type WebBurnPlan struct {
+	InscriptionID        string `json:"inscription_id"`
+	Mrc721name           string `json:"mrc721name"`
+	Mrc20name            string `json:"mrc20name"`
+	Unit                 string `json:"unit"`
+	Boost                string `json:"boost"`
+	BurnAmount           string `json:"burn_amount"` // Tokens burnt for the miner so far
+	Power                string `json:"power"`
+	MaxPower             string `json:"max_power"`
+	TargetPower          string `json:"target_power,omitempty"`
+	Budget               string `json:"budget,omitempty"`
+	PlannedPower         string `json:"planned_power"` // Power reached, at most TargetPower or what Budget buys
+	BurnNeeded           string `json:"burn_needed"`   // Fewest tokens to burn to reach PlannedPower
+	TotalPower           string `json:"total_power"`
+	BlockEarnings        string `json:"block_earnings"` // Earnings of the next block at the current power
+	PlannedBlockEarnings string `json:"planned_block_earnings"`
+	BlockEarningsChange  string `json:"block_earnings_change"`
+	PaybackBlocks        string `json:"payback_blocks"` // Blocks until the extra earnings repay BurnNeeded, empty if they never do
+	PaybackHeight        string `json:"payback_height"`
+}
+
+// GetBurnPlan returns the burn a miner needs to reach targetPower, or what burning budget tokens brings it, with the
+// change in its per-block earnings and the payback period of the burn. Exactly one of targetPower and budget is set.
+func (b *BTOrdIdx) GetBurnPlan(inscriptionID, targetPower, budget string) (WebBurnPlan, error) {
+	targetPower, budget = strings.TrimSpace(targetPower), strings.TrimSpace(budget)
+	if (targetPower == "") == (budget == "") {
+		return WebBurnPlan{}, errors.New("either a target power or a budget is required")
+	}
+	limit, ok := new(big.Int).SetString(targetPower+budget, 10)
+	if !ok || limit.Sign() < 0 {
+		return WebBurnPlan{}, fmt.Errorf("invalid target power or budget: %q", targetPower+budget)
+	}
+
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	plan := WebBurnPlan{InscriptionID: inscriptionID, TargetPower: targetPower, Budget: budget}
+	err := b.db.View(func(txn *badger.Txn) error {
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("inscription %s is not a miner", inscriptionID)
+		}
+		if err != nil {
+			return err
+		}
+		o, err := loadEmissionOutlook(txn, ckpt.Name)
+		if err != nil {
+			return err
+		}
+		if o.protocol.Burn == nil {
+			return fmt.Errorf("collection %s has no burn section", ckpt.Name)
+		}
+		rules := LatestRules()
+		plan.Mrc721name = ckpt.Name
+		plan.Mrc20name = o.protocol.Token.Tick
+		plan.Unit = o.protocol.Burn.Unit
+		plan.Boost = o.protocol.Burn.Boost
+		plan.Power = ckpt.Power
+		plan.MaxPower = strconv.FormatInt(rules.MaxMinerPower, 10)
+
+		burnNum, err := getBurnNum(txn, inscriptionID)
+		if err != nil {
+			return err
+		}
+		stakeNum, err := getStakeNum(txn, inscriptionID)
+		if err != nil {
+			return err
+		}
+		plan.BurnAmount = burnNum.String()
+		power, _ := new(big.Int).SetString(ckpt.Power, 10)
+		unitBigInt, _ := new(big.Int).SetString(o.protocol.Burn.Unit, 10)
+		boostBigInt := stringToPercentageBigInt(o.protocol.Burn.Boost)
+		if unitBigInt == nil || unitBigInt.Sign() <= 0 || boostBigInt.Sign() <= 0 {
+			return fmt.Errorf("collection %s has an invalid burn section", ckpt.Name)
+		}
+
+		// The power planned is the target, or what the budget buys on top of the burns so far
+		planned := limit
+		if budget != "" {
+			planned = computeMinerPower(rules, o.protocol, new(big.Int).Add(burnNum, limit), stakeNum)
+		} else if planned.Cmp(big.NewInt(rules.MaxMinerPower)) > 0 {
+			return fmt.Errorf("target power %s is above the maximum power %d", targetPower, rules.MaxMinerPower)
+		}
+		if planned.Cmp(power) < 0 {
+			planned = new(big.Int).Set(power)
+		}
+		plan.PlannedPower = planned.String()
+
+		// Power grows by boost for every unit burnt in total, above the power without burns
+		burnNeeded := big.NewInt(0)
+		if planned.Cmp(power) > 0 {
+			units := new(big.Int).Sub(planned, computeMinerPower(rules, o.protocol, big.NewInt(0), stakeNum))
+			units.Add(units, new(big.Int).Sub(boostBigInt, big.NewInt(1)))
+			units.Div(units, boostBigInt)
+			burnNeeded.Mul(units, unitBigInt)
+			burnNeeded.Sub(burnNeeded, burnNum)
+			if burnNeeded.Sign() < 0 {
+				burnNeeded.SetInt64(0)
+			}
+		}
+		plan.BurnNeeded = burnNeeded.String()
+
+		// Earnings of the next block before and after the burn
+		pool, err := getRewardPool(txn, ckpt.Name)
+		if err != nil {
+			return err
+		}
+		_, totalPower := pool.totals()
+		plannedTotal := new(big.Int).Sub(totalPower, power)
+		plannedTotal.Add(plannedTotal, planned)
+		plan.TotalPower = totalPower.String()
+		_, blockMined, _, _ := o.releasedAt(o.currentHeight + 1)
+		share := func(mined, power, total *big.Int) *big.Int {
+			if total.Sign() == 0 {
+				return big.NewInt(0)
+			}
+			earnings := new(big.Int).Mul(mined, power)
+			return earnings.Div(earnings, total)
+		}
+		blockEarnings := share(blockMined, power, totalPower)
+		plannedEarnings := share(blockMined, planned, plannedTotal)
+		plan.BlockEarnings = blockEarnings.String()
+		plan.PlannedBlockEarnings = plannedEarnings.String()
+		plan.BlockEarningsChange = new(big.Int).Sub(plannedEarnings, blockEarnings).String()
+
+		// The burn is repaid once the miners have mined burnNeeded / (planned / plannedTotal - power / totalPower)
+		if burnNeeded.Sign() == 0 {
+			plan.PaybackBlocks = "0"
+			plan.PaybackHeight = strconv.FormatInt(o.currentHeight, 10)
+			return nil
+		}
+		gain := new(big.Int).Mul(planned, totalPower)
+		gain.Sub(gain, new(big.Int).Mul(power, plannedTotal))
+		if gain.Sign() <= 0 {
+			return nil
+		}
+		required := new(big.Int).Mul(burnNeeded, plannedTotal)
+		required.Mul(required, totalPower)
+		required.Add(required, new(big.Int).Sub(gain, big.NewInt(1)))
+		required.Div(required, gain)
+
+		low, high := o.currentHeight+1, o.currentHeight+maxPaybackBlocks
+		if o.isEnded {
+			high = o.endHeight - 1
+		}
+		if _, mined, _, _ := o.releasedAt(high); high < low || mined.Cmp(required) < 0 {
+			return nil
+		}
+		for low < high {
+			middle := low + (high-low)/2
+			if _, mined, _, _ := o.releasedAt(middle); mined.Cmp(required) >= 0 {
+				high = middle
+			} else {
+				low = middle + 1
+			}
+		}
+		plan.PaybackBlocks = strconv.FormatInt(low-o.currentHeight, 10)
+		plan.PaybackHeight = strconv.FormatInt(low, 10)
+		return nil
+	})
+	if err != nil {
+		return WebBurnPlan{}, err
+	}
+	return plan, nil
+}
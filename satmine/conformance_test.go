@@ -0,0 +1,31 @@
+package satmine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"satmine/conformance"
+)
+
+// TestConformance replays every conformance vector, conformance imports satmine so the test lives in satmine_test.
+func TestConformance(t *testing.T) {
+	vectors, err := conformance.LoadVectors(filepath.Join("..", "conformance", "vectors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no conformance vectors")
+	}
+	for _, vector := range vectors {
+		vector := vector
+		t.Run(vector.Name, func(t *testing.T) {
+			result := conformance.Run(vector)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			for _, diff := range result.Diffs {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// filePath: satmine/contentstore.go
+
+package satmine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Inscription bodies are kept in a content-addressed store, separate from the inscription metadata:
+//
+//	content::<sha256 hex> -> raw content bytes
+//
+// HookInscription values under "inscr::<id>" and the inscriptions inside "block::<height>" only carry the
+// ContentHash, so ownership updates and block records no longer rewrite the body. Identical bodies are stored once.
+// Records written before the content store existed still embed ContentByte and are read as they are, until the
+// content_store migration moves their bodies into the store.
+
+// contentKey returns the key of the content store entry for the given hash.
+func contentKey(hash string) []byte {
+	return []byte("content::" + hash)
+}
+
+// hashContent returns the hex encoded SHA-256 hash used to address an inscription body.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// putContent stores an inscription body in the content store if it is not there yet and returns its hash.
+func putContent(txn *badger.Txn, content []byte) (string, error) {
+	hash := hashContent(content)
+	if err := putHashedContent(txn, hash, content); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// putHashedContent stores an inscription body whose hash is already known, if it is not there yet.
+func putHashedContent(txn *badger.Txn, hash string, content []byte) error {
+	key := contentKey(hash)
+
+	// Deduplicate: an identical body is already stored under the same hash
+	_, err := txn.Get(key)
+	if err == nil {
+		return nil
+	}
+	if err != badger.ErrKeyNotFound {
+		return err
+	}
+
+	if err := setState(txn, key, content); err != nil {
+		logger.Error("Failed to write inscription content: ", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// getContent loads an inscription body from the content store.
+func getContent(txn *badger.Txn, hash string) ([]byte, error) {
+	item, err := txn.Get(contentKey(hash))
+	if err != nil {
+		return nil, fmt.Errorf("inscription content %s: %w", hash, err)
+	}
+	return item.ValueCopy(nil)
+}
+
+// stripContent moves the body of the inscription into the content store and returns a copy
+// of the inscription carrying only the content hash. The body of an inscription which already carries its hash, read
+// back from an index, is not hashed again: it is only stored under that hash if the store lacks it, as when stored
+// blocks are replayed into another index.
+func stripContent(txn *badger.Txn, inscription *HookInscription) (HookInscription, error) {
+	meta := *inscription
+	if meta.ContentByte == nil {
+		return meta, nil
+	}
+	if meta.ContentHash != "" {
+		if err := putHashedContent(txn, meta.ContentHash, *meta.ContentByte); err != nil {
+			return HookInscription{}, err
+		}
+	} else {
+		hash, err := putContent(txn, *meta.ContentByte)
+		if err != nil {
+			return HookInscription{}, err
+		}
+		meta.ContentHash = hash
+	}
+	meta.ContentByte = nil
+	return meta, nil
+}
+
+// hydrateContent fills ContentByte of an inscription from the content store.
+// Legacy records that still embed the body are left untouched.
+func hydrateContent(txn *badger.Txn, inscription *HookInscription) error {
+	if inscription.ContentByte != nil || inscription.ContentHash == "" {
+		return nil
+	}
+	content, err := getContent(txn, inscription.ContentHash)
+	if err != nil {
+		return err
+	}
+	inscription.ContentByte = &content
+	return nil
+}
+
+// marshalInscription stores the body of the inscription in the content store and
+// returns the metadata JSON to be written under "inscr::<id>".
+func marshalInscription(txn *badger.Txn, inscription *HookInscription) ([]byte, error) {
+	meta, err := stripContent(txn, inscription)
+	if err != nil {
+		return nil, err
+	}
+	return jsoniter.Marshal(meta)
+}
+
+// unmarshalInscription decodes an "inscr::<id>" value and loads its body from the content store.
+func unmarshalInscription(txn *badger.Txn, val []byte, inscription *HookInscription) error {
+	if err := jsoniter.Unmarshal(val, inscription); err != nil {
+		return err
+	}
+	return hydrateContent(txn, inscription)
+}
+
+// unmarshalInscriptionMeta decodes an "inscr::<id>" value without loading its body from the content store, for the
+// callers which only need the metadata. Legacy records still embedding their body are decoded with it.
+func unmarshalInscriptionMeta(val []byte, inscription *HookInscription) error {
+	return jsoniter.Unmarshal(val, inscription)
+}
+
+// marshalBlock returns the JSON written under "block::<height>", with the inscription bodies
+// replaced by their content hashes. The block itself is not modified.
+func marshalBlock(txn *badger.Txn, block *HookBlock) ([]byte, error) {
+	stored := *block
+	stored.Inscriptions = make([]HookInscription, len(block.Inscriptions))
+	for i := range block.Inscriptions {
+		meta, err := stripContent(txn, &block.Inscriptions[i])
+		if err != nil {
+			return nil, err
+		}
+		stored.Inscriptions[i] = meta
+	}
+	return jsoniter.Marshal(stored)
+}
+
+// unmarshalBlock decodes a "block::<height>" value and loads the inscription bodies from the content store.
+func unmarshalBlock(txn *badger.Txn, val []byte, block *HookBlock) error {
+	if err := jsoniter.Unmarshal(val, block); err != nil {
+		return err
+	}
+	for i := range block.Inscriptions {
+		if err := hydrateContent(txn, &block.Inscriptions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateContent moves the bodies still embedded in the "inscr::<id>" and "block::<height>" records into the content
+// store, then compacts the database to reclaim the space they took. The records are rewritten in batches, a write
+// batch commits as many transactions as it needs.
+func (b *BTOrdIdx) migrateContent() error {
+	batch := b.db.NewWriteBatch()
+	stored := make(map[string]bool) // Bodies in the content store, or written by the batch
+	strip := func(txn *badger.Txn, inscription *HookInscription) (bool, error) {
+		if inscription.ContentByte == nil {
+			return false, nil
+		}
+		hash := hashContent(*inscription.ContentByte)
+		if !stored[hash] {
+			_, err := txn.Get(contentKey(hash))
+			if err == badger.ErrKeyNotFound {
+				err = batch.Set(contentKey(hash), *inscription.ContentByte)
+			}
+			if err != nil {
+				return false, err
+			}
+			stored[hash] = true
+		}
+		inscription.ContentHash = hash
+		inscription.ContentByte = nil
+		return true, nil
+	}
+
+	inscriptions, blocks := 0, 0
+	err := b.db.View(func(txn *badger.Txn) error {
+		err := scanPrefix(txn, "inscr::", func(key string, val []byte) error {
+			if strings.HasPrefix(key, "number::") {
+				return nil // inscr::number::[number] -> [inscription_id]
+			}
+			var inscription HookInscription
+			if err := jsoniter.Unmarshal(val, &inscription); err != nil {
+				return err
+			}
+			stripped, err := strip(txn, &inscription)
+			if err != nil || !stripped {
+				return err
+			}
+			inscriptionJSON, err := jsoniter.Marshal(inscription)
+			if err != nil {
+				return err
+			}
+			inscriptions++
+			return batch.Set([]byte("inscr::"+key), inscriptionJSON)
+		})
+		if err != nil {
+			return err
+		}
+
+		return scanPrefix(txn, "block::", func(key string, val []byte) error {
+			var block HookBlock
+			if err := jsoniter.Unmarshal(val, &block); err != nil {
+				return err
+			}
+			changed := false
+			for i := range block.Inscriptions {
+				stripped, err := strip(txn, &block.Inscriptions[i])
+				if err != nil {
+					return err
+				}
+				changed = changed || stripped
+			}
+			if !changed {
+				return nil
+			}
+			blockJSON, err := jsoniter.Marshal(block)
+			if err != nil {
+				return err
+			}
+			blocks++
+			return batch.Set([]byte("block::"+key), blockJSON)
+		})
+	})
+	if err != nil {
+		batch.Cancel()
+		return err
+	}
+	if err := batch.Flush(); err != nil {
+		return err
+	}
+	logger.Info("Moved inscription bodies into the content store",
+		zap.Int("inscriptions", inscriptions), zap.Int("blocks", blocks), zap.Int("bodies", len(stored)))
+	if inscriptions == 0 && blocks == 0 {
+		return nil
+	}
+
+	// The rewritten records leave their old versions behind until the tables and the value log are compacted
+	if err := b.db.Flatten(1); err != nil {
+		return err
+	}
+	for b.db.RunValueLogGC(0.5) == nil {
+	}
+	return nil
+}
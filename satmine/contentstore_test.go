@@ -0,0 +1,174 @@
+package satmine
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// openTestIndex opens an index over an empty in-memory database.
+func openTestIndex(t *testing.T) *BTOrdIdx {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewBTOrdIdx(db)
+}
+
+func TestMigrateContent(t *testing.T) {
+	b := openTestIndex(t)
+	legacyBody := []byte(`{"p":"mrc-20","op":"transfer","tick":"alph","amt":"1"}`)
+	currentBody := []byte(`{"p":"mrc-20","op":"transfer","tick":"alph","amt":"2"}`)
+	legacy := HookInscription{ID: "legacyi0", Number: 1, Address: "bc1qlegacy", ContentByte: &legacyBody, ContentType: "text/plain"}
+	current := HookInscription{ID: "currenti0", Number: 2, Address: "bc1qcurrent", ContentByte: &currentBody, ContentType: "text/plain"}
+
+	// The legacy records embed their body, as written before the content store existed
+	err := b.db.Update(func(txn *badger.Txn) error {
+		legacyJSON, err := jsoniter.Marshal(legacy)
+		if err != nil {
+			return err
+		}
+		blockJSON, err := jsoniter.Marshal(HookBlock{BlockHeight: "100", BlockHash: "h100", Inscriptions: []HookInscription{legacy}})
+		if err != nil {
+			return err
+		}
+		currentJSON, err := marshalInscription(txn, &current)
+		if err != nil {
+			return err
+		}
+		for key, val := range map[string][]byte{
+			"inscr::legacyi0":  legacyJSON,
+			"block::100":       blockJSON,
+			"inscr::currenti0": currentJSON,
+			"inscr::number::1": []byte("legacyi0"),
+			"latestblock":      []byte("100"),
+		} {
+			if err := txn.Set([]byte(key), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkBodies := func(stage string) {
+		t.Helper()
+		for id, body := range map[string][]byte{"legacyi0": legacyBody, "currenti0": currentBody} {
+			inscription, err := b.GetInscription(id)
+			if err != nil {
+				t.Fatalf("%s: read %s: %v", stage, id, err)
+			}
+			if inscription.ContentByte == nil || !bytes.Equal(*inscription.ContentByte, body) {
+				t.Fatalf("%s: body of %s is not restored", stage, id)
+			}
+		}
+		block, err := b.GetBlockByHeight("100")
+		if err != nil {
+			t.Fatalf("%s: read block: %v", stage, err)
+		}
+		if len(block.Inscriptions) != 1 || block.Inscriptions[0].ContentByte == nil || !bytes.Equal(*block.Inscriptions[0].ContentByte, legacyBody) {
+			t.Fatalf("%s: body of the block inscription is not restored", stage)
+		}
+	}
+
+	checkBodies("before migration")
+	if err := b.RunMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	checkBodies("after migration")
+
+	// The migrated records only carry the hash of their body
+	err = b.db.View(func(txn *badger.Txn) error {
+		for _, key := range []string{"inscr::legacyi0", "block::100"} {
+			item, err := txn.Get([]byte(key))
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if bytes.Contains(val, []byte(`"content_byte":"`)) {
+				t.Errorf("%s still embeds its body: %s", key, val)
+			}
+			if !bytes.Contains(val, []byte(hashContent(legacyBody))) {
+				t.Errorf("%s does not carry the hash of its body: %s", key, val)
+			}
+		}
+		stored, err := getContent(txn, hashContent(legacyBody))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(stored, legacyBody) {
+			t.Errorf("content store holds %q", stored)
+		}
+		_, err = txn.Get(migrationKey("content_store"))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalInscriptionKeepsHash(t *testing.T) {
+	b := openTestIndex(t)
+	body := []byte(`{"p":"mrc-20","op":"transfer","tick":"alph","amt":"3"}`)
+	hydrated := HookInscription{ID: "hydratedi0", Address: "bc1qowner", ContentByte: &body, ContentHash: "storedhash"}
+
+	// A rewrite of an inscription read with its body does not hash the body again, it is only stored under its hash if missing
+	err := b.db.Update(func(txn *badger.Txn) error {
+		val, err := marshalInscription(txn, &hydrated)
+		if err != nil {
+			return err
+		}
+		var meta HookInscription
+		if err := unmarshalInscriptionMeta(val, &meta); err != nil {
+			return err
+		}
+		if meta.ContentByte != nil || meta.ContentHash != "storedhash" {
+			t.Errorf("rewritten record is %s", val)
+		}
+		if _, err := txn.Get(contentKey(hashContent(body))); err != badger.ErrKeyNotFound {
+			t.Errorf("body hashed again: %v", err)
+		}
+		if content, err := getContent(txn, "storedhash"); err != nil || !bytes.Equal(content, body) {
+			t.Errorf("body missing under its hash: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReindexStoresContent(t *testing.T) {
+	src := openTestIndex(t)
+	block := &HookBlock{
+		BlockHeight:  "800000",
+		BlockHash:    "hash800000",
+		Timestamp:    1700000000,
+		Inscriptions: []HookInscription{testInscription(1, 800000, "bc1qdeployer", testMinerContent)},
+	}
+	if err := src.WriteBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored blocks are read back with their hashes, the bodies must still reach the new index
+	dst := openTestIndex(t)
+	if _, err := dst.ReindexFrom(src, -1); err != nil {
+		t.Fatal(err)
+	}
+	inscription, err := dst.GetInscription("test1i0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inscription.ContentByte == nil || !bytes.Equal(*inscription.ContentByte, testMinerContent) {
+		t.Fatal("body of the reindexed inscription is missing")
+	}
+}
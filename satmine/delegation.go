@@ -0,0 +1,202 @@
+// filePath: satmine/delegation.go
+
+package satmine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// The owner of a miner can redirect its rewards to another address with a delegate op, for example to keep the
+// miner in a cold wallet. The delegation ends with a delegate op without "to", or when the miner changes hands:
+//
+//	mrc721::delegate::<inscription_id>                    -> Mrc721Delegation
+//	mrc721::delegated_to::<delegate>::<inscription_id>    -> nil
+
+// Mrc721Delegation redirects the rewards of a miner to another address than its owner.
+type Mrc721Delegation struct {
+	InscriptionID string `json:"inscription_id"` // The miner
+	Owner         string `json:"owner"`          // Owner of the miner who delegated it
+	Delegate      string `json:"delegate"`       // Address credited with the rewards of the miner
+	DelegateID    string `json:"delegate_id"`    // The delegate inscription
+	BlockHeight   string `json:"block_height"`
+}
+
+// WebMinerDelegation tells where the rewards of a miner go.
+type WebMinerDelegation struct {
+	InscriptionID string            `json:"inscription_id"`
+	Owner         string            `json:"owner"`
+	RewardAddress string            `json:"reward_address"` // The delegate, or the owner when the miner is not delegated
+	Delegation    *Mrc721Delegation `json:"delegation"`     // Nil when the miner is not delegated
+}
+
+// delegationKey returns the key of the delegation of a miner.
+func delegationKey(inscriptionID string) []byte {
+	return []byte("mrc721::delegate::" + inscriptionID)
+}
+
+// getMinerDelegation reads the delegation of a miner, nil if it has none.
+func getMinerDelegation(txn *badger.Txn, inscriptionID string) (*Mrc721Delegation, error) {
+	item, err := txn.Get(delegationKey(inscriptionID))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var delegation Mrc721Delegation
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &delegation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// revokeMinerDelegation ends the delegation of a miner, if any. The caller settles the miner first.
+func revokeMinerDelegation(txn *badger.Txn, inscriptionID string) error {
+	delegation, err := getMinerDelegation(txn, inscriptionID)
+	if err != nil || delegation == nil {
+		return err
+	}
+	if err := deleteState(txn, delegationKey(inscriptionID)); err != nil {
+		return err
+	}
+	return deleteState(txn, []byte("mrc721::delegated_to::"+delegation.Delegate+"::"+inscriptionID))
+}
+
+// rewardAddress returns the address credited with the rewards of a miner: its delegate, or its owner.
+func rewardAddress(txn *badger.Txn, inscriptionID string) (string, error) {
+	delegation, err := getMinerDelegation(txn, inscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if delegation != nil {
+		return delegation.Delegate, nil
+	}
+	return getInscriptionAddress(txn, inscriptionID)
+}
+
+// rewardedInscriptions lists the inscriptions whose rewards go to an address: the ones it owns and did not
+// delegate, then the ones delegated to it.
+func rewardedInscriptions(txn *badger.Txn, address string) []string {
+	var ids []string
+	for _, inscriptionID := range ownedInscriptions(txn, address) {
+		if _, err := txn.Get(delegationKey(inscriptionID)); err == badger.ErrKeyNotFound {
+			ids = append(ids, inscriptionID)
+		}
+	}
+
+	prefix := []byte("mrc721::delegated_to::" + address + "::")
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		ids = append(ids, strings.TrimPrefix(string(it.Item().Key()), string(prefix)))
+	}
+	return ids
+}
+
+// delegateMrc20 redirects the future rewards of the miner named by a delegate inscription, or ends its delegation
+// when the inscription has no "to" or names the owner.
+func (b *BTOrdIdx) delegateMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInscription, mrc20Data *MRC20Protocol) error {
+	if mrc20Data.Insc == nil {
+		b.rejectInscription(ReasonMissingTarget, "delegate does not name an inscription")
+		return nil
+	}
+
+	// Check if the token exists
+	if _, err := txn.Get([]byte("mrc20::geninsc::" + mrc20Data.Tick)); err != nil {
+		b.ignoreInscription(ReasonUnknownTick, "no collection mines "+mrc20Data.Tick)
+		return nil // Token does not exist, no error, stop execution
+	}
+
+	mrc721Name, err := tickMinerCollection(txn, mrc20Data.Tick, *mrc20Data.Insc)
+	if err != nil {
+		return err
+	}
+	if mrc721Name == "" {
+		b.rejectInscription(ReasonInvalidTarget, fmt.Sprintf("%s is not a miner of the collection emitting %s", *mrc20Data.Insc, mrc20Data.Tick))
+		return nil
+	}
+	owner, err := getInscriptionAddress(txn, *mrc20Data.Insc)
+	if err != nil {
+		return err
+	}
+	if owner != inscr.Address {
+		b.rejectInscription(ReasonNotOwner, fmt.Sprintf("%s is owned by %s", *mrc20Data.Insc, owner))
+		return nil
+	}
+
+	// Rewards mined so far belong to the current reward address
+	if err := b.settleMiner(txn, *mrc20Data.Insc); err != nil {
+		return err
+	}
+	if err := revokeMinerDelegation(txn, *mrc20Data.Insc); err != nil {
+		return err
+	}
+	if mrc20Data.To == nil || *mrc20Data.To == owner {
+		return nil
+	}
+
+	delegation := Mrc721Delegation{
+		InscriptionID: *mrc20Data.Insc,
+		Owner:         owner,
+		Delegate:      *mrc20Data.To,
+		DelegateID:    inscr.ID,
+		BlockHeight:   block.BlockHeight,
+	}
+	delegationJSON, err := jsoniter.Marshal(delegation)
+	if err != nil {
+		return err
+	}
+	if err := setState(txn, delegationKey(delegation.InscriptionID), delegationJSON); err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("mrc721::delegated_to::"+delegation.Delegate+"::"+delegation.InscriptionID), nil); err != nil {
+		return err
+	}
+
+	// The delegate holds a balance from now on, like the owner of a miner
+	collection, err := b.mrc721Collection(txn, mrc721Name)
+	if err != nil {
+		return err
+	}
+	if collection.Genesis.TotalMinedTokens == collection.Protocol.Token.Total {
+		return nil
+	}
+	return ensureBalance(txn, delegation.Delegate, collection.Genesis.Tick)
+}
+
+// GetMinerDelegation returns the owner of a miner and the address credited with its rewards.
+func (b *BTOrdIdx) GetMinerDelegation(inscriptionID string) (WebMinerDelegation, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	result := WebMinerDelegation{InscriptionID: strings.TrimSpace(inscriptionID)}
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		result.Owner, err = getInscriptionAddress(txn, result.InscriptionID)
+		if err != nil {
+			return err
+		}
+		result.Delegation, err = getMinerDelegation(txn, result.InscriptionID)
+		if err != nil {
+			return err
+		}
+		result.RewardAddress = result.Owner
+		if result.Delegation != nil {
+			result.RewardAddress = result.Delegation.Delegate
+		}
+		return nil
+	})
+	if err != nil {
+		return WebMinerDelegation{}, err
+	}
+	return result, nil
+}
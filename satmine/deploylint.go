@@ -0,0 +1,196 @@
+// filePath: satmine/deploylint.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ReasonNameTaken is reported by the deploy lint for a draft using the name of an existing collection, whatever its
+// case: inscribed, it mints a miner of that collection or is rejected with ReasonGenesisMismatch.
+const ReasonNameTaken = "name_taken"
+
+// WebLintIssue is a problem found in a draft MRC-721 deploy.
+type WebLintIssue struct {
+	Section string `json:"section"` // Section of the protocol, empty for the whole document
+	Reason  string `json:"reason"`  // Reason of the verdict the inscription would get, see verdicts.go
+	Message string `json:"message"`
+}
+
+// WebDeployEmission summarises the emission of a draft collection, in blocks after its deploy block.
+type WebDeployEmission struct {
+	FirstBlockMined     string `json:"first_block_mined"` // Emission of the deploy block distributed to the miners
+	FirstBlockPrizePool string `json:"first_block_prize_pool"`
+	FirstBlockFee       string `json:"first_block_fee"`
+	EndBlocks           string `json:"end_blocks"`           // Blocks after the deploy block when mining ends, empty if it never ends
+	EndReason           string `json:"end_reason"`           // FullRelease or NotFullyReleased
+	HalfReleasedBlocks  string `json:"half_released_blocks"` // Blocks from the deploy block until half the total is released
+	MinedTokens         string `json:"mined_tokens"`         // Released to the miners until the end
+	PrizePoolTokens     string `json:"prize_pool_tokens"`
+	FeeTokens           string `json:"fee_tokens"`
+	Unreleased          string `json:"unreleased"` // Part of the total never released
+}
+
+// WebDeployLint is the outcome of linting a draft MRC-721 deploy under the latest rules.
+type WebDeployLint struct {
+	Valid      bool               `json:"valid"`    // The deploy would create a new collection
+	Issues     []WebLintIssue     `json:"issues"`   // Every problem found, each one enough to prevent the deploy
+	Warnings   []WebLintIssue     `json:"warnings"` // Parts of the deploy the latest rules ignore
+	Mrc721name string             `json:"mrc721name,omitempty"`
+	Tick       string             `json:"tick,omitempty"`
+	Protocol   *MRC721Protocol    `json:"protocol,omitempty"` // Normalised protocol, as the indexer reads it
+	Emission   *WebDeployEmission `json:"emission,omitempty"`
+}
+
+// LintMRC721Deploy checks a draft MRC-721 deploy under the latest rules and returns every problem at once: rule
+// violations, and the name and tick collisions with existing collections. The normalised protocol and a summary of
+// its emission are included whenever they can be derived.
+func (b *BTOrdIdx) LintMRC721Deploy(data []byte) WebDeployLint {
+	rules := LatestRules()
+	lint := WebDeployLint{Issues: []WebLintIssue{}, Warnings: []WebLintIssue{}}
+	addIssue := func(section, reason, message string) {
+		lint.Issues = append(lint.Issues, WebLintIssue{Section: section, Reason: reason, Message: message})
+	}
+	addWarning := func(section, message string) {
+		lint.Warnings = append(lint.Warnings, WebLintIssue{Section: section, Message: message})
+	}
+
+	if err := rules.checkStrict(data, mrc721StrictFields); err != nil {
+		var strictErr *StrictJSONError
+		if errors.As(err, &strictErr) {
+			addIssue("", strictErr.Reason, err.Error())
+		} else {
+			addIssue("", ReasonInvalidData, err.Error())
+		}
+	}
+
+	// The rules apply to the document as inscribed
+	var raw MRC721Protocol
+	if err := jsoniter.Unmarshal(data, &raw); err != nil {
+		addIssue("", ReasonParseError, err.Error())
+		return lint
+	}
+	for _, issue := range mrc721Issues(&raw, rules) {
+		addIssue(issue.section, ReasonInvalidData, issue.err.Error())
+	}
+
+	protocol, err := parseMRC721Protocol(data, rules)
+	if err != nil {
+		addIssue("", ReasonParseError, err.Error())
+		return lint
+	}
+	lint.Protocol = protocol
+	lint.Mrc721name = protocol.Miner.GetUpperName()
+	lint.Tick = protocol.Token.GetLowerTick()
+
+	// Sections and fields the indexer does not honour yet
+	if protocol.Stake != nil && !rules.Staking {
+		addWarning("stake", "the stake section is ignored until staking is enabled")
+	}
+	if protocol.Fee != nil && !rules.CreatorFee {
+		addWarning("fee", "the fee section is ignored until creator fees are enabled")
+	}
+	if protocol.Ltry != nil && !rules.LotteryDraws && (protocol.Ltry.Tiers != "" || protocol.Ltry.Weight != "") {
+		addWarning("ltry", "lottery Tiers and Weight are ignored until lottery draws are enabled")
+	}
+
+	// Collisions with the collections deployed so far
+	b.rwLock.RLock()
+	err = b.db.View(func(txn *badger.Txn) error {
+		var genesisData Mrc721GenesisData
+		item, err := txn.Get([]byte("mrc721::geninsc::" + lint.Mrc721name))
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				return jsoniter.Unmarshal(val, &genesisData)
+			}); err != nil {
+				return err
+			}
+			outcome := "it would be rejected as not matching the genesis inscription"
+			if existing, err := b.genesisProtocol(txn, genesisData.ID); err == nil && isEqual721Protocol(existing, protocol) {
+				outcome = "it would mint a miner of that collection"
+			}
+			addIssue("miner", ReasonNameTaken, fmt.Sprintf("name %q is taken by the collection %q deployed by inscription %s, %s",
+				protocol.Miner.Name, genesisData.PrevName, genesisData.ID, outcome))
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		item, err = txn.Get([]byte("mrc20::geninsc::" + lint.Tick))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if string(val) != lint.Mrc721name {
+				addIssue("token", ReasonTickTaken, fmt.Sprintf("tick %q is mined by the collection %s", lint.Tick, string(val)))
+			}
+			return nil
+		})
+	})
+	b.rwLock.RUnlock()
+	if err != nil {
+		addIssue("", ReasonInvalidData, err.Error())
+	}
+
+	lint.Emission = deployEmission(protocol, rules)
+	lint.Valid = len(lint.Issues) == 0
+	return lint
+}
+
+// genesisProtocol parses the protocol of a genesis inscription.
+func (b *BTOrdIdx) genesisProtocol(txn *badger.Txn, genesisID string) (*MRC721Protocol, error) {
+	var inscription HookInscription
+	item, err := txn.Get([]byte("inscr::" + genesisID))
+	if err != nil {
+		return nil, err
+	}
+	if err := item.Value(func(val []byte) error {
+		return unmarshalInscription(txn, val, &inscription)
+	}); err != nil {
+		return nil, err
+	}
+	return ParseMRC721Protocol(*inscription.ContentByte)
+}
+
+// deployEmission summarises the emission of a draft protocol, nil if its token section cannot be scheduled.
+func deployEmission(protocol *MRC721Protocol, rules *ProtocolRules) *WebDeployEmission {
+	scheduled := *protocol
+	if scheduled.Fee != nil && !rules.CreatorFee {
+		scheduled.Fee = nil
+	}
+	total, ok := new(big.Int).SetString(scheduled.Token.Total, 10)
+	if !ok || total.Sign() < 0 {
+		return nil
+	}
+
+	// The deploy block is the first block mined
+	o := &emissionOutlook{protocol: &scheduled, currentHeight: -1}
+	if err := o.init(0, total); err != nil {
+		return nil
+	}
+	emission := &WebDeployEmission{EndReason: o.endReason}
+	_, mined, prizePool, fee := o.releasedAt(0)
+	emission.FirstBlockMined = mined.String()
+	emission.FirstBlockPrizePool = prizePool.String()
+	emission.FirstBlockFee = fee.String()
+	if o.isEnded {
+		emission.EndBlocks = strconv.FormatInt(o.endHeight, 10)
+		released, mined, prizePool, fee := o.releasedAt(o.endHeight)
+		emission.MinedTokens = mined.String()
+		emission.PrizePoolTokens = prizePool.String()
+		emission.FeeTokens = fee.String()
+		emission.Unreleased = new(big.Int).Sub(total, released).String()
+	}
+	if half, ok := o.schedule.HeightReaching(new(big.Int).Div(total, big.NewInt(2))); ok && (!o.isEnded || half < o.endHeight) {
+		emission.HalfReleasedBlocks = strconv.FormatInt(half+1, 10)
+	}
+	return emission
+}
@@ -0,0 +1,231 @@
+// filePath: satmine/emission.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// maxEmissionRounds bounds the number of halving rounds kept by an EmissionSchedule. With the smallest non-zero
+// reduction ratio (0.001) the per-block emission reaches zero after a few thousand rounds per order of magnitude of beg.
+const maxEmissionRounds = 1 << 20
+
+// EmissionSchedule holds the per-block emission of a MRC-721 collection for every halving round, computed once from
+// the protocol with the same truncating integer arithmetic as the mining loop: the emission of round r+1 is
+// emission(r) * (1000 - dcr) / 1000. Rounds are only stored while their emission is non-zero, so the emission and
+// the cumulative emission at any height are answered without replaying the halvings.
+//
+// The schedule ignores the total supply: the remaining supply depends on the indexed state and is applied by the caller.
+type EmissionSchedule struct {
+	genesisHeight int64
+	halv          int64
+	poolRate      *big.Int   // Lottery extraction per mille, zero without a lottery
+	feeRate       *big.Int   // Creator fee per mille of what the lottery leaves, zero without a fee
+	rounds        []*big.Int // Per-block emission of each round with a non-zero emission
+	roundStart    []*big.Int // Cumulative emission before each round, one more entry than rounds
+	poolStart     []*big.Int // Cumulative lottery extraction before each round, one more entry than rounds
+	feeStart      []*big.Int // Cumulative creator fee before each round, one more entry than rounds
+	perpetual     bool       // The reduction ratio is zero, the emission of the first round never ends
+}
+
+// NewEmissionSchedule computes the emission schedule of a collection deployed at genesisHeight. The fee section is
+// taken as is: callers projecting under rules without creator fees drop it from the protocol first. A negative beg
+// gives a schedule without rounds.
+func NewEmissionSchedule(firstMrc721 *MRC721Protocol, genesisHeight int64) (*EmissionSchedule, error) {
+	beginBigInt, ok := new(big.Int).SetString(firstMrc721.Token.Beg, 10) // Number of tokens mined per block
+	if !ok {
+		return nil, fmt.Errorf("invalid token beg: %q", firstMrc721.Token.Beg)
+	}
+	// tokenIssues lets a negative beg through and the deploy is indexed: the collection mines nothing, like beg 0
+	if beginBigInt.Sign() < 0 {
+		beginBigInt.SetInt64(0)
+	}
+	halv, err := strconv.ParseInt(firstMrc721.Token.Halv, 10, 64) // Halving cycle
+	if err != nil || halv <= 0 {
+		return nil, fmt.Errorf("invalid token halv: %q", firstMrc721.Token.Halv)
+	}
+	dcrBigInt := stringToPercentageBigInt(firstMrc721.Token.Dcr) // Reduction ratio after each halving cycle
+	if dcrBigInt.Sign() < 0 || dcrBigInt.Cmp(big.NewInt(1000)) > 0 {
+		return nil, fmt.Errorf("invalid token dcr: %q", firstMrc721.Token.Dcr)
+	}
+	poolRate := big.NewInt(0)
+	if firstMrc721.Ltry != nil {
+		poolRate = stringToPercentageBigInt(firstMrc721.Ltry.Pool)
+		if poolRate.Sign() < 0 || poolRate.Cmp(big.NewInt(1000)) > 0 {
+			return nil, fmt.Errorf("invalid lottery pool: %q", firstMrc721.Ltry.Pool)
+		}
+	}
+	feeRate := big.NewInt(0)
+	if firstMrc721.Fee != nil {
+		feeRate = stringToPercentageBigInt(firstMrc721.Fee.Rate)
+		if feeRate.Sign() < 0 || feeRate.Cmp(big.NewInt(1000)) > 0 {
+			return nil, fmt.Errorf("invalid fee rate: %q", firstMrc721.Fee.Rate)
+		}
+	}
+
+	schedule := &EmissionSchedule{
+		genesisHeight: genesisHeight,
+		halv:          halv,
+		poolRate:      poolRate,
+		feeRate:       feeRate,
+		roundStart:    []*big.Int{big.NewInt(0)},
+		poolStart:     []*big.Int{big.NewInt(0)},
+		feeStart:      []*big.Int{big.NewInt(0)},
+		perpetual:     dcrBigInt.Sign() == 0 && beginBigInt.Sign() > 0,
+	}
+
+	factor := new(big.Int).Sub(big.NewInt(1000), dcrBigInt)
+	halvBigInt := big.NewInt(halv)
+	current := beginBigInt
+	for current.Sign() > 0 {
+		if len(schedule.rounds) == maxEmissionRounds {
+			return nil, errors.New("emission schedule has too many rounds")
+		}
+		last := len(schedule.rounds)
+		schedule.rounds = append(schedule.rounds, current)
+
+		roundTotal := new(big.Int).Mul(current, halvBigInt)
+		schedule.roundStart = append(schedule.roundStart, roundTotal.Add(roundTotal, schedule.roundStart[last]))
+		poolTotal := new(big.Int).Mul(schedule.poolPerBlock(current), halvBigInt)
+		schedule.poolStart = append(schedule.poolStart, poolTotal.Add(poolTotal, schedule.poolStart[last]))
+		feeTotal := new(big.Int).Mul(schedule.feePerBlock(current), halvBigInt)
+		schedule.feeStart = append(schedule.feeStart, feeTotal.Add(feeTotal, schedule.feeStart[last]))
+
+		if schedule.perpetual {
+			break
+		}
+		next := new(big.Int).Mul(current, factor)
+		current = next.Div(next, big.NewInt(1000))
+	}
+
+	return schedule, nil
+}
+
+// poolPerBlock returns the lottery extraction of a block emitting amount.
+func (s *EmissionSchedule) poolPerBlock(amount *big.Int) *big.Int {
+	prizePoolValue := new(big.Int).Mul(amount, s.poolRate)
+	return prizePoolValue.Div(prizePoolValue, big.NewInt(1000))
+}
+
+// feePerBlock returns the creator fee of a block emitting amount, taken from what the lottery extraction leaves.
+func (s *EmissionSchedule) feePerBlock(amount *big.Int) *big.Int {
+	feeValue := new(big.Int).Sub(amount, s.poolPerBlock(amount))
+	feeValue.Mul(feeValue, s.feeRate)
+	return feeValue.Div(feeValue, big.NewInt(1000))
+}
+
+// position returns the round of a height and its offset inside the round, ok is false before the genesis block.
+func (s *EmissionSchedule) position(height int64) (round, offset int64, ok bool) {
+	blockCount := height - s.genesisHeight
+	if blockCount < 0 {
+		return 0, 0, false
+	}
+	return blockCount / s.halv, blockCount % s.halv, true
+}
+
+// EmissionAt returns the number of tokens emitted by the block at height, before the lottery extraction.
+func (s *EmissionSchedule) EmissionAt(height int64) *big.Int {
+	round, _, ok := s.position(height)
+	if !ok {
+		// Same as the mining loop: no halving has happened yet
+		round = 0
+	}
+	if round < int64(len(s.rounds)) {
+		return new(big.Int).Set(s.rounds[round])
+	}
+	if s.perpetual {
+		return new(big.Int).Set(s.rounds[0])
+	}
+	return big.NewInt(0)
+}
+
+// CumulativeAt returns the number of tokens emitted from the genesis block up to and including height.
+func (s *EmissionSchedule) CumulativeAt(height int64) *big.Int {
+	round, offset, ok := s.position(height)
+	if !ok {
+		return big.NewInt(0)
+	}
+	if s.perpetual {
+		return new(big.Int).Mul(s.rounds[0], big.NewInt(round*s.halv+offset+1))
+	}
+	if round >= int64(len(s.rounds)) {
+		return new(big.Int).Set(s.roundStart[len(s.rounds)])
+	}
+	total := new(big.Int).Mul(s.rounds[round], big.NewInt(offset+1))
+	return total.Add(total, s.roundStart[round])
+}
+
+// PrizePoolAt returns the lottery extraction from the genesis block up to and including height,
+// ignoring the total supply.
+func (s *EmissionSchedule) PrizePoolAt(height int64) *big.Int {
+	return s.extractedAt(height, s.poolPerBlock, s.poolStart)
+}
+
+// FeeAt returns the creator fee from the genesis block up to and including height, ignoring the total supply.
+func (s *EmissionSchedule) FeeAt(height int64) *big.Int {
+	return s.extractedAt(height, s.feePerBlock, s.feeStart)
+}
+
+// extractedAt sums the per-block extraction perBlock from the genesis block up to and including height, start
+// holding the cumulative extraction before each round.
+func (s *EmissionSchedule) extractedAt(height int64, perBlock func(*big.Int) *big.Int, start []*big.Int) *big.Int {
+	round, offset, ok := s.position(height)
+	if !ok {
+		return big.NewInt(0)
+	}
+	if s.perpetual {
+		extracted := perBlock(s.rounds[0])
+		return extracted.Mul(extracted, big.NewInt(round*s.halv+offset+1))
+	}
+	if round >= int64(len(s.rounds)) {
+		return new(big.Int).Set(start[len(s.rounds)])
+	}
+	extracted := perBlock(s.rounds[round])
+	extracted.Mul(extracted, big.NewInt(offset+1))
+	return extracted.Add(extracted, start[round])
+}
+
+// EndHeight returns the first height whose emission is zero, ok is false if the emission never ends.
+func (s *EmissionSchedule) EndHeight() (height int64, ok bool) {
+	if s.perpetual {
+		return 0, false
+	}
+	return s.genesisHeight + int64(len(s.rounds))*s.halv, true
+}
+
+// HeightReaching returns the first height at which the cumulative emission reaches amount,
+// ok is false if it never does.
+func (s *EmissionSchedule) HeightReaching(amount *big.Int) (height int64, ok bool) {
+	if amount.Sign() <= 0 {
+		return s.genesisHeight, true
+	}
+
+	if s.perpetual {
+		// ceil(amount / beg) blocks
+		blocks := new(big.Int).Add(amount, s.rounds[0])
+		blocks.Sub(blocks, big.NewInt(1))
+		blocks.Div(blocks, s.rounds[0])
+		if !blocks.IsInt64() {
+			return 0, false
+		}
+		return s.genesisHeight + blocks.Int64() - 1, true
+	}
+
+	if amount.Cmp(s.roundStart[len(s.rounds)]) > 0 {
+		return 0, false
+	}
+
+	// The round in which the amount is reached, then the block inside it
+	round := sort.Search(len(s.rounds), func(i int) bool {
+		return s.roundStart[i+1].Cmp(amount) >= 0
+	})
+	missing := new(big.Int).Sub(amount, s.roundStart[round])
+	blocks := missing.Add(missing, s.rounds[round])
+	blocks.Sub(blocks, big.NewInt(1))
+	blocks.Div(blocks, s.rounds[round])
+	return s.genesisHeight + int64(round)*s.halv + blocks.Int64() - 1, true
+}
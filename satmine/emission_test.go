@@ -0,0 +1,46 @@
+package satmine
+
+import (
+	"strconv"
+	"testing"
+)
+
+// A deploy with a negative beg passes tokenIssues, its collection must be indexed without ever mining.
+func TestNegativeBegMinesNothing(t *testing.T) {
+	deploy := []byte(`{"p":"mrc-721","miner":{"name":"negx","max":"100","lim":"5"},"token":{"tick":"negx","total":"1000000","beg":"-1","halv":"3","dcr":"0.5"}}`)
+	protocol, err := ParseMRC721Protocol(deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := tokenIssues(protocol.Token, LatestRules()); len(errs) != 0 {
+		t.Fatalf("deploy is rejected: %v", errs)
+	}
+	schedule, err := NewEmissionSchedule(protocol, 800000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emission := schedule.EmissionAt(800001); emission.Sign() != 0 {
+		t.Fatalf("emission at 800001 is %s, want 0", emission)
+	}
+
+	b := openTestIndex(t)
+	blocks := []*HookBlock{
+		{Inscriptions: []HookInscription{testInscription(1, 800000, "bc1qdeployer", deploy)}},
+		{Inscriptions: []HookInscription{testInscription(2, 800001, "bc1qminer", deploy)}},
+		{},
+		{},
+	}
+	for i, block := range blocks {
+		block.BlockHeight = strconv.Itoa(800000 + i)
+		block.BlockHash = "hash" + block.BlockHeight
+		block.Timestamp = int64(1700000000 + 600*i)
+		if err := b.WriteBlock(block); err != nil {
+			t.Fatalf("block %s: %v", block.BlockHeight, err)
+		}
+	}
+	for key, balance := range snapshotBalances(t, b) {
+		if balance != "0" {
+			t.Errorf("balance %v is %s, want 0", key, balance)
+		}
+	}
+}
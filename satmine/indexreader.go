@@ -3,6 +3,7 @@
 package satmine
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -82,7 +83,7 @@ func (b *BTOrdIdx) GetBlockByHeight(blockHeight string) (*HookBlock, error) {
 
 		// Deserialize the block from the retrieved item
 		err = item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &block)
+			return unmarshalBlock(txn, val, &block)
 		})
 		if err != nil {
 			return err // Returning an error here will abort the transaction
@@ -284,6 +285,9 @@ func (b *BTOrdIdx) GetAddressBalance(address, tick string) (string, error) {
 			return err // Returning an error here will abort the transaction
 		}
 
+		// Include the mining rewards not settled yet
+		balance = unlockedEffectiveBalance(txn, address, tick, balance)
+
 		return nil // Returning nil commits the transaction
 	})
 
@@ -336,6 +340,9 @@ func (b *BTOrdIdx) GetAddressBalances(address string) (string, error) {
 				return err // Error while retrieving the value
 			}
 
+			// Include the mining rewards not settled yet
+			balance = unlockedEffectiveBalance(txn, address, tick, balance)
+
 			balances = append(balances, BalanceInfo{Tick: tick, Balance: balance})
 		}
 		return nil
@@ -378,7 +385,7 @@ func (b *BTOrdIdx) GetInscription(id string) (HookInscription, error) {
 
 		// Deserialize the inscription from the retrieved item
 		err = item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &inscription)
+			return unmarshalInscription(txn, val, &inscription)
 		})
 		if err != nil {
 			return err // Returning an error here will abort the transaction
@@ -395,7 +402,15 @@ func (b *BTOrdIdx) GetInscription(id string) (HookInscription, error) {
 	return inscription, nil
 }
 
+// GetMiningProfitChart simulates the mining of a collection deployed at block 0 with a single miner, every step
+// blocks up to max.
 func (b *BTOrdIdx) GetMiningProfitChart(firstMrc721 *MRC721Protocol, step, max int) (string, error) {
+	return simulateMiningProfitChart(context.Background(), firstMrc721, step, max, nil)
+}
+
+// simulateMiningProfitChart computes the mining profit chart, reporting the heights done out of the heights to
+// compute to progress if not nil. It stops with the error of ctx once ctx is done.
+func simulateMiningProfitChart(ctx context.Context, firstMrc721 *MRC721Protocol, step, max int, progress func(done, total int64)) (string, error) {
 
 	type MiningProfitChartResult struct {
 		EndHeight     int    `json:"end_height"`
@@ -404,108 +419,148 @@ func (b *BTOrdIdx) GetMiningProfitChart(firstMrc721 *MRC721Protocol, step, max i
 			BlockHeight        int    `json:"block_height"`
 			MinedFunds         string `json:"mined_funds"`
 			PrizePoolFunds     string `json:"prize_pool_funds"`
+			CreatorFeeFunds    string `json:"creator_fee_funds"`
 			TotalReleasedFunds string `json:"total_released_funds"`
 		} `json:"profit_details"`
 	}
 
 	var result MiningProfitChartResult
 
-	genesisData := Mrc721GenesisData{}
-	minerMap := Mrc721MinerMap{Data: make(map[string]*Mrc721MinerData)}
+	if step <= 0 {
+		return "{}", fmt.Errorf("invalid step: %d", step)
+	}
 
-	genesisData.Tick = firstMrc721.Token.Tick
-	genesisData.TotalPrizePoolTokens = "0"
-	genesisData.BlockHeight = "0"
-	genesisData.TotalMinedTokens = "0"
-	genesisData.InscriptionsCount = 1
+	// The fee section only takes its share once creator fees are enabled
+	if firstMrc721.Fee != nil && !LatestRules().CreatorFee {
+		withoutFee := *firstMrc721
+		withoutFee.Fee = nil
+		firstMrc721 = &withoutFee
+	}
 
-	minerMap.Data["address111111111"] = &Mrc721MinerData{
-		InscriptionsID:     "inc111111111",
-		InscriptionsNumber: 1,
-		Address:            "address111111111",
-		BurnNum:            "0",
-		Tick:               "demo",
-		MinedAmount:        "0",
-		Power:              *big.NewInt(1000),
+	// The chart mines from block 0 with a single miner, which always receives the whole emission
+	schedule, err := NewEmissionSchedule(firstMrc721, 0)
+	if err != nil {
+		return "{}", err
+	}
+	totalBigInt, ok := new(big.Int).SetString(firstMrc721.Token.Total, 10)
+	if !ok {
+		return "{}", fmt.Errorf("invalid token total: %q", firstMrc721.Token.Total)
 	}
 
-	for i := 0; i < max; i++ {
-		blockHeight := strconv.Itoa(i)
-		calcResult, err := CalculateMiningRewards(blockHeight, &genesisData, firstMrc721, &minerMap)
-		if err != nil {
-			return "{}", err
+	// Mining ends at the first block finding the supply fully released, or else at the first block emitting nothing
+	endHeight, endReason, isEnded := int64(0), "", false
+	if zeroHeight, ok := schedule.EndHeight(); ok {
+		endHeight, endReason, isEnded = zeroHeight, "NotFullyReleased", true
+	}
+	fullHeight, fullOk := int64(0), totalBigInt.Sign() <= 0
+	if !fullOk {
+		if reached, ok := schedule.HeightReaching(totalBigInt); ok {
+			fullHeight, fullOk = reached+1, true
 		}
+	}
+	if fullOk && (!isEnded || fullHeight <= endHeight) {
+		endHeight, endReason, isEnded = fullHeight, "FullRelease", true
+	}
 
-		prizePoolTokensBigInt, _ := new(big.Int).SetString(genesisData.TotalPrizePoolTokens, 10)
-		minedTokensBigInt, _ := new(big.Int).SetString(genesisData.TotalMinedTokens, 10)
-
-		currentPrizePoolTokensBigInt, _ := new(big.Int).SetString(calcResult.CurrentPrizePoolAllNum, 10)
-		currentMiningTokensBigInt, _ := new(big.Int).SetString(calcResult.CurrentMiningAllNum, 10)
+	// releasedAt returns the tokens mined by the miners, put into the prize pool and credited as creator fee
+	// up to and including height
+	releasedAt := func(height int64) (minedTokens, prizePoolTokens, feeTokens *big.Int) {
+		if height < 0 {
+			return big.NewInt(0), big.NewInt(0), big.NewInt(0)
+		}
+		released := schedule.CumulativeAt(height)
+		if released.Cmp(totalBigInt) <= 0 {
+			prizePoolTokens = schedule.PrizePoolAt(height)
+			feeTokens = schedule.FeeAt(height)
+		} else {
+			// The emission of the last block is capped by the remaining supply
+			released.Set(totalBigInt)
+			lastEmission := new(big.Int).Sub(totalBigInt, schedule.CumulativeAt(height-1))
+			prizePoolTokens = new(big.Int).Add(schedule.PrizePoolAt(height-1), schedule.poolPerBlock(lastEmission))
+			feeTokens = new(big.Int).Add(schedule.FeeAt(height-1), schedule.feePerBlock(lastEmission))
+		}
+		released.Sub(released, prizePoolTokens)
+		return released.Sub(released, feeTokens), prizePoolTokens, feeTokens
+	}
 
-		prizePoolTokensBigInt.Add(prizePoolTokensBigInt, currentPrizePoolTokensBigInt)
-		minedTokensBigInt.Add(minedTokensBigInt, currentMiningTokensBigInt)
+	formatFunds := func(funds string) string {
 
-		var allToken big.Int
-		allToken.Add(prizePoolTokensBigInt, minedTokensBigInt)
+		fundBigInt, ok := new(big.Int).SetString(funds, 10)
+		if !ok {
+			return "0.000"
+		}
 
-		// 将累加后的值转换为字符串格式
-		genesisData.TotalPrizePoolTokens = prizePoolTokensBigInt.String()
-		genesisData.TotalMinedTokens = minedTokensBigInt.String()
+		// 首先除以100000000取整
+		fundBigInt.Div(fundBigInt, big.NewInt(100000000))
 
-		//fmt.Println("GetMiningProfitChart TotalMinedTokens=", genesisData.TotalMinedTokens, calcResult.IsMiningEnd)
-		//fmt.Println("GetMiningProfitChart minerMap=", minerMap.Data["address111111111"].MinedAmount)
+		// 再次除以100000000并保留三位小数
+		fundFloat := new(big.Float).SetInt(fundBigInt)
+		fundFloat.Quo(fundFloat, big.NewFloat(100000000))
 
-		if i%step == 0 || calcResult.IsMiningEnd {
-			// Adding the profit details to the result slice
-			var profitDetail struct {
-				BlockHeight        int    `json:"block_height"`
-				MinedFunds         string `json:"mined_funds"`
-				PrizePoolFunds     string `json:"prize_pool_funds"`
-				TotalReleasedFunds string `json:"total_released_funds"`
-			}
+		// 格式化为字符串并保留三位小数
+		return fmt.Sprintf("%.3f", fundFloat)
+	}
 
-			profitDetail.BlockHeight = i
-			// profitDetail.MinedFunds = genesisData.TotalMinedTokens
-			// profitDetail.PrizePoolFunds = genesisData.TotalPrizePoolTokens
-			// allToken := new(big.Int).Add(prizePoolTokensBigInt, minedTokensBigInt)
-			// profitDetail.TotalReleasedFunds = allToken.String()
-			formatFunds := func(funds string) string {
+	addProfitDetail := func(height int64) {
+		// The block ending the mining releases nothing
+		releasedHeight := height
+		if isEnded && height >= endHeight {
+			releasedHeight = endHeight - 1
+		}
+		minedTokens, prizePoolTokens, feeTokens := releasedAt(releasedHeight)
 
-				fundBigInt, ok := new(big.Int).SetString(funds, 10)
-				if !ok {
-					return "0.000"
-				}
+		// Adding the profit details to the result slice
+		var profitDetail struct {
+			BlockHeight        int    `json:"block_height"`
+			MinedFunds         string `json:"mined_funds"`
+			PrizePoolFunds     string `json:"prize_pool_funds"`
+			CreatorFeeFunds    string `json:"creator_fee_funds"`
+			TotalReleasedFunds string `json:"total_released_funds"`
+		}
 
-				// 首先除以100000000取整
-				fundBigInt.Div(fundBigInt, big.NewInt(100000000))
+		profitDetail.BlockHeight = int(height)
+		// 使用formatFunds函数处理资金数值
+		profitDetail.MinedFunds = formatFunds(minedTokens.String())
+		profitDetail.PrizePoolFunds = formatFunds(prizePoolTokens.String())
+		profitDetail.CreatorFeeFunds = formatFunds(feeTokens.String())
+		allToken := new(big.Int).Add(prizePoolTokens, minedTokens)
+		allToken.Add(allToken, feeTokens)
+		profitDetail.TotalReleasedFunds = formatFunds(allToken.String())
 
-				// 再次除以100000000并保留三位小数
-				fundFloat := new(big.Float).SetInt(fundBigInt)
-				fundFloat.Quo(fundFloat, big.NewFloat(100000000))
+		result.ProfitDetails = append(result.ProfitDetails, profitDetail)
+	}
 
-				// 格式化为字符串并保留三位小数
-				return fmt.Sprintf("%.3f", fundFloat)
+	// Only the sampled heights are computed, each in logarithmic time
+	limit := int64(max)
+	if isEnded && endHeight < limit {
+		limit = endHeight + 1
+	}
+	total := (limit + int64(step) - 1) / int64(step)
+	for i, done := int64(0), int64(0); i < limit; i, done = i+int64(step), done+1 {
+		if done%profitChartBatch == 0 {
+			if err := ctx.Err(); err != nil {
+				return "{}", err
+			}
+			if progress != nil {
+				progress(done, total)
 			}
-
-			// 使用formatFunds函数处理资金数值
-			profitDetail.MinedFunds = formatFunds(genesisData.TotalMinedTokens)
-			profitDetail.PrizePoolFunds = formatFunds(genesisData.TotalPrizePoolTokens)
-			allToken := new(big.Int).Add(prizePoolTokensBigInt, minedTokensBigInt)
-			profitDetail.TotalReleasedFunds = formatFunds(allToken.String())
-
-			result.ProfitDetails = append(result.ProfitDetails, profitDetail)
 		}
-
-		if calcResult.IsMiningEnd {
-			result.EndHeight = i
-			result.EndReason = calcResult.EndReason
-			break
+		addProfitDetail(i)
+	}
+	if isEnded && endHeight < int64(max) {
+		if endHeight%int64(step) != 0 {
+			addProfitDetail(endHeight)
 		}
-
+		result.EndHeight = int(endHeight)
+		result.EndReason = endReason
 	}
 
 	//fmt.Println("result.ProfitDetails", result.ProfitDetails)
 
+	if progress != nil {
+		progress(total, total)
+	}
+
 	// Encoding the result to JSON using jsoniter
 	jsonBytes, err := jsoniter.Marshal(result)
 	if err != nil {
@@ -546,7 +601,7 @@ func (b *BTOrdIdx) GetInscriptionPlus(id string) (InscriptionPlus, error) {
 
 		// Deserialize the inscription from the retrieved item
 		err = item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &inscription)
+			return unmarshalInscription(txn, val, &inscription)
 		})
 		if err != nil {
 			return err // Returning an error here will abort the transaction
@@ -566,17 +621,13 @@ func (b *BTOrdIdx) GetInscriptionPlus(id string) (InscriptionPlus, error) {
 	inscriptionPlus.Power = "1000" // Set power value to "1000"
 
 	// Retrieve the burn value from the database
-	burnKey := "mrc721::burn::" + inscription.ID
 	err = b.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(burnKey))
+		burnNum, err := getBurnNum(txn, inscription.ID)
 		if err != nil {
-			inscriptionPlus.Burn = "0"
-			return nil
+			return err
 		}
-		return item.Value(func(val []byte) error {
-			inscriptionPlus.Burn = string(val)
-			return nil
-		})
+		inscriptionPlus.Burn = burnNum.String()
+		return nil
 	})
 	if err != nil {
 		logger.Error("Failed to get the burn value: ", zap.Error(err))
@@ -637,6 +688,7 @@ type Mrc721GenesisDataWeb struct {
 	PrizePoolTokens      string `json:"prize_pool_tokens"`       // The amount of tokens that have been added to the prize pool
 	TotalMinedTokens     string `json:"mined_tokens"`            // The amount of tokens that have been mined
 	TotalPrizePoolTokens string `json:"total_prize_pool_tokens"` // The cumulative total of tokens in the prize pool
+	TotalFeeTokens       string `json:"total_fee_tokens"`        // The part of the mined tokens credited to the creator fee recipient
 	Tick                 string `json:"tick"`                    // The token ticker brc20name
 	PrevTick             string `json:"previous_tick"`           // Previous The token ticker brc20name
 	Holders              int    `json:"holders"`                 // Total Holders
@@ -830,6 +882,7 @@ func (b *BTOrdIdx) GetAllMrc721() ([]Mrc721GenesisDataWeb, error) {
 					PrizePoolTokens:      genesisData.PrizePoolTokens,
 					TotalMinedTokens:     genesisData.TotalMinedTokens,
 					TotalPrizePoolTokens: genesisData.TotalPrizePoolTokens,
+					TotalFeeTokens:       totalFeeTokens(genesisData),
 					Tick:                 genesisData.Tick,
 					PrevTick:             genesisData.PrevTick,
 					Holders:              Holders,                // Calculate Holders
@@ -921,6 +974,7 @@ func (b *BTOrdIdx) GetOneMrc721(mrc721Name string) (Mrc721GenesisDataWeb, error)
 				PrizePoolTokens:      genesisData.PrizePoolTokens,
 				TotalMinedTokens:     genesisData.TotalMinedTokens,
 				TotalPrizePoolTokens: genesisData.TotalPrizePoolTokens,
+				TotalFeeTokens:       totalFeeTokens(genesisData),
 				Tick:                 genesisData.Tick,
 				PrevTick:             genesisData.PrevTick,
 				Holders:              calculateHolders(txn, mrc721Name),
@@ -971,7 +1025,7 @@ func (b *BTOrdIdx) GetAddressMrc721List(address string, mrc721name string, pageI
 					return err
 				}
 				return item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscription(txn, val, &hookInscription)
 				})
 			})
 			if err != nil {
@@ -1034,13 +1088,19 @@ func (b *BTOrdIdx) GetAddressMrc721List(address string, mrc721name string, pageI
 
 		// Fetch MinedAmount
 		err := b.db.View(func(txn *badger.Txn) error {
+			// Include the mining rewards not settled yet
+			pending, err := unlockedPendingMinerReward(txn, insc.ID)
+			if err != nil {
+				return err
+			}
+			minedAmount = pending
+
 			item, err := txn.Get([]byte("mrc721::inscr_miner::" + insc.ID))
 			if err != nil {
 				return err
 			}
 			return item.Value(func(val []byte) error {
-				minedAmount = new(big.Int)
-				minedAmount.SetBytes(val)
+				minedAmount = new(big.Int).Add(pending, new(big.Int).SetBytes(val))
 				return nil
 			})
 		})
@@ -1134,7 +1194,7 @@ func (b *BTOrdIdx) GetAddressMrc721Bar(address string) ([]string, error) {
 					return err
 				}
 				return item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscription(txn, val, &hookInscription)
 				})
 			})
 			if err != nil {
@@ -1209,7 +1269,7 @@ func (b *BTOrdIdx) GetMrc721Collections(mrc721name string) ([]WebCollections, er
 					return err
 				}
 				return item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscriptionMeta(val, &hookInscription)
 				})
 			})
 			if err != nil {
@@ -1309,7 +1369,7 @@ func (b *BTOrdIdx) GetGenesisMRC721Protocol(mrc721name string) (*MRC721Protocol,
 			return err
 		}
 		return item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &inscription)
+			return unmarshalInscription(txn, val, &inscription)
 		})
 	})
 	if err != nil {
@@ -1354,6 +1414,9 @@ func (b *BTOrdIdx) GetAddressMrc20Bar(address string, mrc20Name string) ([]WebMr
 				return err
 			}
 
+			// Include the mining rewards not settled yet
+			balance = unlockedEffectiveBalance(txn, address, tick, balance)
+
 			// Initialize total transfer amount as big.Int
 			totalTransferAmount := big.NewInt(0)
 
@@ -1372,7 +1435,7 @@ func (b *BTOrdIdx) GetAddressMrc20Bar(address string, mrc20Name string) ([]WebMr
 					return err
 				}
 				err = itemInscr.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscription(txn, val, &hookInscription)
 				})
 				if err != nil {
 					return err
@@ -1469,7 +1532,7 @@ func (b *BTOrdIdx) GetAddressMrc20List(address string, mrc20name string, pageInd
 					return err
 				}
 				return item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscription(txn, val, &hookInscription)
 				})
 			})
 			if err != nil {
@@ -1556,7 +1619,7 @@ func (b *BTOrdIdx) GetAddressMrc721BarPlus(address string) ([]WebMrc721Bar, erro
 					return fmt.Errorf(" GetAddressMrc721BarPlus error1 : %w", err)
 				}
 				return item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscription(txn, val, &hookInscription)
 				})
 			})
 			if err != nil {
@@ -1614,6 +1677,14 @@ func (b *BTOrdIdx) GetAddressMrc721BarPlus(address string) ([]WebMrc721Bar, erro
 
 			//  Accumulate reward (similar to power accumulation)
 			rewardBigInt, _ := new(big.Int).SetString(mrc721Bar.TotalReward, 10)
+			pendingReward, err := unlockedPendingMinerReward(txn, inscriptionID)
+			if err != nil {
+				return fmt.Errorf(" GetAddressMrc721BarPlus error5 : %w", err)
+			}
+			if pendingReward.Sign() > 0 {
+				rewardBigInt = rewardBigInt.Add(rewardBigInt, pendingReward)
+				mrc721Bar.TotalReward = rewardBigInt.String()
+			}
 			rewardItem, err := txn.Get([]byte("mrc721::inscr_miner::" + inscriptionID))
 			if err == nil {
 				err = rewardItem.Value(func(val []byte) error {
@@ -1885,7 +1956,7 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 			return fmt.Errorf("GetBurnInfo error1: %w", err)
 		}
 		return item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &hookInscription)
+			return unmarshalInscription(txn, val, &hookInscription)
 		})
 	})
 	if err != nil {
@@ -1926,7 +1997,7 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 			return fmt.Errorf("GetBurnInfo error5.2: %w", err)
 		}
 		return item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &genInscription)
+			return unmarshalInscription(txn, val, &genInscription)
 		})
 	})
 	if err != nil {
@@ -1942,6 +2013,7 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 
 	webBurnInfo.Mrc721name = mrc721name
 	webBurnInfo.Mrc20name = genInscMrc721.Token.Tick
+	webBurnInfo.TotalBurn = genesisData.TotalBurn
 	webBurnInfo.Unit = "0"
 	webBurnInfo.Boost = "0"
 	webBurnInfo.BurnMax = "0"
@@ -1952,7 +2024,7 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 		// Calculate BurnMax
 		unitBigInt, _ := new(big.Int).SetString(genInscMrc721.Burn.Unit, 10)
 		boostBigInt := stringToPercentageBigInt(genInscMrc721.Burn.Boost)
-		webBurnInfo.BurnMax = new(big.Int).Div(big.NewInt(11000), boostBigInt).Mul(unitBigInt, big.NewInt(10)).String()
+		webBurnInfo.BurnMax = new(big.Int).Div(big.NewInt(LatestRules().MaxMinerPower), boostBigInt).Mul(unitBigInt, big.NewInt(10)).String()
 
 		// webBurnInfo.BurnMax = unitBigInt.Mul(unitBigInt, big.NewInt(10)).String()
 
@@ -1964,10 +2036,17 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 		if err != nil {
 			return fmt.Errorf("GetBurnInfo error7: %w", err)
 		}
-		return balanceItem.Value(func(val []byte) error {
+		err = balanceItem.Value(func(val []byte) error {
 			webBurnInfo.Balance = string(val)
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+
+		// Include the mining rewards not settled yet
+		webBurnInfo.Balance = unlockedEffectiveBalance(txn, hookInscription.Address, genInscMrc721.Token.Tick, webBurnInfo.Balance)
+		return nil
 	})
 	if err != nil {
 		webBurnInfo.Balance = "0"
@@ -1991,20 +2070,18 @@ func (b *BTOrdIdx) GetBurnInfo(inscriptionID string) (WebBurnInfo, error) {
 		//return WebBurnInfo{}, fmt.Errorf("GetBurnInfo error10: %w", err)
 	}
 
-	// Retrieve burn amount
+	// Retrieve burn amount and the burns behind it
 	err = b.db.View(func(txn *badger.Txn) error {
-		burnAmountItem, err := txn.Get([]byte("mrc721::burn::" + inscriptionID))
+		burnAmount, err := getBurnNum(txn, inscriptionID)
 		if err != nil {
 			return fmt.Errorf("GetBurnInfo error11: %w", err)
 		}
-		return burnAmountItem.Value(func(val []byte) error {
-			burnAmountBigInt := new(big.Int).SetBytes(val)
-			webBurnInfo.BurnAmount = burnAmountBigInt.String()
-			return nil
-		})
+		webBurnInfo.BurnAmount = burnAmount.String()
+		webBurnInfo.Burns, err = getBurnLedger(txn, inscriptionID)
+		return err
 	})
 	if err != nil {
-		webBurnInfo.BurnAmount = "0"
+		return WebBurnInfo{}, fmt.Errorf("GetBurnInfo error12: %w", err)
 	}
 
 	return webBurnInfo, nil
@@ -2026,7 +2103,7 @@ func (b *BTOrdIdx) GetMrcAllInscription(inscriptionID string) (WebMrcAllInscript
 			return fmt.Errorf("GetMrcAllInscription error1: %w", err)
 		}
 		return item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &hookInscription)
+			return unmarshalInscription(txn, val, &hookInscription)
 		})
 	})
 	if err != nil {
@@ -2053,7 +2130,7 @@ func (b *BTOrdIdx) GetMrcAllInscription(inscriptionID string) (WebMrcAllInscript
 				return fmt.Errorf("GetMrcAllInscription error3: %w", err)
 			}
 			return item.Value(func(val []byte) error {
-				return jsoniter.Unmarshal(val, &genesisInscription)
+				return unmarshalInscription(txn, val, &genesisInscription)
 			})
 		})
 		if err != nil {
@@ -2291,9 +2368,12 @@ func (b *BTOrdIdx) GetLotteryList(mrc721name string) ([]LotteryData, error) {
 // UnlockedFindMrc721ImgID searches for the image ID of a given MRC-721 name by scanning through its inscriptions.
 // It tries to find a valid image source URL from the inscription's content.
 func (b *BTOrdIdx) unlockedFindMrc721ImgID(txn *badger.Txn, mrc721Name string) (string, error) {
+	// Collections may skip their first inscriptions, see the miner overrides of the protocol rules.
+	imageSkip := LatestRules().imageSkip(mrc721Name)
+
 	// Loop through inscription numbers from 0 to 100.
 	for i := 0; i <= 100; i++ {
-		if mrc721Name == "SATMINE" && i < 50 {
+		if i < imageSkip {
 			continue
 		}
 		// Construct the key for the current inscription count.
@@ -2330,7 +2410,7 @@ func (b *BTOrdIdx) unlockedFindMrc721ImgID(txn *badger.Txn, mrc721Name string) (
 		var hookInscription HookInscription
 		err = inscriptionItem.Value(func(val []byte) error {
 			// Unmarshal the value into a HookInscription object.
-			return jsoniter.Unmarshal(val, &hookInscription)
+			return unmarshalInscription(txn, val, &hookInscription)
 		})
 		if err != nil {
 			return "", err
@@ -0,0 +1,520 @@
+// filePath: satmine/lottery.go
+
+package satmine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// A lottery draw pays pool * dist to one miner picked uniformly by its "mrc721::count_inscr::" index, using the block
+// hash as the seed. Once rules.LotteryDraws is enabled, the ltry section can split the prize of a draw into tiers,
+// each paid to another miner, and weight the pick by mining power. A pick landing on a burnt miner, or on a miner
+// already paid by the draw, is drawn again with the next seed:
+//
+//	seed(tier, attempt) = block hash                                  for the first draw of the first tier
+//	                    = hex(sha256("<block hash>:<tier>:<attempt>")) otherwise
+//
+// A tier finding no eligible miner within maxLotteryDraws draws is not paid, its share stays in the prize pool.
+
+// maxLotteryDraws bounds the number of draws of one prize tier.
+const maxLotteryDraws = 16
+
+// maxLotteryTiers bounds the number of prize tiers of a draw.
+const maxLotteryTiers = 10
+
+// lotteryWeightPower is the ltry weight picking miners in proportion to their mining power.
+const lotteryWeightPower = "power"
+
+// LotteryProofMiner is a miner taking part in a draw, in the order of the draw.
+type LotteryProofMiner struct {
+	Index         int64  `json:"index"` // "mrc721::count_inscr::" index of the miner
+	InscriptionID string `json:"inscription_id"`
+	Power         string `json:"power,omitempty"` // Mining power at the draw, for a power-weighted draw only
+}
+
+// LotteryProofDraw is one draw of a prize tier.
+type LotteryProofDraw struct {
+	Attempt       int    `json:"attempt"`  // Number of draws made again before this one
+	Seed          string `json:"seed"`     // Hash seeding the draw, see lotterySeed
+	LuckNum       string `json:"luck_num"` // Seed modulo the luck number modulus
+	Index         int64  `json:"index"`    // Miner picked by the luck number
+	InscriptionID string `json:"inscription_id"`
+	Owner         string `json:"owner"`    // Owner of the miner at the draw
+	Eligible      bool   `json:"eligible"` // False when the pick is drawn again
+}
+
+// LotteryDrawProof is how a lottery win was derived, enough for VerifyLotteryDraw to recompute it.
+type LotteryDrawProof struct {
+	Mrc721name    string              `json:"mrc721name"`
+	Round         int                 `json:"round"`
+	Tier          int                 `json:"tier"` // Prize tier of the win, from 1
+	BlockHeight   string              `json:"block_height"`
+	BlockHash     string              `json:"block_hash"`
+	RandomWinp    string              `json:"random_winp"` // Block hash modulo 1000, the draw happens when it is at most Winp
+	Winp          string              `json:"winp"`        // Winning probability, per mille
+	Weighted      bool                `json:"weighted"`    // Miners are picked in proportion to their power
+	Modulus       string              `json:"modulus"`     // Luck number modulus: the number of miners, or their total power
+	Miners        []LotteryProofMiner `json:"miners"`      // Miners of the draw, in order
+	Redraw        bool                `json:"redraw"`      // Burnt miners and miners in Paid are drawn again
+	Paid          []string            `json:"paid"`        // Miners paid by the earlier tiers of the draw
+	Draws         []LotteryProofDraw  `json:"draws"`       // Draws of the tier, the last one winning
+	InscriptionID string              `json:"inscription_id"`
+	Address       string              `json:"address"`
+	PrizePool     string              `json:"prize_pool"` // Prize pool before the draw
+	Dist          string              `json:"dist"`       // Share of the prize pool paid by the draw, per mille
+	Share         string              `json:"share"`      // Share of the prize of the draw paid to the tier, per mille
+	WinAmount     string              `json:"win_amount"`
+	Reconstructed bool                `json:"reconstructed"` // Rebuilt from the lottery record of a round drawn before proofs were recorded
+}
+
+// WebLotteryProof is the proof of a lottery round and the outcome of its verification.
+type WebLotteryProof struct {
+	Proof       LotteryDrawProof `json:"proof"`
+	Verified    bool             `json:"verified"`
+	VerifyError string           `json:"verify_error,omitempty"`
+}
+
+// lotteryProofKey returns the key of the proof of a lottery round.
+func lotteryProofKey(mrc721Name string, round int) []byte {
+	return []byte(fmt.Sprintf("lottery::proof::%s::%d", mrc721Name, round))
+}
+
+// parseLotteryTiers parses the comma-separated tier shares of an ltry section into per mille values.
+func parseLotteryTiers(tiers string) ([]*big.Int, error) {
+	parts := strings.Split(tiers, ",")
+	if len(parts) > maxLotteryTiers {
+		return nil, fmt.Errorf("lottery Tiers must not have more than %d shares", maxLotteryTiers)
+	}
+	shares := make([]*big.Int, 0, len(parts))
+	sum := big.NewInt(0)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if err := validatePercentageField(part); err != nil {
+			return nil, err
+		}
+		share := stringToPercentageBigInt(part)
+		if share.Sign() == 0 {
+			return nil, errors.New("lottery Tiers shares must not be zero")
+		}
+		shares = append(shares, share)
+		sum.Add(sum, share)
+	}
+	if sum.Cmp(big.NewInt(1000)) > 0 {
+		return nil, errors.New("lottery Tiers shares must not add up to more than 1")
+	}
+	return shares, nil
+}
+
+// lotteryTierShares returns the share per mille of the prize of a draw paid to each tier: the whole prize to a
+// single tier unless the rules honour the tiers of the ltry section.
+func lotteryTierShares(rules *ProtocolRules, ltry *Lottery) []*big.Int {
+	if rules.LotteryDraws && ltry.Tiers != "" {
+		if shares, err := parseLotteryTiers(ltry.Tiers); err == nil { // Already validated by lotteryIssues
+			return shares
+		}
+	}
+	return []*big.Int{big.NewInt(1000)}
+}
+
+// lotterySeed returns the hash seeding a draw of a tier.
+func lotterySeed(blockHash string, tier, attempt int) string {
+	if tier == 0 && attempt == 0 {
+		return blockHash
+	}
+	sum := sha256.Sum256([]byte(blockHash + ":" + strconv.Itoa(tier) + ":" + strconv.Itoa(attempt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// drawLotteryWinner picks the winner of a tier among the first inscriptionsCount miners of a collection and returns
+// the proof of the pick, its last draw being the winner. Without rules.LotteryDraws the first pick wins whoever owns
+// it; otherwise picks owned by the burn address or listed in paid are drawn again, and the winner is nil when no
+// draw finds an eligible miner.
+func drawLotteryWinner(txn *badger.Txn, rules *ProtocolRules, mrc721Name string, inscriptionsCount int64, ltry *Lottery, blockHash string, tier int, paid []string) (*LotteryProofDraw, *LotteryDrawProof, error) {
+	proof := &LotteryDrawProof{
+		Mrc721name: mrc721Name,
+		Tier:       tier + 1,
+		BlockHash:  blockHash,
+		Weighted:   rules.LotteryDraws && ltry.Weight == lotteryWeightPower,
+		Modulus:    strconv.FormatInt(inscriptionsCount, 10),
+		Redraw:     rules.LotteryDraws,
+		Paid:       append([]string{}, paid...),
+	}
+	var powers []*big.Int
+	totalPower := big.NewInt(0)
+	if proof.Weighted {
+		var err error
+		proof.Miners, powers, err = lotteryMinerPowers(txn, mrc721Name, inscriptionsCount)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, power := range powers {
+			totalPower.Add(totalPower, power)
+		}
+		proof.Modulus = totalPower.String()
+	}
+
+	for attempt := 0; attempt < maxLotteryDraws; attempt++ {
+		seed := lotterySeed(blockHash, tier, attempt)
+
+		var luckNum *big.Int
+		var index int64
+		var err error
+		if proof.Weighted {
+			luckNum, index, err = pickByPower(seed, powers, totalPower)
+		} else {
+			luckNum, err = convertHashToBigInt(seed, inscriptionsCount)
+			if err == nil {
+				index = luckNum.Int64()
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		inscriptionID, err := lotteryMinerAt(txn, mrc721Name, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		address := lotteryMinerOwner(txn, inscriptionID)
+		if address == "" {
+			return nil, nil, errors.New("no luck address found")
+		}
+
+		proof.Draws = append(proof.Draws, LotteryProofDraw{
+			Attempt:       attempt,
+			Seed:          seed,
+			LuckNum:       luckNum.String(),
+			Index:         index,
+			InscriptionID: inscriptionID,
+			Owner:         address,
+			Eligible:      lotteryEligible(proof.Redraw, inscriptionID, address, paid),
+		})
+		if winner := &proof.Draws[len(proof.Draws)-1]; winner.Eligible {
+			return winner, proof, nil
+		}
+	}
+	return nil, proof, nil
+}
+
+// lotteryEligible tells whether a pick wins or is drawn again.
+func lotteryEligible(redraw bool, inscriptionID, owner string, paid []string) bool {
+	if !redraw {
+		return true
+	}
+	if owner == burnAddress {
+		return false
+	}
+	for _, paidID := range paid {
+		if paidID == inscriptionID {
+			return false
+		}
+	}
+	return true
+}
+
+// pickByPower returns the point drawn in [0, totalPower) and the index of the miner whose power range holds it.
+func pickByPower(seed string, powers []*big.Int, totalPower *big.Int) (*big.Int, int64, error) {
+	if !totalPower.IsInt64() || totalPower.Sign() <= 0 {
+		return nil, 0, fmt.Errorf("invalid total lottery power: %s", totalPower)
+	}
+	point, err := convertHashToBigInt(seed, totalPower.Int64())
+	if err != nil {
+		return nil, 0, err
+	}
+	rangeEnd := big.NewInt(0)
+	for index, power := range powers {
+		rangeEnd.Add(rangeEnd, power)
+		if point.Cmp(rangeEnd) < 0 {
+			return point, int64(index), nil
+		}
+	}
+	return nil, 0, errors.New("lottery point out of the power range")
+}
+
+// lotteryMinerPowers returns the first inscriptionsCount miners of a collection and their mining power, by index.
+// A miner not registered in the reward pool yet has the base power.
+func lotteryMinerPowers(txn *badger.Txn, mrc721Name string, inscriptionsCount int64) ([]LotteryProofMiner, []*big.Int, error) {
+	miners := make([]LotteryProofMiner, 0, inscriptionsCount)
+	powers := make([]*big.Int, 0, inscriptionsCount)
+	for index := int64(0); index < inscriptionsCount; index++ {
+		inscriptionID, err := lotteryMinerAt(txn, mrc721Name, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		power := big.NewInt(baseMinerPower)
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err != nil && err != badger.ErrKeyNotFound {
+			return nil, nil, err
+		}
+		if err == nil {
+			power.SetString(ckpt.Power, 10)
+		}
+		miners = append(miners, LotteryProofMiner{Index: index, InscriptionID: inscriptionID, Power: power.String()})
+		powers = append(powers, power)
+	}
+	return miners, powers, nil
+}
+
+// lotteryMinerAt returns the miner of a collection at a "mrc721::count_inscr::" index.
+func lotteryMinerAt(txn *badger.Txn, mrc721Name string, index int64) (string, error) {
+	item, err := txn.Get([]byte(fmt.Sprintf("mrc721::count_inscr::%s::%d", mrc721Name, index)))
+	if err != nil {
+		return "", err
+	}
+	var inscriptionID string
+	err = item.Value(func(val []byte) error {
+		inscriptionID = string(val)
+		return nil
+	})
+	return inscriptionID, err
+}
+
+// lotteryMinerOwner returns the owner of a miner from the "mrc721::inscr_addr::" index, empty if it has none.
+func lotteryMinerOwner(txn *badger.Txn, inscriptionID string) string {
+	prefix := []byte(fmt.Sprintf("mrc721::inscr_addr::%s::", inscriptionID))
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		return string(it.Item().Key()[len(prefix):])
+	}
+	return ""
+}
+
+// VerifyLotteryDraw recomputes a lottery win from the raw inputs of its proof: the block hash, the miners of the
+// draw in order, the owners of the picks and the prize pool. It needs no index, so anyone can audit a jackpot.
+func VerifyLotteryDraw(proof *LotteryDrawProof) error {
+	// The draw happens when the block hash modulo 1000 is at most Winp
+	randomWinp, err := convertHashToBigInt(proof.BlockHash, 1000)
+	if err != nil {
+		return err
+	}
+	if randomWinp.String() != proof.RandomWinp {
+		return fmt.Errorf("random winp is %s, not %s", randomWinp, proof.RandomWinp)
+	}
+	winp, ok := new(big.Int).SetString(proof.Winp, 10)
+	if !ok || randomWinp.Cmp(winp) > 0 {
+		return fmt.Errorf("random winp %s is above winp %s", randomWinp, proof.Winp)
+	}
+
+	// The luck number modulus is the number of miners, or their total power
+	if len(proof.Miners) == 0 {
+		return errors.New("draw has no miners")
+	}
+	powers := make([]*big.Int, len(proof.Miners))
+	modulus := big.NewInt(int64(len(proof.Miners)))
+	if proof.Weighted {
+		modulus.SetInt64(0)
+		for i, miner := range proof.Miners {
+			power, ok := new(big.Int).SetString(miner.Power, 10)
+			if !ok || power.Sign() < 0 {
+				return fmt.Errorf("invalid power of miner %d: %q", i, miner.Power)
+			}
+			powers[i] = power
+			modulus.Add(modulus, power)
+		}
+	}
+	if modulus.String() != proof.Modulus {
+		return fmt.Errorf("modulus is %s, not %s", modulus, proof.Modulus)
+	}
+
+	// Every draw but the last one lands on a miner drawn again
+	if len(proof.Draws) == 0 || len(proof.Draws) > maxLotteryDraws {
+		return fmt.Errorf("draw count %d is not between 1 and %d", len(proof.Draws), maxLotteryDraws)
+	}
+	for attempt, draw := range proof.Draws {
+		seed := lotterySeed(proof.BlockHash, proof.Tier-1, attempt)
+		if draw.Attempt != attempt || draw.Seed != seed {
+			return fmt.Errorf("draw %d is not seeded by %s", attempt, seed)
+		}
+		var luckNum *big.Int
+		index := int64(0)
+		if proof.Weighted {
+			luckNum, index, err = pickByPower(seed, powers, modulus)
+		} else {
+			luckNum, err = convertHashToBigInt(seed, modulus.Int64())
+			if err == nil {
+				index = luckNum.Int64()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if draw.LuckNum != luckNum.String() || draw.Index != index || draw.InscriptionID != proof.Miners[index].InscriptionID {
+			return fmt.Errorf("draw %d picks %s at index %d", attempt, proof.Miners[index].InscriptionID, index)
+		}
+		eligible := lotteryEligible(proof.Redraw, draw.InscriptionID, draw.Owner, proof.Paid)
+		if draw.Eligible != eligible || eligible != (attempt == len(proof.Draws)-1) {
+			return fmt.Errorf("draw %d is wrongly marked eligible=%t", attempt, draw.Eligible)
+		}
+	}
+	winner := proof.Draws[len(proof.Draws)-1]
+	if winner.InscriptionID != proof.InscriptionID || winner.Owner != proof.Address {
+		return fmt.Errorf("winner is %s owned by %s", winner.InscriptionID, winner.Owner)
+	}
+
+	// The tier gets its share of the prize of the draw
+	prizePool, ok1 := new(big.Int).SetString(proof.PrizePool, 10)
+	dist, ok2 := new(big.Int).SetString(proof.Dist, 10)
+	share, ok3 := new(big.Int).SetString(proof.Share, 10)
+	if !ok1 || !ok2 || !ok3 {
+		return errors.New("invalid prize pool, dist or share")
+	}
+	winAmount := prizePool.Mul(prizePool, dist)
+	winAmount.Div(winAmount, big.NewInt(1000))
+	winAmount.Mul(winAmount, share)
+	winAmount.Div(winAmount, big.NewInt(1000))
+	if winAmount.String() != proof.WinAmount {
+		return fmt.Errorf("win amount is %s, not %s", winAmount, proof.WinAmount)
+	}
+	return nil
+}
+
+// reconstructLotteryProof rebuilds the proof of a round drawn before proofs were recorded. Such rounds were drawn
+// by the original rules: a single tier, uniform, the first pick winning, among the miners minted up to the draw.
+func reconstructLotteryProof(txn *badger.Txn, lotteryData *LotteryData) (*LotteryDrawProof, error) {
+	height, err := strconv.Atoi(lotteryData.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	var inscriptionsCount int64
+	for ; ; inscriptionsCount++ {
+		inscriptionID, err := lotteryMinerAt(txn, lotteryData.Mrc721name, inscriptionsCount)
+		if err == badger.ErrKeyNotFound {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		minted, err := getInscriptionHeight(txn, inscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		if minted > height {
+			break
+		}
+	}
+
+	randomWinp, err := convertHashToBigInt(lotteryData.BlockHash, 1000)
+	if err != nil {
+		return nil, err
+	}
+	luckNum, err := convertHashToBigInt(lotteryData.BlockHash, inscriptionsCount)
+	if err != nil {
+		return nil, err
+	}
+	inscriptionID, err := lotteryMinerAt(txn, lotteryData.Mrc721name, luckNum.Int64())
+	if err != nil {
+		return nil, err
+	}
+	return &LotteryDrawProof{
+		Mrc721name:  lotteryData.Mrc721name,
+		Round:       lotteryData.Round,
+		Tier:        1,
+		BlockHeight: lotteryData.BlockHeight,
+		BlockHash:   lotteryData.BlockHash,
+		RandomWinp:  randomWinp.String(),
+		Winp:        stringToPercentageBigInt(lotteryData.Winp).String(),
+		Modulus:     strconv.FormatInt(inscriptionsCount, 10),
+		Paid:        []string{},
+		Draws: []LotteryProofDraw{{
+			Seed:          lotteryData.BlockHash,
+			LuckNum:       luckNum.String(),
+			Index:         luckNum.Int64(),
+			InscriptionID: inscriptionID,
+			Owner:         lotteryData.Address,
+			Eligible:      true,
+		}},
+		InscriptionID: lotteryData.InscriptionID,
+		Address:       lotteryData.Address,
+		PrizePool:     lotteryData.JackpotAccum,
+		Dist:          stringToPercentageBigInt(lotteryData.Dist).String(),
+		Share:         "1000",
+		WinAmount:     lotteryData.WinAmount,
+		Reconstructed: true,
+	}, nil
+}
+
+// getInscriptionHeight returns the height of the block an inscription was revealed in.
+func getInscriptionHeight(txn *badger.Txn, inscriptionID string) (int, error) {
+	item, err := txn.Get([]byte("inscr::" + inscriptionID))
+	if err != nil {
+		return 0, err
+	}
+	var hookInscription HookInscription
+	err = item.Value(func(val []byte) error {
+		return unmarshalInscriptionMeta(val, &hookInscription)
+	})
+	return hookInscription.BlockHeight, err
+}
+
+// GetLotteryProof returns the derivation of a lottery round and checks it with VerifyLotteryDraw.
+func (b *BTOrdIdx) GetLotteryProof(mrc721Name string, round int) (WebLotteryProof, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	mrc721Name = strings.ToUpper(strings.TrimSpace(mrc721Name))
+	var result WebLotteryProof
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(fmt.Sprintf("lottery::mrc721::%s::%d", mrc721Name, round)))
+		if err != nil {
+			return fmt.Errorf("no lottery round %d for %s: %w", round, mrc721Name, err)
+		}
+		var lotteryData LotteryData
+		err = item.Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &lotteryData)
+		})
+		if err != nil {
+			return err
+		}
+
+		var proof *LotteryDrawProof
+		item, err = txn.Get(lotteryProofKey(mrc721Name, round))
+		if err == badger.ErrKeyNotFound {
+			proof, err = reconstructLotteryProof(txn, &lotteryData)
+		} else if err == nil {
+			proof = &LotteryDrawProof{}
+			err = item.Value(func(val []byte) error {
+				return jsoniter.Unmarshal(val, proof)
+			})
+		}
+		if err != nil {
+			return err
+		}
+		// Uniform draws do not record their miners: the "mrc721::count_inscr::" indexes never change
+		if !proof.Weighted {
+			count, err := strconv.ParseInt(proof.Modulus, 10, 64)
+			if err != nil {
+				return err
+			}
+			proof.Miners = make([]LotteryProofMiner, 0, count)
+			for index := int64(0); index < count; index++ {
+				inscriptionID, err := lotteryMinerAt(txn, mrc721Name, index)
+				if err != nil {
+					return err
+				}
+				proof.Miners = append(proof.Miners, LotteryProofMiner{Index: index, InscriptionID: inscriptionID})
+			}
+		}
+		result.Proof = *proof
+		return nil
+	})
+	if err != nil {
+		return WebLotteryProof{}, err
+	}
+
+	if err := VerifyLotteryDraw(&result.Proof); err != nil {
+		result.VerifyError = err.Error()
+	} else {
+		result.Verified = true
+	}
+	return result, nil
+}
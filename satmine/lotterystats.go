@@ -0,0 +1,294 @@
+// filePath: satmine/lotterystats.go
+
+package satmine
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Lottery wins are indexed by winner across all collections, next to the rounds of each collection:
+//
+//	lottery::address::<address>::<mrc721_name>::<round> -> nil
+//
+// The wins drawn before the index existed are indexed by the lottery_address_index migration.
+
+// WebLotteryCollectionWins is the total won by an address in the lottery of one collection.
+type WebLotteryCollectionWins struct {
+	Mrc721name string `json:"mrc721name"`
+	Wins       int    `json:"wins"`
+	WinAmount  string `json:"win_amount"`
+}
+
+// WebAddressLotteryWins is the lottery win history of an address across all collections.
+type WebAddressLotteryWins struct {
+	Address     string                     `json:"address"`
+	Collections []WebLotteryCollectionWins `json:"collections"`
+	Wins        []LotteryData              `json:"wins"` // Most recent first
+}
+
+// WebJackpotPoint is the jackpot of a collection at one win.
+type WebJackpotPoint struct {
+	Round        int    `json:"round"`
+	BlockHeight  string `json:"block_height"`
+	Tier         int    `json:"tier,omitempty"`
+	JackpotAccum string `json:"jackpot_accum"` // Prize pool before the win
+	WinAmount    string `json:"win_amount"`
+}
+
+// WebLotteryStats summarises the lottery of a collection.
+type WebLotteryStats struct {
+	Mrc721name            string            `json:"mrc721name"`
+	Rounds                int               `json:"rounds"`  // Wins paid so far
+	Winners               int               `json:"winners"` // Distinct winning addresses
+	TotalWon              string            `json:"total_won"`
+	ScheduledDraws        int64             `json:"scheduled_draws"`   // Draw heights passed since the genesis block
+	HitDraws              int               `json:"hit_draws"`         // Draw heights which paid at least one win
+	HitRate               string            `json:"hit_rate"`          // HitDraws / ScheduledDraws
+	ExpectedHitRate       string            `json:"expected_hit_rate"` // Probability of a draw given Winp
+	PrizePool             string            `json:"prize_pool"`        // Current prize pool
+	NextDrawHeight        string            `json:"next_draw_height"`
+	NextDrawPrize         string            `json:"next_draw_prize"`          // Paid by the next draw if it hits, with the current pool
+	ExpectedValuePerMiner string            `json:"expected_value_per_miner"` // NextDrawPrize * ExpectedHitRate / InscriptionsCount, for a uniform draw
+	Jackpots              []WebJackpotPoint `json:"jackpots"`                 // Jackpot at every win, in round order
+}
+
+// lotteryAddressKey returns the key indexing a win by its winner.
+func lotteryAddressKey(address, mrc721Name string, round int) []byte {
+	return []byte(fmt.Sprintf("lottery::address::%s::%s::%d", address, mrc721Name, round))
+}
+
+// migrateLotteryWins indexes by winner the wins drawn before the index existed. The wins drawn since are indexed by
+// the block that draws them.
+func (b *BTOrdIdx) migrateLotteryWins() error {
+	batch := b.db.NewWriteBatch()
+	count := 0
+	err := b.db.View(func(txn *badger.Txn) error {
+		return scanPrefix(txn, "lottery::mrc721::", func(key string, val []byte) error {
+			var lotteryData LotteryData
+			if err := jsoniter.Unmarshal(val, &lotteryData); err != nil {
+				return err
+			}
+			count++
+			return batch.Set(lotteryAddressKey(lotteryData.Address, lotteryData.Mrc721name, lotteryData.Round), nil)
+		})
+	})
+	if err != nil {
+		batch.Cancel()
+		return err
+	}
+	if err := batch.Flush(); err != nil {
+		return err
+	}
+	logger.Info("Indexed lottery wins by address", zap.Int("wins", count))
+	return nil
+}
+
+// getLotteryRound reads the win of a round of a collection.
+func getLotteryRound(txn *badger.Txn, mrc721Name string, round int) (LotteryData, error) {
+	var lotteryData LotteryData
+	item, err := txn.Get([]byte(fmt.Sprintf("lottery::mrc721::%s::%d", mrc721Name, round)))
+	if err != nil {
+		return lotteryData, err
+	}
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &lotteryData)
+	})
+	return lotteryData, err
+}
+
+// GetAddressLotteryWins returns the lottery wins of an address in every collection, with the total by collection.
+func (b *BTOrdIdx) GetAddressLotteryWins(address string) (WebAddressLotteryWins, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	result := WebAddressLotteryWins{
+		Address:     strings.TrimSpace(address),
+		Collections: []WebLotteryCollectionWins{},
+		Wins:        []LotteryData{},
+	}
+	err := b.db.View(func(txn *badger.Txn) error {
+		totals := make(map[string]*big.Int)
+		counts := make(map[string]int)
+		err := scanPrefix(txn, "lottery::address::"+result.Address+"::", func(key string, val []byte) error {
+			separator := strings.LastIndex(key, "::")
+			if separator < 0 {
+				return nil
+			}
+			mrc721Name := key[:separator]
+			round, err := strconv.Atoi(key[separator+2:])
+			if err != nil {
+				return nil
+			}
+			lotteryData, err := getLotteryRound(txn, mrc721Name, round)
+			if err != nil {
+				return err
+			}
+			result.Wins = append(result.Wins, lotteryData)
+
+			winAmount, _ := new(big.Int).SetString(lotteryData.WinAmount, 10)
+			if totals[mrc721Name] == nil {
+				totals[mrc721Name] = big.NewInt(0)
+			}
+			if winAmount != nil {
+				totals[mrc721Name].Add(totals[mrc721Name], winAmount)
+			}
+			counts[mrc721Name]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for mrc721Name, total := range totals {
+			result.Collections = append(result.Collections, WebLotteryCollectionWins{
+				Mrc721name: mrc721Name,
+				Wins:       counts[mrc721Name],
+				WinAmount:  total.String(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return WebAddressLotteryWins{}, err
+	}
+
+	sort.Slice(result.Collections, func(i, j int) bool {
+		return result.Collections[i].Mrc721name < result.Collections[j].Mrc721name
+	})
+	sort.SliceStable(result.Wins, func(i, j int) bool {
+		if result.Wins[i].BlockTimestamp != result.Wins[j].BlockTimestamp {
+			return result.Wins[i].BlockTimestamp > result.Wins[j].BlockTimestamp
+		}
+		return result.Wins[i].Round > result.Wins[j].Round
+	})
+	return result, nil
+}
+
+// GetLotteryStats returns the jackpot history of a collection, its hit rate against Winp and the expected value of
+// the next draw for one miner.
+func (b *BTOrdIdx) GetLotteryStats(mrc721Name string) (WebLotteryStats, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	mrc721Name = strings.ToUpper(strings.TrimSpace(mrc721Name))
+	stats := WebLotteryStats{Mrc721name: mrc721Name, Jackpots: []WebJackpotPoint{}}
+	err := b.db.View(func(txn *badger.Txn) error {
+		var genesisData Mrc721GenesisData
+		item, err := txn.Get([]byte("mrc721::geninsc::" + mrc721Name))
+		if err != nil {
+			return fmt.Errorf("no collection %s: %w", mrc721Name, err)
+		}
+		if err := item.Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &genesisData)
+		}); err != nil {
+			return err
+		}
+		var inscription HookInscription
+		item, err = txn.Get([]byte("inscr::" + genesisData.ID))
+		if err != nil {
+			return err
+		}
+		if err := item.Value(func(val []byte) error {
+			return unmarshalInscription(txn, val, &inscription)
+		}); err != nil {
+			return err
+		}
+		firstMrc721, err := ParseMRC721Protocol(*inscription.ContentByte)
+		if err != nil {
+			return err
+		}
+		if firstMrc721.Ltry == nil {
+			return fmt.Errorf("collection %s has no lottery", mrc721Name)
+		}
+
+		// Jackpot history and hits
+		totalWon := big.NewInt(0)
+		winners := make(map[string]bool)
+		hitHeights := make(map[string]bool)
+		for round := 1; round <= genesisData.TotalPrizeRound; round++ {
+			lotteryData, err := getLotteryRound(txn, mrc721Name, round)
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if winAmount, ok := new(big.Int).SetString(lotteryData.WinAmount, 10); ok {
+				totalWon.Add(totalWon, winAmount)
+			}
+			winners[lotteryData.Address] = true
+			hitHeights[lotteryData.BlockHeight] = true
+			stats.Jackpots = append(stats.Jackpots, WebJackpotPoint{
+				Round:        lotteryData.Round,
+				BlockHeight:  lotteryData.BlockHeight,
+				Tier:         lotteryData.Tier,
+				JackpotAccum: lotteryData.JackpotAccum,
+				WinAmount:    lotteryData.WinAmount,
+			})
+		}
+		stats.Rounds = len(stats.Jackpots)
+		stats.Winners = len(winners)
+		stats.TotalWon = totalWon.String()
+		stats.HitDraws = len(hitHeights)
+
+		// Draws are held every Intvl blocks after the genesis block
+		latestHeight := int64(0)
+		if item, err := txn.Get([]byte("latestblock")); err == nil {
+			_ = item.Value(func(val []byte) error {
+				latestHeight, _ = strconv.ParseInt(string(val), 10, 64)
+				return nil
+			})
+		}
+		genesisHeight, _ := strconv.ParseInt(genesisData.BlockHeight, 10, 64)
+		intvl, _ := strconv.ParseInt(firstMrc721.Ltry.Intvl, 10, 64) // Already validated by lotteryIssues
+		if intvl > 0 && latestHeight > genesisHeight {
+			stats.ScheduledDraws = (latestHeight - genesisHeight) / intvl
+		}
+		stats.NextDrawHeight = strconv.FormatInt(genesisHeight+(stats.ScheduledDraws+1)*intvl, 10)
+
+		// A draw happens when the block hash modulo 1000 is at most Winp
+		winpBigInt := stringToPercentageBigInt(firstMrc721.Ltry.Winp)
+		drawChance := new(big.Int).Add(winpBigInt, big.NewInt(1))
+		if drawChance.Cmp(big.NewInt(1000)) > 0 {
+			drawChance.SetInt64(1000)
+		}
+		stats.ExpectedHitRate = fmt.Sprintf("%.4f", float64(drawChance.Int64())/1000)
+		stats.HitRate = "0.0000"
+		if stats.ScheduledDraws > 0 {
+			stats.HitRate = fmt.Sprintf("%.4f", float64(stats.HitDraws)/float64(stats.ScheduledDraws))
+		}
+
+		// The next draw pays the tiers their share of pool * dist
+		stats.PrizePool = genesisData.PrizePoolTokens
+		prizePool, ok := new(big.Int).SetString(genesisData.PrizePoolTokens, 10)
+		if !ok {
+			prizePool = big.NewInt(0)
+		}
+		drawPrize := new(big.Int).Mul(prizePool, stringToPercentageBigInt(firstMrc721.Ltry.Dist))
+		drawPrize.Div(drawPrize, big.NewInt(1000))
+		nextDrawPrize := big.NewInt(0)
+		for _, share := range lotteryTierShares(LatestRules(), firstMrc721.Ltry) {
+			tierPrize := new(big.Int).Mul(drawPrize, share)
+			nextDrawPrize.Add(nextDrawPrize, tierPrize.Div(tierPrize, big.NewInt(1000)))
+		}
+		stats.NextDrawPrize = nextDrawPrize.String()
+		expectedValue := new(big.Int).Mul(nextDrawPrize, drawChance)
+		expectedValue.Div(expectedValue, big.NewInt(1000))
+		if genesisData.InscriptionsCount > 0 {
+			expectedValue.Div(expectedValue, big.NewInt(int64(genesisData.InscriptionsCount)))
+		}
+		stats.ExpectedValuePerMiner = expectedValue.String()
+		return nil
+	})
+	if err != nil {
+		return WebLotteryStats{}, err
+	}
+	return stats, nil
+}
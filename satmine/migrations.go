@@ -0,0 +1,63 @@
+// filePath: satmine/migrations.go
+
+package satmine
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// Records written by an older version of the indexer are migrated once, when the index is opened, outside the block
+// write path and in transactions of their own:
+//
+//	migration::<name> -> set once the migration has run
+
+// indexMigration rewrites the records of an older version of the indexer.
+type indexMigration struct {
+	name string
+	run  func(b *BTOrdIdx) error
+}
+
+// indexMigrations are run in order, new migrations are appended.
+var indexMigrations = []indexMigration{
+	{name: "content_store", run: (*BTOrdIdx).migrateContent},
+	{name: "lottery_address_index", run: (*BTOrdIdx).migrateLotteryWins},
+}
+
+// migrationKey returns the key marking a migration as run.
+func migrationKey(name string) []byte {
+	return []byte("migration::" + name)
+}
+
+// RunMigrations runs the migrations the index has not run yet. It is called before the index serves or writes
+// anything.
+func (b *BTOrdIdx) RunMigrations() error {
+	b.rwLock.Lock()
+	defer b.rwLock.Unlock()
+
+	for _, migration := range indexMigrations {
+		err := b.db.View(func(txn *badger.Txn) error {
+			_, err := txn.Get(migrationKey(migration.name))
+			return err
+		})
+		if err == nil {
+			continue // Already run
+		}
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		logger.Info("Running index migration", zap.String("migration", migration.name))
+		if err := migration.run(b); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.name, err)
+		}
+		if err := b.db.Update(func(txn *badger.Txn) error {
+			return txn.Set(migrationKey(migration.name), nil)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
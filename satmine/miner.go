@@ -4,37 +4,48 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strconv"
 )
 
 // Mrc721GenesisData represents the information about a genesis inscription in the MRC-721 protocol.
 // It includes identification details and statistics relevant to the inscription.
 type Mrc721GenesisData struct {
-	ID                   string `json:"id"`                      // Unique identifier for the inscription
-	Number               int    `json:"number"`                  // Number for the inscription
-	Name                 string `json:"name"`                    // Name for the inscription
-	PrevName             string `json:"previous_name"`           // Previous name for the inscription
-	BlockHeight          string `json:"block_height"`            // Height of the block in the blockchain where the inscription was recorded
-	GenesisAddress       string `json:"genesis_address"`         // Address associated with the genesis transaction of the inscription
-	InscriptionsCount    int    `json:"inscriptions_count"`      // Total count of inscriptions
-	InscriptionsMax      int    `json:"inscriptions_max"`        // Total count of inscriptions
-	PrizePoolTokens      string `json:"prize_pool_tokens"`       // The amount of tokens that have been added to the prize pool
-	TotalMinedTokens     string `json:"mined_tokens"`            // The amount of tokens that have been mined
-	TotalPrizePoolTokens string `json:"total_prize_pool_tokens"` // The cumulative total of tokens in the prize pool
-	Tick                 string `json:"tick"`                    // The token ticker brc20name
-	PrevTick             string `json:"previous_tick"`           // Previous The token ticker brc20name
-	GenesisBlockHeight   string `json:"genesis_block_height"`    // Height of the block in the blockchain where the genesis transaction of the inscription was recorded
-	GenesisTimestamp     int64  `json:"genesis_timestamp"`       // Timestamp of the block in the blockchain where the genesis transaction of the inscription was recorded
-	EndID                string `json:"end_id"`                  // The ID of the inscription that ended the mining round
-	EndBlockHeight       string `json:"end_block_height"`        // The block height of the inscription that ended the mining round
-	EndTimestamp         int64  `json:"end_timestamp"`           // The timestamp of the block in the blockchain where the inscription that ended the mining round was recorded
-	TotalPrizeRound      int    `json:"total_prize_round"`       // The total prize round
-	TotalBurn            string `json:"total_burn"`              // The total burn
+	ID                   string `json:"id"`                         // Unique identifier for the inscription
+	Number               int    `json:"number"`                     // Number for the inscription
+	Name                 string `json:"name"`                       // Name for the inscription
+	PrevName             string `json:"previous_name"`              // Previous name for the inscription
+	BlockHeight          string `json:"block_height"`               // Height of the block in the blockchain where the inscription was recorded
+	GenesisAddress       string `json:"genesis_address"`            // Address associated with the genesis transaction of the inscription
+	InscriptionsCount    int    `json:"inscriptions_count"`         // Total count of inscriptions
+	InscriptionsMax      int    `json:"inscriptions_max"`           // Total count of inscriptions
+	PrizePoolTokens      string `json:"prize_pool_tokens"`          // The amount of tokens that have been added to the prize pool
+	TotalMinedTokens     string `json:"mined_tokens"`               // The amount of tokens that have been mined
+	TotalPrizePoolTokens string `json:"total_prize_pool_tokens"`    // The cumulative total of tokens in the prize pool
+	Tick                 string `json:"tick"`                       // The token ticker brc20name
+	PrevTick             string `json:"previous_tick"`              // Previous The token ticker brc20name
+	GenesisBlockHeight   string `json:"genesis_block_height"`       // Height of the block in the blockchain where the genesis transaction of the inscription was recorded
+	GenesisTimestamp     int64  `json:"genesis_timestamp"`          // Timestamp of the block in the blockchain where the genesis transaction of the inscription was recorded
+	EndID                string `json:"end_id"`                     // The ID of the inscription that ended the mining round
+	EndBlockHeight       string `json:"end_block_height"`           // The block height of the inscription that ended the mining round
+	EndTimestamp         int64  `json:"end_timestamp"`              // The timestamp of the block in the blockchain where the inscription that ended the mining round was recorded
+	TotalPrizeRound      int    `json:"total_prize_round"`          // The total prize round
+	TotalBurn            string `json:"total_burn"`                 // The total burn
+	TotalFeeTokens       string `json:"total_fee_tokens,omitempty"` // The part of the mined tokens credited to the creator fee recipient
+}
+
+// totalFeeTokens returns the creator fee credited so far, collections without a fee have none.
+func totalFeeTokens(genesisData Mrc721GenesisData) string {
+	if genesisData.TotalFeeTokens == "" {
+		return "0"
+	}
+	return genesisData.TotalFeeTokens
 }
 
 // MiningRewardCalculation contains the results of the CalculateMiningRewards method.
 type MiningRewardCalculation struct {
 	CurrentPrizePoolAllNum string `json:"current_prize_pool_all_num"` // Total amount of funds extracted in this round (to be subsequently added to the prize pool for accumulation)
 	CurrentMiningAllNum    string `json:"current_mining_all_num"`     // Total amount of funds mined in this round (to be deducted from the total capital)
+	CurrentFeeAllNum       string `json:"current_fee_all_num"`        // Creator fee extracted in this round, after the prize pool (also mined)
 	IsMiningEnd            bool   `json:"is_mining_end"`              // Has the mining round concluded?
 	EndReason              string `json:"end_reason"`                 // Reason for conclusion
 }
@@ -44,6 +55,7 @@ type Mrc721MinerData struct {
 	InscriptionsNumber int     `json:"inscriptions_number"`
 	Address            string  `json:"address"`
 	BurnNum            string  `json:"burn_num"`
+	StakeNum           string  `json:"stake_num"` // Tokens staked on the inscription, in decimal
 	Tick               string  `json:"tick"`
 	MinedAmount        string  `json:"mined_amount"` // Number of final digs per inscription
 	Power              big.Int // The arithmetic value of each inscription, determined by the BurnNum parameter, defaults to 1000.
@@ -53,42 +65,80 @@ type Mrc721MinerMap struct {
 	Data map[string]*Mrc721MinerData
 }
 
-// CalculateMiningRewards computes the amount of tokens that each mining machine can earn in a single mining round.
-// This function takes into account various factors such as the mining machine's performance, the current network difficulty,
-// and any other relevant parameters to accurately calculate the mining yield. The result helps miners understand their potential
-// earnings from mining activities during each round.
-func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData, firstMrc721 *MRC721Protocol, minerMap *Mrc721MinerMap) (calcResult MiningRewardCalculation, err error) {
+// Mining power of an inscription: every miner starts with baseMinerPower, burning tokens for it adds
+// (burnt / unit) * boost and staking tokens on it adds (staked / unit) * boost of the stake section,
+// up to the MaxMinerPower of the protocol rules.
+const baseMinerPower = 1000
+
+// computeMinerPower returns the mining power of an inscription of the collection for which burnNum tokens have been burnt
+// and stakeNum tokens are staked.
+func computeMinerPower(rules *ProtocolRules, firstMrc721 *MRC721Protocol, burnNum, stakeNum *big.Int) *big.Int {
+	power := big.NewInt(baseMinerPower)
+	if firstMrc721.Burn != nil {
+		addBurnPower(rules, firstMrc721, power, burnNum)
+	}
+	if firstMrc721.Stake != nil {
+		addStakePower(rules, firstMrc721, power, stakeNum)
+	}
+	return power
+}
+
+// addBurnPower adds the power gained by burning burnNum tokens to power and applies the cap.
+func addBurnPower(rules *ProtocolRules, firstMrc721 *MRC721Protocol, power *big.Int, burnNum *big.Int) *big.Int {
+	unitBigInt, _ := new(big.Int).SetString(firstMrc721.Burn.Unit, 10)
+	boostBigInt := stringToPercentageBigInt(firstMrc721.Burn.Boost)
+
+	// Calculate the power value
+	powerValue := new(big.Int).Div(burnNum, unitBigInt)
+	powerValue.Mul(powerValue, boostBigInt)
+	// Add to the existing Power value
+	power.Add(power, powerValue)
+	// If the power value is greater than the maximum, set it to the maximum
+	if power.Cmp(big.NewInt(rules.MaxMinerPower)) > 0 {
+		power.SetInt64(rules.MaxMinerPower)
+	}
+	return power
+}
+
+// addStakePower adds the power gained by staking stakeNum tokens to power and applies the cap.
+func addStakePower(rules *ProtocolRules, firstMrc721 *MRC721Protocol, power *big.Int, stakeNum *big.Int) *big.Int {
+	unitBigInt, _ := new(big.Int).SetString(firstMrc721.Stake.Unit, 10)
+	boostBigInt := stringToPercentageBigInt(firstMrc721.Stake.Boost)
+
+	powerValue := new(big.Int).Div(stakeNum, unitBigInt)
+	powerValue.Mul(powerValue, boostBigInt)
+	power.Add(power, powerValue)
+	if power.Cmp(big.NewInt(rules.MaxMinerPower)) > 0 {
+		power.SetInt64(rules.MaxMinerPower)
+	}
+	return power
+}
+
+// calculateBlockEmission computes the amount of tokens released to the miners of a collection at currentHeight,
+// after the lottery pool and then the creator fee have been deducted. When mining has ended, calcResult.IsMiningEnd is set and the
+// returned amount is nil. calcResult.CurrentMiningAllNum is left to the distribution of the amount.
+func calculateBlockEmission(currentHeight string, genesisData *Mrc721GenesisData, firstMrc721 *MRC721Protocol, schedule *EmissionSchedule) (calcResult MiningRewardCalculation, currentBlockMining *big.Int, err error) {
 	// Parameters:
-	// genesisHeight is the block height at genesis
-	// blockHeight is the current block height
-	// total is the total number of tokens that can be mined
-	// beg is the number of tokens mined per block
-	// halv is the halving cycle from 1-n
-	// dcr is the reduction ratio in mining amount after each halving cycle "0.000" - "1.000"
-	// extr is the proportion of funds drawn into the prize pool each round "0.000" - "1.000"
-	// minedTokens is the total number of tokens already mined
-	// inscriptionsCount is the number of inscriptions participating in mining
+	// currentHeight is the current block height
+	// genesisData holds the total number of tokens already mined and put into the prize pool
+	// firstMrc721 is the genesis protocol, total is the total number of tokens that can be mined
+	// schedule gives the number of tokens mined per block after the halvings
 	// Returns:
-	// tokensPerInscription is the number of tokens each inscription can mine this time
-	// eligibleInscriptions is the number of inscriptions that are allowed to receive tokens, during the last mining round not all inscriptions can be allocated, only a fixed number of inscriptions to avoid exceeding the total value
 	// prizePoolTokens is the amount of funds that need to be put into the prize pool after drawing
-	// currentMiningValue is the total amount of mining in this round
+	// currentBlockMining is the amount to be distributed to the miners in this round
 
 	isPrint := false
 
 	// Convert parameters to big numbers
-	genesisHeightBigInt, _ := new(big.Int).SetString(genesisData.BlockHeight, 10) // Block height at genesis
-	currentHeightBigInt, _ := new(big.Int).SetString(currentHeight, 10)           // Current block height
-	totalBigInt, _ := new(big.Int).SetString(firstMrc721.Token.Total, 10)         // Total number of tokens that can be mined
-	beginBigInt, _ := new(big.Int).SetString(firstMrc721.Token.Beg, 10)           // Number of tokens mined per block
-	halvBigInt, _ := new(big.Int).SetString(firstMrc721.Token.Halv, 10)           // Halving cycle
-	dcrBigInt := stringToPercentageBigInt(firstMrc721.Token.Dcr)                  // Reduction ratio after each halving cycle "0.000" - "1.000"
+	currentHeightInt, err := strconv.ParseInt(currentHeight, 10, 64) // Current block height
+	if err != nil {
+		return
+	}
+	totalBigInt, _ := new(big.Int).SetString(firstMrc721.Token.Total, 10) // Total number of tokens that can be mined
 
 	totalMinedTokensBigInt, _ := new(big.Int).SetString(genesisData.TotalMinedTokens, 10)         // Total mined tokens
 	totalPrizePoolTokensBigInt, _ := new(big.Int).SetString(genesisData.TotalPrizePoolTokens, 10) // Total tokens in prize pool
 
-	//fmt.Println("CalculateMiningRewards debug=", totalBigInt, totalMinedTokensBigInt, totalPrizePoolTokensBigInt)
-
 	// Calculate the remaining unreleased tokens
 	remainingTokens := new(big.Int).Sub(totalBigInt, new(big.Int).Add(totalMinedTokensBigInt, totalPrizePoolTokensBigInt))
 	// Check if remaining tokens are less than zero, which is an error
@@ -102,29 +152,14 @@ func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData
 		// Funds have been fully released, stop mining
 		calcResult.CurrentMiningAllNum = "0"
 		calcResult.CurrentPrizePoolAllNum = "0"
+		calcResult.CurrentFeeAllNum = "0"
 		calcResult.IsMiningEnd = true
 		calcResult.EndReason = "FullRelease"
 		return
 	}
 
-	blockCount := new(big.Int).Sub(currentHeightBigInt, genesisHeightBigInt)
-
-	// Calculate the current round
-	roundBigInt := new(big.Int).Div(blockCount, halvBigInt)
-	// Print the current round for debugging purposes
-	if isPrint {
-		fmt.Printf("Current round: %s\n", roundBigInt.String())
-	}
-
-	currentBlockMining := new(big.Int).Set(beginBigInt)
-
-	// Loop through each round
-	for i := big.NewInt(0); i.Cmp(roundBigInt) < 0; i.Add(i, big.NewInt(1)) {
-		// Update the current block mining total
-		currentBlockMining.Mul(currentBlockMining, new(big.Int).Sub(big.NewInt(1000), dcrBigInt))
-		currentBlockMining.Div(currentBlockMining, big.NewInt(1000))
-
-	}
+	// Emission of the current round, after the halvings
+	currentBlockMining = schedule.EmissionAt(currentHeightInt)
 
 	// If the funds available are greater than the funds to be allocated, the maximum amount that can be allocated is the remaining funds.
 	if currentBlockMining.Cmp(remainingTokens) > 0 {
@@ -136,9 +171,10 @@ func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData
 		// Funds fully released, stop mining.
 		calcResult.CurrentMiningAllNum = "0"
 		calcResult.CurrentPrizePoolAllNum = "0"
+		calcResult.CurrentFeeAllNum = "0"
 		calcResult.IsMiningEnd = true
 		calcResult.EndReason = "NotFullyReleased"
-		return
+		return calcResult, nil, nil
 	}
 
 	// Print the final current block mining total
@@ -151,7 +187,6 @@ func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData
 	// Handling of raffle prizes
 	if firstMrc721.Ltry != nil {
 		poolBigInt := stringToPercentageBigInt(firstMrc721.Ltry.Pool)
-		//intvlBigInt, _ := new(big.Int).SetString(firstMrc721.Ltry.Intvl, 10)
 
 		// Calculation of pumping values per round
 		prizePoolValue := new(big.Int).Mul(currentBlockMining, poolBigInt)
@@ -164,46 +199,65 @@ func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData
 
 	}
 
+	// Handling of the creator fee, taken from what the prize pool left
+	calcResult.CurrentFeeAllNum = "0"
+	if firstMrc721.Fee != nil && RulesAt(currentHeightInt).CreatorFee {
+		feeValue := new(big.Int).Mul(currentBlockMining, stringToPercentageBigInt(firstMrc721.Fee.Rate))
+		feeValue.Div(feeValue, big.NewInt(1000))
+		currentBlockMining.Sub(currentBlockMining, feeValue)
+		calcResult.CurrentFeeAllNum = feeValue.String()
+	}
+
+	calcResult.IsMiningEnd = false
+	return calcResult, currentBlockMining, nil
+}
+
+// CalculateMiningRewards computes the amount of tokens that each mining machine can earn in a single mining round.
+// This function takes into account various factors such as the mining machine's performance, the current network difficulty,
+// and any other relevant parameters to accurately calculate the mining yield. The result helps miners understand their potential
+// earnings from mining activities during each round.
+//
+// The emission schedule is rebuilt on every call, callers mining many blocks should keep an EmissionSchedule instead.
+func CalculateMiningRewards(currentHeight string, genesisData *Mrc721GenesisData, firstMrc721 *MRC721Protocol, minerMap *Mrc721MinerMap) (calcResult MiningRewardCalculation, err error) {
+	genesisHeight, err := strconv.ParseInt(genesisData.BlockHeight, 10, 64) // Block height at genesis
+	if err != nil {
+		return
+	}
+	schedule, err := NewEmissionSchedule(firstMrc721, genesisHeight)
+	if err != nil {
+		return
+	}
+
+	calcResult, currentBlockMining, err := calculateBlockEmission(currentHeight, genesisData, firstMrc721, schedule)
+	if err != nil || calcResult.IsMiningEnd {
+		return
+	}
+
 	// Increased arithmetic of burning
+	currentHeightInt, _ := strconv.ParseInt(currentHeight, 10, 64) // Already validated by calculateBlockEmission
+	rules := RulesAt(currentHeightInt)
 	if firstMrc721.Burn != nil {
-		// Calculate the arithmetic value of each inscription
-		unitBigInt, _ := new(big.Int).SetString(firstMrc721.Burn.Unit, 10)
-		boostBigInt := stringToPercentageBigInt(firstMrc721.Burn.Boost)
-
 		// Iterate over the minerMap
 		for _, minerData := range minerMap.Data {
-			//burnNumBigInt, _ := new(big.Int).SetString(minerData.BurnNum, 10)
 			burnNumBigInt := new(big.Int).SetBytes([]byte(minerData.BurnNum))
-			// Calculate the power value
-
-			//fmt.Println("burnNumBigInt =", burnNumBigInt, "minerData.BurnNum=", minerData.BurnNum)
-			powerValue := new(big.Int).Div(burnNumBigInt, unitBigInt)
-			powerValue.Mul(powerValue, boostBigInt)
-			// Add to the existing Power value
-			minerData.Power.Add(&minerData.Power, powerValue)
-			// If the power value is greater than 10000, set the power value to 100000 (that's 10 times the power, to avoid over-parameterization issues).
-			if minerData.Power.Cmp(big.NewInt(11000)) > 0 {
-				minerData.Power.SetInt64(11000)
-			}
+			addBurnPower(rules, firstMrc721, &minerData.Power, burnNumBigInt)
+		}
+	}
 
-			//fmt.Println("minerData.Power=", minerData.Power)
-			// Print each InscriptionsID's power value
-			if isPrint {
-				fmt.Printf("InscriptionsID: %s,BurnNum %s,unitBigInt %s, Power: %s, MinedAmount: %s\n", minerData.InscriptionsID, minerData.BurnNum, unitBigInt, minerData.Power.String(), minerData.MinedAmount)
+	// Increased arithmetic of staking, only tokens still staked count
+	if firstMrc721.Stake != nil {
+		for _, minerData := range minerMap.Data {
+			stakeNumBigInt, ok := new(big.Int).SetString(minerData.StakeNum, 10)
+			if !ok {
+				continue
 			}
+			addStakePower(rules, firstMrc721, &minerData.Power, stakeNumBigInt)
 		}
 	}
 
 	//Calculate the bonus for each inscription
 	residualFunds, _ := powerRewards(*currentBlockMining, minerMap)
 
-	if isPrint {
-		fmt.Printf("Funds remaining after mining: %s\n", residualFunds.String())
-
-		//fmt.Println("currentBlockMining", currentBlockMining)
-		fmt.Printf("currentBlockMining=%s \n", currentBlockMining.String())
-	}
-
 	// Update calcResult.CurrentMiningAllNum to the total number of mined blocks mined minus the remaining funds.
 	calcResult.CurrentMiningAllNum = new(big.Int).Sub(currentBlockMining, &residualFunds).String()
 	calcResult.IsMiningEnd = false
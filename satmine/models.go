@@ -18,7 +18,8 @@ type HookInscription struct {
 	Sat                     int64   `json:"sat"`
 	BlockHeight             int     `json:"block_height"`
 	OrdinalHeight           int     `json:"ordinal_height"`
-	ContentByte             *[]byte `json:"content_byte"` // binary data
+	ContentByte             *[]byte `json:"content_byte"`           // binary data
+	ContentHash             string  `json:"content_hash,omitempty"` // key of the body in the content store
 	ContentType             string  `json:"content_type"`
 	ContentLength           int     `json:"content_length"`
 	CurseType               *string `json:"curse_type"`
@@ -54,6 +55,7 @@ type LotteryData struct {
 	Round          int    `json:"round"`
 	Winp           string `json:"winp"`
 	Dist           string `json:"dist"`
+	Tier           int    `json:"tier,omitempty"` // Prize tier of the win, from 1, when the draw has several tiers
 }
 
 type SimpleHookBlock struct {
@@ -110,14 +112,16 @@ type WebMrc721Holder struct {
 }
 
 type WebBurnInfo struct {
-	Balance    string `json:"balance"`
-	Mrc721name string `json:"mrc721name"`
-	Mrc20name  string `json:"mrc20name"`
-	Power      string `json:"power"`
-	BurnAmount string `json:"burn_amount"`
-	BurnMax    string `json:"burn_max"`
-	Unit       string `json:"unit"`
-	Boost      string `json:"boost"`
+	Balance    string            `json:"balance"`
+	Mrc721name string            `json:"mrc721name"`
+	Mrc20name  string            `json:"mrc20name"`
+	Power      string            `json:"power"`
+	BurnAmount string            `json:"burn_amount"` // Tokens burnt for the inscription
+	TotalBurn  string            `json:"total_burn"`  // Tokens burnt for the whole collection
+	Burns      []Mrc20BurnRecord `json:"burns"`       // Burns that boosted the inscription
+	BurnMax    string            `json:"burn_max"`
+	Unit       string            `json:"unit"`
+	Boost      string            `json:"boost"`
 }
 
 type WebMrcAllInscription struct {
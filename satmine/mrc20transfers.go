@@ -0,0 +1,227 @@
+// filePath: satmine/mrc20transfers.go
+
+package satmine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// States of a MRC-20 transfer inscription.
+const (
+	TransferInscribed            = "inscribed"                     // Valid, the amount left the balance of the sender
+	TransferRejectedInsufficient = "rejected_insufficient_balance" // The sender held less than the amount, nothing moved
+	TransferInTransit            = "in_transit"                    // Spent in fees, the next move of the inscription settles it
+	TransferSettled              = "settled"                       // Credited to the address the inscription was sent to
+	TransferReturned             = "returned"                      // Sent back to the sender and credited to it
+	TransferBurnt                = "burnt"                         // Burnt, credited to the burn address
+)
+
+// burnAddress receives the inscriptions and the MRC-20 amounts of burnt inscriptions.
+const burnAddress = "1BitcoinEaterAddressDontSendf59kuE"
+
+// Mrc20TransferEvent is one transition of a MRC-20 transfer inscription.
+type Mrc20TransferEvent struct {
+	State        string `json:"state"`
+	BlockHeight  string `json:"block_height"`
+	Counterparty string `json:"counterparty,omitempty"` // Address the inscription moved to, if any
+}
+
+// Mrc20TransferRecord is the lifecycle of a MRC-20 transfer inscription.
+type Mrc20TransferRecord struct {
+	InscriptionID string               `json:"inscription_id"`
+	Tick          string               `json:"tick"`
+	Amount        string               `json:"amount"`
+	Sender        string               `json:"sender"`
+	State         string               `json:"state"` // State of the last event
+	Events        []Mrc20TransferEvent `json:"events"`
+}
+
+// getMrc20Transfer reads the lifecycle of a transfer inscription, nil if it has none.
+// mrc20::transfer::[inscription_id] -> Mrc20TransferRecord
+func getMrc20Transfer(txn *badger.Txn, inscriptionID string) (*Mrc20TransferRecord, error) {
+	item, err := txn.Get([]byte("mrc20::transfer::" + inscriptionID))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Mrc20TransferRecord
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// putMrc20Transfer writes the lifecycle of a transfer inscription and indexes it under every address involved.
+// mrc20::transfer_addr::[address]::[inscription_id] -> nil
+func putMrc20Transfer(txn *badger.Txn, record *Mrc20TransferRecord) error {
+	recordJSON, err := jsoniter.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := setState(txn, []byte("mrc20::transfer::"+record.InscriptionID), recordJSON); err != nil {
+		return err
+	}
+	addresses := []string{record.Sender}
+	if last := record.Events[len(record.Events)-1]; last.Counterparty != "" {
+		addresses = append(addresses, last.Counterparty)
+	}
+	for _, address := range addresses {
+		if err := setState(txn, []byte("mrc20::transfer_addr::"+address+"::"+record.InscriptionID), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startMrc20Transfer records the first state of a transfer inscription.
+func startMrc20Transfer(txn *badger.Txn, block *HookBlock, inscr *HookInscription, mrc20Data *MRC20Protocol, state string) error {
+	record := &Mrc20TransferRecord{
+		InscriptionID: inscr.ID,
+		Tick:          mrc20Data.Tick,
+		Amount:        mrc20Data.Amt,
+		Sender:        inscr.Address,
+		State:         state,
+		Events:        []Mrc20TransferEvent{{State: state, BlockHeight: block.BlockHeight}},
+	}
+	return putMrc20Transfer(txn, record)
+}
+
+// moveMrc20Transfer records a move of a live transfer inscription inscribed by sender. Transfers inscribed before
+// lifecycles were recorded get one from their first move on.
+func moveMrc20Transfer(txn *badger.Txn, block *HookBlock, inscriptionID, sender string, mrc20Data *MRC20Protocol, state, counterparty string) error {
+	record, err := getMrc20Transfer(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		record = &Mrc20TransferRecord{
+			InscriptionID: inscriptionID,
+			Tick:          mrc20Data.Tick,
+			Amount:        mrc20Data.Amt,
+			Sender:        sender,
+		}
+	}
+	record.State = state
+	record.Events = append(record.Events, Mrc20TransferEvent{State: state, BlockHeight: block.BlockHeight, Counterparty: counterparty})
+	return putMrc20Transfer(txn, record)
+}
+
+// settledTransferState returns the state of a transfer inscription credited to toAddress.
+func settledTransferState(sender, toAddress string) string {
+	switch toAddress {
+	case burnAddress:
+		return TransferBurnt
+	case sender:
+		return TransferReturned
+	}
+	return TransferSettled
+}
+
+// markMrc20TransferInTransit records that a live transfer inscription was spent in fees.
+func markMrc20TransferInTransit(txn *badger.Txn, block *HookBlock, inscriptionID string) error {
+	sender := ""
+	prefix := "mrc20::inscr_addr::" + inscriptionID + "::"
+	err := scanPrefix(txn, prefix, func(key string, val []byte) error {
+		sender = key
+		return nil
+	})
+	if err != nil || sender == "" {
+		return err
+	}
+
+	record, err := getMrc20Transfer(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	var mrc20Data *MRC20Protocol
+	if record == nil {
+		// Transfer inscribed before lifecycles were recorded
+		item, err := txn.Get([]byte("inscr::" + inscriptionID))
+		if err != nil {
+			return err
+		}
+		var inscription HookInscription
+		if err := item.Value(func(val []byte) error {
+			return unmarshalInscription(txn, val, &inscription)
+		}); err != nil {
+			return err
+		}
+		if mrc20Data, err = ParseMRC20Protocol(*inscription.ContentByte); err != nil {
+			return err
+		}
+	}
+	return moveMrc20Transfer(txn, block, inscriptionID, sender, mrc20Data, TransferInTransit, "")
+}
+
+// GetMrc20Transfer returns the lifecycle of a MRC-20 transfer inscription.
+func (b *BTOrdIdx) GetMrc20Transfer(inscriptionID string) (Mrc20TransferRecord, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	var record *Mrc20TransferRecord
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		record, err = getMrc20Transfer(txn, strings.TrimSpace(inscriptionID))
+		return err
+	})
+	if err != nil {
+		return Mrc20TransferRecord{}, err
+	}
+	if record == nil {
+		return Mrc20TransferRecord{}, fmt.Errorf("no MRC-20 transfer for inscription %s", inscriptionID)
+	}
+	return *record, nil
+}
+
+// GetAddressMrc20Transfers returns a page of the MRC-20 transfer inscriptions an address sent or received,
+// optionally limited to the ones in a state, and their total count.
+func (b *BTOrdIdx) GetAddressMrc20Transfers(address, state string, pageIndex, pageSize int) ([]Mrc20TransferRecord, int, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	var records []Mrc20TransferRecord
+	err := b.db.View(func(txn *badger.Txn) error {
+		prefix := "mrc20::transfer_addr::" + strings.TrimSpace(address) + "::"
+		var inscriptionIDs []string
+		err := scanPrefix(txn, prefix, func(key string, val []byte) error {
+			inscriptionIDs = append(inscriptionIDs, key)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, inscriptionID := range inscriptionIDs {
+			record, err := getMrc20Transfer(txn, inscriptionID)
+			if err != nil {
+				return err
+			}
+			if record != nil && (state == "" || record.State == state) {
+				records = append(records, *record)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	allCount := len(records)
+	start := pageIndex * pageSize
+	if start > allCount {
+		start = allCount
+	}
+	end := start + pageSize
+	if end > allCount {
+		end = allCount
+	}
+	return records[start:end], allCount, nil
+}
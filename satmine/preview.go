@@ -0,0 +1,206 @@
+// filePath: satmine/preview.go
+
+package satmine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// WebInscriptionPreview is what a piece of content would do if an address inscribed it in the next block, given
+// the current state. Before and after values are only set for the state the content touches.
+type WebInscriptionPreview struct {
+	BlockHeight string `json:"block_height"` // Height of the next block, whose rules apply
+	Protocol    string `json:"protocol"`     // Protocol type of the content, empty if it is not a protocol inscription
+	Status      string `json:"status,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+
+	// MRC-721 deploys and mints
+	Mrc721name          string `json:"mrc721name,omitempty"`
+	MinersBefore        string `json:"miners_before,omitempty"`
+	MinersAfter         string `json:"miners_after,omitempty"`
+	MinersMax           string `json:"miners_max,omitempty"`
+	AddressMinersBefore string `json:"address_miners_before,omitempty"`
+	AddressMinersAfter  string `json:"address_miners_after,omitempty"`
+	AddressMinersLimit  string `json:"address_miners_limit,omitempty"`
+
+	// MRC-20 operations
+	Tick              string `json:"tick,omitempty"`
+	BalanceBefore     string `json:"balance_before,omitempty"` // Including the mining rewards not settled yet
+	BalanceAfter      string `json:"balance_after,omitempty"`
+	Target            string `json:"target,omitempty"` // Miner a burn, stake, unstake or delegation names
+	TargetPowerBefore string `json:"target_power_before,omitempty"`
+	TargetPowerAfter  string `json:"target_power_after,omitempty"`
+}
+
+// previewState is the state an inscription preview compares before and after the inscription is applied.
+type previewState struct {
+	miners, minersMax, addressMiners string
+	balance, targetPower             string
+}
+
+// readPreviewState reads the state of the collection and the tick of preview for address.
+func readPreviewState(txn *badger.Txn, preview *WebInscriptionPreview, address string) previewState {
+	var state previewState
+	if preview.Mrc721name != "" {
+		if item, err := txn.Get([]byte("mrc721::geninsc::" + preview.Mrc721name)); err == nil {
+			var genesisData Mrc721GenesisData
+			if item.Value(func(val []byte) error {
+				return jsoniter.Unmarshal(val, &genesisData)
+			}) == nil {
+				state.miners = strconv.Itoa(genesisData.InscriptionsCount)
+				state.minersMax = strconv.Itoa(genesisData.InscriptionsMax)
+			}
+		} else {
+			state.miners = "0"
+		}
+		state.addressMiners = "0"
+		if item, err := txn.Get([]byte("mrc721::addr_num::" + preview.Mrc721name + "::" + address)); err == nil {
+			_ = item.Value(func(val []byte) error {
+				state.addressMiners = string(val)
+				return nil
+			})
+		}
+	}
+	if preview.Tick != "" {
+		balance := "0"
+		if item, err := txn.Get([]byte("mrc20::balance::" + address + "::" + preview.Tick)); err == nil {
+			_ = item.Value(func(val []byte) error {
+				balance = string(val)
+				return nil
+			})
+		}
+		state.balance = unlockedEffectiveBalance(txn, address, preview.Tick, balance)
+	}
+	if preview.Target != "" {
+		if item, err := txn.Get([]byte("mrc721::inscr_power::" + preview.Target)); err == nil {
+			_ = item.Value(func(val []byte) error {
+				state.targetPower = new(big.Int).SetBytes(val).String()
+				return nil
+			})
+		}
+	}
+	return state
+}
+
+// PreviewInscription runs content inscribed by address through the validation and the apply logic of the next
+// block, in a transaction which is discarded, and returns its verdict with the state it would change. Nothing is
+// written: the collections cached by the write path are dropped like after a failed block.
+func (b *BTOrdIdx) PreviewInscription(content []byte, contentType, address string) (WebInscriptionPreview, error) {
+	if len(content) == 0 || address == "" {
+		return WebInscriptionPreview{}, errors.New("content and address are required")
+	}
+
+	// The write path state is used, block writes wait for the preview
+	b.rwLock.Lock()
+	defer b.rwLock.Unlock()
+
+	txn := b.db.NewTransaction(true)
+	defer txn.Discard()
+	defer func() {
+		b.registry.discard()
+		b.rollbackProtocolHandlers()
+		b.verdict = nil
+	}()
+
+	var preview WebInscriptionPreview
+	item, err := txn.Get([]byte("latestblock"))
+	if err != nil {
+		return WebInscriptionPreview{}, err
+	}
+	var height int64
+	if err := item.Value(func(val []byte) error {
+		height, err = strconv.ParseInt(string(val), 10, 64)
+		return err
+	}); err != nil {
+		return WebInscriptionPreview{}, err
+	}
+	height++
+	preview.BlockHeight = strconv.FormatInt(height, 10)
+	if err := b.useRules(txn, height); err != nil {
+		return WebInscriptionPreview{}, err
+	}
+
+	// What the content names decides the state to compare
+	handler := detectProtocolHandler(content)
+	if handler == nil {
+		return preview, nil
+	}
+	preview.Protocol = handler.Name()
+	switch preview.Protocol {
+	case "mrc-721":
+		if protocol, err := ParseMRC721Protocol(content); err == nil {
+			preview.Mrc721name = protocol.Miner.GetUpperName()
+			preview.AddressMinersLimit = protocol.Miner.Lim
+		}
+	case "mrc-721html", "mrc-721svg":
+		var protocol *MRC721Protocol
+		if preview.Protocol == "mrc-721html" {
+			protocol, err = b.parseMRC721HtmlProtocol(txn, content)
+		} else {
+			protocol, err = b.parseMRC721SvgProtocol(txn, content)
+		}
+		if err == nil {
+			preview.Mrc721name = protocol.Miner.GetUpperName()
+			preview.AddressMinersLimit = protocol.Miner.Lim
+		}
+	case "mrc-20":
+		if mrc20Data, err := ParseMRC20Protocol(content); err == nil {
+			preview.Tick = mrc20Data.Tick
+			if mrc20Data.Insc != nil {
+				preview.Target = *mrc20Data.Insc
+			}
+		}
+	}
+	before := readPreviewState(txn, &preview, address)
+
+	// The inscription gets an ID of its own, its number does not matter as the transaction is discarded
+	hash := sha256.Sum256(append([]byte(address+"\n"), content...))
+	inscription := HookInscription{
+		ID:            hex.EncodeToString(hash[:]) + "i0",
+		Address:       address,
+		BlockHeight:   int(height),
+		ContentByte:   &content,
+		ContentType:   contentType,
+		ContentLength: len(content),
+	}
+	block := &HookBlock{
+		BlockHeight:  preview.BlockHeight,
+		Timestamp:    time.Now().Unix(),
+		Inscriptions: []HookInscription{inscription},
+	}
+	if err := b.addInscriptionList(txn, block); err != nil {
+		return WebInscriptionPreview{}, fmt.Errorf("preview failed: %w", err)
+	}
+	item, err = txn.Get([]byte("verdict::" + inscription.ID))
+	if err != nil {
+		return WebInscriptionPreview{}, err
+	}
+	var verdict InscriptionVerdict
+	if err := item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &verdict)
+	}); err != nil {
+		return WebInscriptionPreview{}, err
+	}
+	preview.Status, preview.Reason, preview.Detail = verdict.Status, verdict.Reason, verdict.Detail
+
+	after := readPreviewState(txn, &preview, address)
+	preview.MinersBefore, preview.MinersAfter = before.miners, after.miners
+	preview.MinersMax = after.minersMax
+	if preview.MinersMax == "" {
+		preview.MinersMax = before.minersMax
+	}
+	preview.AddressMinersBefore, preview.AddressMinersAfter = before.addressMiners, after.addressMiners
+	preview.BalanceBefore, preview.BalanceAfter = before.balance, after.balance
+	preview.TargetPowerBefore, preview.TargetPowerAfter = before.targetPower, after.targetPower
+	return preview, nil
+}
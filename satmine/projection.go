@@ -0,0 +1,304 @@
+// filePath: satmine/projection.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// maxProjectionPoints bounds the number of points of a mining projection.
+const maxProjectionPoints = 10000
+
+// WebProjectionPoint is the emission expected from the next block up to and including a height.
+type WebProjectionPoint struct {
+	BlockHeight     string `json:"block_height"`
+	Released        string `json:"released"`          // Emission released, capped by the remaining supply
+	MinedTokens     string `json:"mined_tokens"`      // Part of the emission distributed to the miners
+	PrizePoolTokens string `json:"prize_pool_tokens"` // Part of the emission put into the prize pool
+	FeeTokens       string `json:"fee_tokens"`        // Part of the emission credited as creator fee
+}
+
+// WebMinerProjection is what a miner is expected to earn over the horizon of a projection.
+type WebMinerProjection struct {
+	InscriptionID    string `json:"inscription_id"`
+	Power            string `json:"power"`
+	Pending          string `json:"pending"`           // Rewards mined so far and not settled yet
+	ExpectedEarnings string `json:"expected_earnings"` // Share of the mined tokens of the horizon, by power
+}
+
+// WebMiningProjection projects the emission of a collection from its indexed state: the remaining supply and the
+// current miners and powers. Miners minted, burnt or boosted later are not foreseen, and the per-block flooring of
+// the distribution is ignored, so earnings are estimates.
+type WebMiningProjection struct {
+	Mrc721name         string               `json:"mrc721name"`
+	CurrentHeight      string               `json:"current_height"`
+	RemainingSupply    string               `json:"remaining_supply"`    // Tokens neither mined nor put into the prize pool
+	RemainingEmission  string               `json:"remaining_emission"`  // Tokens the schedule still releases, at most RemainingSupply
+	EndHeight          string               `json:"end_height"`          // First height releasing nothing, the next height if mining has ended, empty if it never ends
+	EndReason          string               `json:"end_reason"`          // FullRelease or NotFullyReleased, like MiningRewardCalculation
+	Miners             int                  `json:"miners"`              //
+	TotalPower         string               `json:"total_power"`         //
+	BaseMinerEarnings  string               `json:"base_miner_earnings"` // Expected earnings over the horizon of a miner of base power
+	Points             []WebProjectionPoint `json:"points"`
+	Address            string               `json:"address,omitempty"`
+	AddressMiners      []WebMinerProjection `json:"address_miners,omitempty"`   // Miners rewarding Address
+	AddressEarnings    string               `json:"address_earnings,omitempty"` // Expected earnings over the horizon of the miners rewarding Address
+	HorizonMinedTokens string               `json:"horizon_mined_tokens"`       // Tokens distributed to the miners over the horizon
+}
+
+// emissionOutlook is what the schedule of a collection still releases after the latest block, from its indexed
+// state.
+type emissionOutlook struct {
+	protocol      *MRC721Protocol
+	genesis       Mrc721GenesisData
+	currentHeight int64
+	schedule      *EmissionSchedule
+	emitted       *big.Int // Released by the schedule up to the latest block
+	poolEmitted   *big.Int
+	feeEmitted    *big.Int
+	remaining     *big.Int // Tokens neither mined nor put into the prize pool
+	endHeight     int64
+	endReason     string
+	isEnded       bool
+}
+
+// loadEmissionOutlook reads the emission outlook of a collection, with the sections of its protocol enabled by the
+// latest rules.
+func loadEmissionOutlook(txn *badger.Txn, mrc721Name string) (*emissionOutlook, error) {
+	o := &emissionOutlook{}
+	item, err := txn.Get([]byte("mrc721::geninsc::" + mrc721Name))
+	if err != nil {
+		return nil, fmt.Errorf("no collection %s: %w", mrc721Name, err)
+	}
+	if err := item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &o.genesis)
+	}); err != nil {
+		return nil, err
+	}
+	var inscription HookInscription
+	item, err = txn.Get([]byte("inscr::" + o.genesis.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := item.Value(func(val []byte) error {
+		return unmarshalInscription(txn, val, &inscription)
+	}); err != nil {
+		return nil, err
+	}
+	o.protocol, err = ParseMRC721Protocol(*inscription.ContentByte)
+	if err != nil {
+		return nil, err
+	}
+	LatestRules().applyMinerOverride(o.protocol)
+	if o.protocol.Fee != nil && !LatestRules().CreatorFee {
+		o.protocol.Fee = nil
+	}
+
+	item, err = txn.Get([]byte("latestblock"))
+	if err != nil {
+		return nil, err
+	}
+	if err := item.Value(func(val []byte) error {
+		o.currentHeight, err = strconv.ParseInt(string(val), 10, 64)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	genesisHeight, err := strconv.ParseInt(o.genesis.BlockHeight, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// The supply left is what the next blocks can release
+	remaining, ok := new(big.Int).SetString(o.protocol.Token.Total, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid token total: %q", o.protocol.Token.Total)
+	}
+	totalMined, _ := new(big.Int).SetString(o.genesis.TotalMinedTokens, 10)
+	totalPrizePool, _ := new(big.Int).SetString(o.genesis.TotalPrizePoolTokens, 10)
+	if totalMined == nil || totalPrizePool == nil {
+		return nil, errors.New("invalid mined or prize pool tokens")
+	}
+	remaining.Sub(remaining, totalMined)
+	remaining.Sub(remaining, totalPrizePool)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	if err := o.init(genesisHeight, remaining); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// init sets the schedule of the protocol of o from genesisHeight, and when mining ends given the supply remaining
+// after o.currentHeight.
+func (o *emissionOutlook) init(genesisHeight int64, remaining *big.Int) (err error) {
+	o.schedule, err = NewEmissionSchedule(o.protocol, genesisHeight)
+	if err != nil {
+		return err
+	}
+	o.emitted = o.schedule.CumulativeAt(o.currentHeight)
+	o.poolEmitted = o.schedule.PrizePoolAt(o.currentHeight)
+	o.feeEmitted = o.schedule.FeeAt(o.currentHeight)
+	o.remaining = remaining
+
+	// Mining ends at the first block finding the supply fully released, or else at the first block emitting nothing
+	if zeroHeight, ok := o.schedule.EndHeight(); ok {
+		o.endHeight, o.endReason, o.isEnded = zeroHeight, "NotFullyReleased", true
+		if o.endHeight <= o.currentHeight {
+			o.endHeight = o.currentHeight + 1
+		}
+	}
+	fullHeight, fullOk := o.currentHeight+1, remaining.Sign() == 0
+	if !fullOk {
+		if reached, ok := o.schedule.HeightReaching(new(big.Int).Add(o.emitted, remaining)); ok {
+			fullHeight, fullOk = reached+1, true
+		}
+	}
+	if fullOk && (!o.isEnded || fullHeight <= o.endHeight) {
+		o.endHeight, o.endReason, o.isEnded = fullHeight, "FullRelease", true
+	}
+	return nil
+}
+
+// releasedAt splits the emission from the next block up to and including height.
+func (o *emissionOutlook) releasedAt(height int64) (released, minedTokens, prizePoolTokens, feeTokens *big.Int) {
+	if o.isEnded && height >= o.endHeight {
+		height = o.endHeight - 1
+	}
+	if height <= o.currentHeight {
+		return big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	}
+	released = new(big.Int).Sub(o.schedule.CumulativeAt(height), o.emitted)
+	if released.Cmp(o.remaining) <= 0 {
+		prizePoolTokens = new(big.Int).Sub(o.schedule.PrizePoolAt(height), o.poolEmitted)
+		feeTokens = new(big.Int).Sub(o.schedule.FeeAt(height), o.feeEmitted)
+	} else {
+		// The emission of the last block is capped by the remaining supply
+		released.Set(o.remaining)
+		lastEmission := new(big.Int).Sub(o.schedule.CumulativeAt(height-1), o.emitted)
+		lastEmission.Sub(o.remaining, lastEmission)
+		prizePoolTokens = new(big.Int).Sub(o.schedule.PrizePoolAt(height-1), o.poolEmitted)
+		prizePoolTokens.Add(prizePoolTokens, o.schedule.poolPerBlock(lastEmission))
+		feeTokens = new(big.Int).Sub(o.schedule.FeeAt(height-1), o.feeEmitted)
+		feeTokens.Add(feeTokens, o.schedule.feePerBlock(lastEmission))
+	}
+	minedTokens = new(big.Int).Sub(released, prizePoolTokens)
+	minedTokens.Sub(minedTokens, feeTokens)
+	return released, minedTokens, prizePoolTokens, feeTokens
+}
+
+// GetMiningProjection projects the emission of a collection every step blocks over the next horizon blocks, from its
+// indexed state. With an address, the expected earnings of the miners rewarding it are included.
+func (b *BTOrdIdx) GetMiningProjection(mrc721Name string, step, horizon int64, address string) (WebMiningProjection, error) {
+	if step <= 0 || horizon <= 0 {
+		return WebMiningProjection{}, fmt.Errorf("invalid step %d or horizon %d", step, horizon)
+	}
+	if horizon/step > maxProjectionPoints {
+		return WebMiningProjection{}, fmt.Errorf("horizon %d needs more than %d points of step %d", horizon, maxProjectionPoints, step)
+	}
+
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	mrc721Name = strings.ToUpper(strings.TrimSpace(mrc721Name))
+	projection := WebMiningProjection{Mrc721name: mrc721Name, Address: strings.TrimSpace(address), Points: []WebProjectionPoint{}}
+	err := b.db.View(func(txn *badger.Txn) error {
+		o, err := loadEmissionOutlook(txn, mrc721Name)
+		if err != nil {
+			return err
+		}
+		currentHeight := o.currentHeight
+		projection.CurrentHeight = strconv.FormatInt(currentHeight, 10)
+		projection.RemainingSupply = o.remaining.String()
+		projection.EndReason = o.endReason
+		if o.isEnded {
+			projection.EndHeight = strconv.FormatInt(o.endHeight, 10)
+		}
+
+		addPoint := func(height int64) {
+			released, minedTokens, prizePoolTokens, feeTokens := o.releasedAt(height)
+			projection.Points = append(projection.Points, WebProjectionPoint{
+				BlockHeight:     strconv.FormatInt(height, 10),
+				Released:        released.String(),
+				MinedTokens:     minedTokens.String(),
+				PrizePoolTokens: prizePoolTokens.String(),
+				FeeTokens:       feeTokens.String(),
+			})
+		}
+		lastHeight := currentHeight + horizon
+		for height := currentHeight + step; height <= lastHeight; height += step {
+			addPoint(height)
+		}
+		if horizon%step != 0 {
+			addPoint(lastHeight)
+		}
+
+		remainingEmission, _, _, _ := o.releasedAt(o.endHeight)
+		if !o.isEnded {
+			remainingEmission, _, _, _ = o.releasedAt(lastHeight)
+		}
+		projection.RemainingEmission = remainingEmission.String()
+
+		// The mined tokens of the horizon are shared by power
+		_, horizonMined, _, _ := o.releasedAt(lastHeight)
+		projection.HorizonMinedTokens = horizonMined.String()
+		pool, err := getRewardPool(txn, mrc721Name)
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		totalPower := big.NewInt(0)
+		if pool != nil {
+			projection.Miners, totalPower = pool.totals()
+		}
+		projection.TotalPower = totalPower.String()
+		earnings := func(power *big.Int) *big.Int {
+			if totalPower.Sign() == 0 {
+				return big.NewInt(0)
+			}
+			share := new(big.Int).Mul(horizonMined, power)
+			return share.Div(share, totalPower)
+		}
+		projection.BaseMinerEarnings = earnings(big.NewInt(baseMinerPower)).String()
+
+		if projection.Address == "" || pool == nil {
+			return nil
+		}
+		addressEarnings := big.NewInt(0)
+		for _, inscriptionID := range rewardedInscriptions(txn, projection.Address) {
+			ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if ckpt.Name != mrc721Name {
+				continue
+			}
+			power, _ := new(big.Int).SetString(ckpt.Power, 10)
+			expected := earnings(power)
+			addressEarnings.Add(addressEarnings, expected)
+			projection.AddressMiners = append(projection.AddressMiners, WebMinerProjection{
+				InscriptionID:    inscriptionID,
+				Power:            ckpt.Power,
+				Pending:          pool.pending(ckpt).String(),
+				ExpectedEarnings: expected.String(),
+			})
+		}
+		projection.AddressEarnings = addressEarnings.String()
+		return nil
+	})
+	if err != nil {
+		return WebMiningProjection{}, err
+	}
+	return projection, nil
+}
@@ -23,6 +23,8 @@ type MRC721Protocol struct {
 	Token Token    `json:"token"`
 	Ltry  *Lottery `json:"ltry,omitempty"` // Pointer to allow the field to be empty
 	Burn  *Burn    `json:"burn,omitempty"` // Pointer to allow the field to be empty
+	Stake *Stake   `json:"stake,omitempty"`
+	Fee   *Fee     `json:"fee,omitempty"`
 }
 
 // Miner defines the structure for miner information in MRC-721.
@@ -50,32 +52,61 @@ func (t *Token) GetLowerTick() string {
 	return strings.ToLower(t.Tick)
 }
 
-// Lottery defines the lottery parameters in MRC-721.
+// Lottery defines the lottery parameters in MRC-721. Tiers splits the prize of a draw between several winners, as
+// comma-separated shares, and Weight "power" picks the winners in proportion to their mining power, see lottery.go.
 type Lottery struct {
-	Pool  string `json:"pool"`
-	Intvl string `json:"intvl"`
-	Winp  string `json:"winp"`
-	Dist  string `json:"dist"`
+	Pool   string `json:"pool"`
+	Intvl  string `json:"intvl"`
+	Winp   string `json:"winp"`
+	Dist   string `json:"dist"`
+	Tiers  string `json:"tiers,omitempty"`
+	Weight string `json:"weight,omitempty"`
 }
 
-// Stake defines the staking parameters in MRC-721.
+// Burn defines the burning parameters in MRC-721.
 type Burn struct {
 	Unit  string `json:"unit"`
 	Boost string `json:"boost"`
 }
 
+// Stake defines the staking parameters in MRC-721: every Unit tokens staked on a miner add Boost to its power
+// while they stay staked, and a stake cannot be withdrawn before Lock blocks have passed.
+type Stake struct {
+	Unit  string `json:"unit"`
+	Boost string `json:"boost"`
+	Lock  string `json:"lock"`
+}
+
+// Fee defines the creator fee in MRC-721: Rate of the emission left after the lottery pool goes to To, or to the
+// genesis address when To is empty, on every block.
+type Fee struct {
+	Rate string `json:"rate"`
+	To   string `json:"to,omitempty"`
+}
+
 // MRC20Protocol defines the structure for the MRC-20 token transfer protocol.
 type MRC20Protocol struct {
 	P    string  `json:"p"`
 	Op   string  `json:"op"`
 	Tick string  `json:"tick"`
 	Amt  string  `json:"amt"`
-	Dec  *string `json:"dec,omitempty"` // Pointer to allow the field to be empty
-	Insc *string `json:"insc,omitempty"`
+	Dec  *string `json:"dec,omitempty"`  // Pointer to allow the field to be empty
+	Insc *string `json:"insc,omitempty"` // Miner inscription targeted by burn, stake, unstake and delegate
+	To   *string `json:"to,omitempty"`   // Address the rewards are delegated to, the delegation ends without it
 }
 
-// ParseMRC721Protocol parses the MRC721Protocol data from a byte slice.
+// ParseMRC721Protocol parses the MRC721Protocol data from a byte slice, applying the latest protocol rules.
 func ParseMRC721Protocol(data []byte) (*MRC721Protocol, error) {
+	return parseMRC721Protocol(data, LatestRules())
+}
+
+// ParseMRC721ProtocolAt parses the MRC721Protocol data from a byte slice, applying the rules in force at height.
+func ParseMRC721ProtocolAt(data []byte, height int64) (*MRC721Protocol, error) {
+	return parseMRC721Protocol(data, RulesAt(height))
+}
+
+// parseMRC721Protocol parses the MRC721Protocol data and applies the miner overrides of rules.
+func parseMRC721Protocol(data []byte, rules *ProtocolRules) (*MRC721Protocol, error) {
 	var protocol MRC721Protocol
 	err := jsoniter.Unmarshal(data, &protocol)
 	if err != nil {
@@ -101,22 +132,25 @@ func ParseMRC721Protocol(data []byte) (*MRC721Protocol, error) {
 		protocol.Ltry.Intvl = strings.TrimSpace(protocol.Ltry.Intvl)
 		protocol.Ltry.Winp = strings.TrimSpace(protocol.Ltry.Winp)
 		protocol.Ltry.Dist = strings.TrimSpace(protocol.Ltry.Dist)
+		protocol.Ltry.Tiers = strings.TrimSpace(protocol.Ltry.Tiers)
+		protocol.Ltry.Weight = strings.TrimSpace(protocol.Ltry.Weight)
 	}
 	if protocol.Burn != nil {
 		protocol.Burn.Unit = strings.TrimSpace(protocol.Burn.Unit)
 		protocol.Burn.Boost = strings.TrimSpace(protocol.Burn.Boost)
 	}
-
-	// Modification of SATMINE data for community reasons
-	// https://twitter.com/SatMineOfficial/status/1744347847208702031
-	// Due to an unfortunate error in SATMINE's initial inscription process, an extra 32% of the supply was inscribed. Our indexer currently does not recognize these NFTs.
-	// We fully value our community's input, so the handling of these additional NFTs will be put to a poll. We can either 1) continue to disregard these extra NFTs, or 2) include them and increase the total supply of SATMINE to 13263. Voting will last 24 hours.
-	// Disregard the extra NFTs 34.1%    Include the extra NFTs  65.9%
-	if protocol.Miner.GetUpperName() == "SATMINE" {
-		protocol.Miner.Max = "13263"
-		//protocol.Miner.Max = "20000"
-		protocol.Miner.Lim = "100"
+	if protocol.Stake != nil {
+		protocol.Stake.Unit = strings.TrimSpace(protocol.Stake.Unit)
+		protocol.Stake.Boost = strings.TrimSpace(protocol.Stake.Boost)
+		protocol.Stake.Lock = strings.TrimSpace(protocol.Stake.Lock)
 	}
+	if protocol.Fee != nil {
+		protocol.Fee.Rate = strings.TrimSpace(protocol.Fee.Rate)
+		protocol.Fee.To = strings.TrimSpace(protocol.Fee.To)
+	}
+
+	// Collections whose miner settings were changed after deployment, see rules.go
+	rules.applyMinerOverride(&protocol)
 
 	return &protocol, nil
 }
@@ -229,7 +263,7 @@ func ParseMRC721HtmlProtocol(txn *badger.Txn, data []byte) (*MRC721Protocol, err
 
 	var hookInscription HookInscription
 	err = item.Value(func(val []byte) error {
-		return jsoniter.Unmarshal(val, &hookInscription)
+		return unmarshalInscription(txn, val, &hookInscription)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal HookInscription: %v", err)
@@ -260,48 +294,37 @@ func ParseMRC20Protocol(data []byte) (*MRC20Protocol, error) {
 		trimmedDec := strings.TrimSpace(*protocol.Dec)
 		protocol.Dec = &trimmedDec
 	}
+	if protocol.To != nil {
+		trimmedTo := strings.TrimSpace(*protocol.To)
+		protocol.To = &trimmedTo
+	}
 
 	return &protocol, nil
 }
 
-// ValidateProtocolData validates the protocol data for either MRC-20, MRC-721, or MRC-721 HTML.
+// ValidateProtocolData validates the protocol data with the registered protocol handlers,
+// with the latest protocol rules.
 // It checks if all required fields in the protocol have a non-zero string length.
 // Returns a boolean indicating validity, the protocol name, and an error if any.
 func ValidateProtocolData(data []byte) (bool, string, error) {
-	// Trim leading and trailing whitespace from the data
-	trimmedData := strings.TrimSpace(string(data))
-	//fmt.Println("---trimmedData=", trimmedData)
-
-	// Check if the trimmed data starts with "<!DOCTYPE html>", which indicates HTML data.
-	if strings.HasPrefix(trimmedData, "<!DOCTYPE html>") {
-		return validateMRC721HtmlData([]byte(trimmedData))
-	}
-	if strings.HasPrefix(trimmedData, "<html>") {
-		return validateMRC721HtmlData([]byte(trimmedData))
-	}
-	if strings.HasPrefix(trimmedData, "<svg") {
-		return validateMRC721SvgData([]byte(trimmedData))
-	}
-
-	var p struct {
-		P string `json:"p"`
-	}
+	return validateProtocolData(data, LatestRules())
+}
 
-	// Decode the JSON data to get the protocol type.
-	err := jsoniter.Unmarshal(data, &p)
-	if err != nil {
-		return false, "", err
-	}
+// ValidateProtocolDataAt validates the protocol data with the rules in force at height.
+func ValidateProtocolDataAt(data []byte, height int64) (bool, string, error) {
+	return validateProtocolData(data, RulesAt(height))
+}
 
-	// Choose the validation function based on the protocol type.
-	switch p.P {
-	case "mrc-721":
-		return validateMRC721Data(data)
-	case "mrc-20":
-		return validateMRC20Data(data)
-	default:
+// validateProtocolData validates the protocol data against rules, with the first registered handler detecting it.
+func validateProtocolData(data []byte, rules *ProtocolRules) (bool, string, error) {
+	handler := detectProtocolHandler(data)
+	if handler == nil {
 		return false, "", errors.New("unknown protocol")
 	}
+	if err := handler.Validate(data, rules); err != nil {
+		return false, handler.Name(), err
+	}
+	return true, handler.Name(), nil
 }
 
 // validateMRC721HtmlData validates the MRC-721 HTML data.
@@ -360,6 +383,11 @@ func IsEqual721DataByte(a, b *[]byte) bool {
 		return false
 	}
 
+	return isEqual721Protocol(protocolA, protocolB)
+}
+
+// isEqual721Protocol compares the fields of two parsed MRC-721 protocols.
+func isEqual721Protocol(protocolA, protocolB *MRC721Protocol) bool {
 	// Compare each field of the protocol structs
 	if protocolA.P != protocolB.P ||
 		protocolA.Miner.Name != protocolB.Miner.Name ||
@@ -378,7 +406,9 @@ func IsEqual721DataByte(a, b *[]byte) bool {
 		(protocolA.Ltry.Pool != protocolB.Ltry.Pool ||
 			protocolA.Ltry.Intvl != protocolB.Ltry.Intvl ||
 			protocolA.Ltry.Winp != protocolB.Ltry.Winp ||
-			protocolA.Ltry.Dist != protocolB.Ltry.Dist) {
+			protocolA.Ltry.Dist != protocolB.Ltry.Dist ||
+			protocolA.Ltry.Tiers != protocolB.Ltry.Tiers ||
+			protocolA.Ltry.Weight != protocolB.Ltry.Weight) {
 		return false
 	}
 
@@ -389,6 +419,21 @@ func IsEqual721DataByte(a, b *[]byte) bool {
 		return false
 	}
 
+	// Check Stake fields if present
+	if (protocolA.Stake != nil && protocolB.Stake != nil) &&
+		(protocolA.Stake.Unit != protocolB.Stake.Unit ||
+			protocolA.Stake.Boost != protocolB.Stake.Boost ||
+			protocolA.Stake.Lock != protocolB.Stake.Lock) {
+		return false
+	}
+
+	// Check Fee fields if present
+	if (protocolA.Fee != nil && protocolB.Fee != nil) &&
+		(protocolA.Fee.Rate != protocolB.Fee.Rate ||
+			protocolA.Fee.To != protocolB.Fee.To) {
+		return false
+	}
+
 	// All fields are equal
 	return true
 }
@@ -396,16 +441,29 @@ func IsEqual721DataByte(a, b *[]byte) bool {
 // It uses ParseMRC721Protocol to parse the byte slices. If parsing fails or any field is not equal, it returns false.
 // If all fields are equal, it returns true.
 func IsEqual721Data(a, b *HookInscription) bool {
-	// Parse the first byte slice using ParseMRC721Protocol
-	if IsEqual721DataByte(a.ContentByte, b.ContentByte) {
-		return true
-	}
-
+	// Parse the genesis inscription using ParseMRC721Protocol
 	protocolA, err := ParseMRC721Protocol(*a.ContentByte)
 	if err != nil {
 		return false
 	}
 
+	return IsEqual721Protocol(a.ID, protocolA, b)
+}
+
+// IsEqual721Protocol reports whether inscription b mints the collection deployed by the genesis inscription
+// genesisID, whose parsed protocol is protocolA. b is either an identical JSON deploy or an HTML/SVG
+// inscription referencing the collection name and the genesis inscription ID.
+func IsEqual721Protocol(genesisID string, protocolA *MRC721Protocol, b *HookInscription) bool {
+	return isEqual721Inscription(genesisID, protocolA, b, LatestRules())
+}
+
+// isEqual721Inscription is IsEqual721Protocol with b parsed under the given rules.
+func isEqual721Inscription(genesisID string, protocolA *MRC721Protocol, b *HookInscription, rules *ProtocolRules) bool {
+	protocolB, err := parseMRC721Protocol(*b.ContentByte, rules)
+	if err == nil && isEqual721Protocol(protocolA, protocolB) {
+		return true
+	}
+
 	//mrc721html,err :=  ParseMRC721HtmlProtocol(txn *badger.Txn, data []byte)
 	mrc721name, mrc721ID, err := HtmlToNameID(*b.ContentByte)
 	if err != nil {
@@ -417,7 +475,7 @@ func IsEqual721Data(a, b *HookInscription) bool {
 
 	// mrc721name ToUpper
 	mrc721name = strings.ToUpper(mrc721name)
-	if protocolA.Miner.GetUpperName() == mrc721name && a.ID == mrc721ID {
+	if protocolA.Miner.GetUpperName() == mrc721name && genesisID == mrc721ID {
 		return true
 	}
 
@@ -425,7 +483,11 @@ func IsEqual721Data(a, b *HookInscription) bool {
 }
 
 // validateMRC20Data validates the MRC-20 protocol data.
-func validateMRC20Data(data []byte) (bool, string, error) {
+func validateMRC20Data(data []byte, rules *ProtocolRules) (bool, string, error) {
+	if err := rules.checkStrict(data, mrc20StrictFields); err != nil {
+		return false, "mrc-20", err
+	}
+
 	var protocol MRC20Protocol
 	err := jsoniter.Unmarshal(data, &protocol)
 	if err != nil {
@@ -438,23 +500,33 @@ func validateMRC20Data(data []byte) (bool, string, error) {
 		return false, "mrc-20", errors.New("invalid protocol type")
 	}
 
-	// Validate the 'Op' field to be either "transfer" or "burn".
-	if protocol.Op != "transfer" && protocol.Op != "burn" {
+	// Validate the 'Op' field to be "transfer" or "burn", "stake" or "unstake" once staking is enabled,
+	// or "delegate" once delegation is enabled.
+	staking := rules.Staking && (protocol.Op == "stake" || protocol.Op == "unstake")
+	delegation := rules.Delegation && protocol.Op == "delegate"
+	if protocol.Op != "transfer" && protocol.Op != "burn" && !staking && !delegation {
 		return false, "mrc-20", errors.New("invalid operation type")
 	}
 
-	// Validate the 'Tick' field to be lowercase and have a length <= 4.
-	if len(protocol.Tick) > 4 || protocol.Tick != strings.ToLower(protocol.Tick) {
+	// Validate the 'Tick' field to be lowercase and have a length <= MaxTickLength.
+	if len(protocol.Tick) > rules.MaxTickLength || protocol.Tick != strings.ToLower(protocol.Tick) {
 		return false, "mrc-20", errors.New("invalid ticker format")
 	}
 
 	// Validate the 'Amt' field to be a valid big.Int and have a length <= 666.
-	if len(protocol.Amt) > 666 {
-		return false, "mrc-20", errors.New("amount exceeds maximum length")
-	}
-	_, ok := new(big.Int).SetString(protocol.Amt, 10)
-	if !ok {
-		return false, "mrc-20", errors.New("invalid amount format")
+	// A delegation moves no tokens, it names the address the rewards go to instead.
+	if protocol.Op == "delegate" {
+		if protocol.To != nil && strings.TrimSpace(*protocol.To) == "" {
+			return false, "mrc-20", errors.New("delegate address must not be empty")
+		}
+	} else {
+		if len(protocol.Amt) > 666 {
+			return false, "mrc-20", errors.New("amount exceeds maximum length")
+		}
+		_, ok := new(big.Int).SetString(protocol.Amt, 10)
+		if !ok {
+			return false, "mrc-20", errors.New("invalid amount format")
+		}
 	}
 
 	// Validate the 'Dec' field to be either nil or "8".
@@ -478,99 +550,187 @@ func validateMRC20Data(data []byte) (bool, string, error) {
 // }
 
 // validateMRC721Data validates the MRC-721 protocol data against specific rules.
-func validateMRC721Data(data []byte) (bool, string, error) {
+func validateMRC721Data(data []byte, rules *ProtocolRules) (bool, string, error) {
+	if err := rules.checkStrict(data, mrc721StrictFields); err != nil {
+		return false, "mrc-721", err
+	}
+
 	var protocol MRC721Protocol
 	err := jsoniter.Unmarshal(data, &protocol)
 	if err != nil {
 		return false, "mrc-721", err
 	}
 
+	if issues := mrc721Issues(&protocol, rules); len(issues) > 0 {
+		return false, "mrc-721", issues[0].err
+	}
+
+	return true, "mrc-721", nil
+}
+
+// protocolIssue is a rule broken by a section of an MRC-721 protocol.
+type protocolIssue struct {
+	section string
+	err     error
+}
+
+// mrc721Issues returns every rule the MRC-721 protocol breaks, in the order validateMRC721Data reports them.
+func mrc721Issues(protocol *MRC721Protocol, rules *ProtocolRules) []protocolIssue {
+	var issues []protocolIssue
+	add := func(section string, errs []error) {
+		for _, err := range errs {
+			issues = append(issues, protocolIssue{section: section, err: err})
+		}
+	}
+
 	// Validate the 'P' field
 	if protocol.P != "mrc-721" {
-		return false, "mrc-721", errors.New("protocol P must be 'mrc-721'")
+		add("p", []error{errors.New("protocol P must be 'mrc-721'")})
 	}
 
 	// Validate Token
-	if err := validateToken(protocol.Token); err != nil {
-		fmt.Println("validateMRC721Data err=", err)
-		return false, "mrc-721", err
-	}
+	add("token", tokenIssues(protocol.Token, rules))
 
 	// Validate Miner
-	if err := validateMiner(protocol.Miner); err != nil {
-		return false, "mrc-721", err
-	}
+	add("miner", minerIssues(protocol.Miner))
 
 	// Validate Lottery if it's not nil
 	if protocol.Ltry != nil {
-		if err := validateLottery(*protocol.Ltry); err != nil {
-			return false, "mrc-721", err
-		}
+		add("ltry", lotteryIssues(*protocol.Ltry, rules))
 	}
 
 	// Validate Burn if it's not nil
 	if protocol.Burn != nil {
-		if err := validateBurn(*protocol.Burn, protocol.Token.Total); err != nil {
-			return false, "mrc-721", err
-		}
+		add("burn", burnIssues(*protocol.Burn, protocol.Token.Total))
 	}
 
-	return true, "mrc-721", nil
+	// Validate Stake if it's not nil, the section is ignored until staking is enabled
+	if protocol.Stake != nil && rules.Staking {
+		add("stake", stakeIssues(*protocol.Stake, protocol.Token.Total))
+	}
+
+	// Validate Fee if it's not nil, the section is ignored until creator fees are enabled
+	if protocol.Fee != nil && rules.CreatorFee {
+		add("fee", feeIssues(*protocol.Fee))
+	}
+
+	return issues
 }
 
-// validateMiner checks if the Miner structure meets the defined requirements.
-func validateMiner(miner Miner) error {
+// minerIssues returns every requirement the Miner structure breaks.
+func minerIssues(miner Miner) []error {
+	var errs []error
 	maxBigInt, ok := big.NewInt(0).SetString(miner.Max, 10)
 	if !ok || maxBigInt.Cmp(big.NewInt(1)) == -1 || maxBigInt.Cmp(big.NewInt(100000000)) == 1 {
-		return errors.New("miner Max must be a big.Int between 1 and 100000000")
+		errs = append(errs, errors.New("miner Max must be a big.Int between 1 and 100000000"))
+		maxBigInt = nil
 	}
 
 	limBigInt, ok := big.NewInt(0).SetString(miner.Lim, 10)
-	if !ok || limBigInt.Cmp(big.NewInt(1)) == -1 || limBigInt.Cmp(maxBigInt) == 1 {
-		return errors.New("miner Lim must be a big.Int between 1 and Max value")
+	if !ok || limBigInt.Cmp(big.NewInt(1)) == -1 || (maxBigInt != nil && limBigInt.Cmp(maxBigInt) == 1) {
+		errs = append(errs, errors.New("miner Lim must be a big.Int between 1 and Max value"))
 	}
 
-	return nil
+	return errs
 }
 
-// validateLottery checks if the Lottery structure meets the defined requirements.
-func validateLottery(ltry Lottery) error {
+// lotteryIssues returns every requirement the Lottery structure breaks. Tiers and Weight are ignored until the rules
+// honour them.
+func lotteryIssues(ltry Lottery, rules *ProtocolRules) []error {
+	var errs []error
+
 	// Validate Pool, Winp, Dist
-	if err := validatePercentageField(ltry.Pool); err != nil {
-		return err
-	}
-	if err := validatePercentageField(ltry.Winp); err != nil {
-		return err
-	}
-	if err := validatePercentageField(ltry.Dist); err != nil {
-		return err
+	for _, field := range []string{ltry.Pool, ltry.Winp, ltry.Dist} {
+		if err := validatePercentageField(field); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	// Validate Intvl
 	intvlBigInt, ok := big.NewInt(0).SetString(ltry.Intvl, 10)
 	if !ok || intvlBigInt.Cmp(big.NewInt(1)) == -1 || intvlBigInt.Cmp(big.NewInt(100000000)) == 1 {
-		return errors.New("lottery Intvl must be a big.Int between 1 and 100000000")
+		errs = append(errs, errors.New("lottery Intvl must be a big.Int between 1 and 100000000"))
 	}
 
-	return nil
+	if !rules.LotteryDraws {
+		return errs
+	}
+
+	// Validate Tiers
+	if ltry.Tiers != "" {
+		if _, err := parseLotteryTiers(ltry.Tiers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Validate Weight
+	if ltry.Weight != "" && ltry.Weight != lotteryWeightPower {
+		errs = append(errs, errors.New("lottery Weight must be empty or 'power'"))
+	}
+
+	return errs
 }
 
-// validateBurn checks if the Burn structure meets the defined requirements.
-func validateBurn(burn Burn, max string) error {
+// burnIssues returns every requirement the Burn structure breaks.
+func burnIssues(burn Burn, max string) []error {
+	var errs []error
+
 	// Validate Unit
-	maxBigInt, _ := big.NewInt(0).SetString(max, 10) // Already validated in validateMiner
+	maxBigInt, maxOk := big.NewInt(0).SetString(max, 10) // Checked by tokenIssues
 	unitBigInt, ok := big.NewInt(0).SetString(burn.Unit, 10)
 
-	if !ok || unitBigInt.Cmp(big.NewInt(1)) == -1 || unitBigInt.Cmp(maxBigInt) == 1 {
-		return errors.New("burn Unit must be a big.Int between 1 and Max value")
+	if !ok || unitBigInt.Cmp(big.NewInt(1)) == -1 || (maxOk && unitBigInt.Cmp(maxBigInt) == 1) {
+		errs = append(errs, errors.New("burn Unit must be a big.Int between 1 and Max value"))
 	}
 
 	// Validate Boost
 	if err := validatePercentageField(burn.Boost); err != nil {
-		return err
+		errs = append(errs, err)
 	}
 
-	return nil
+	return errs
+}
+
+// stakeIssues returns every requirement the Stake structure breaks.
+func stakeIssues(stake Stake, max string) []error {
+	var errs []error
+
+	// Validate Unit
+	maxBigInt, maxOk := big.NewInt(0).SetString(max, 10) // Checked by tokenIssues
+	unitBigInt, ok := big.NewInt(0).SetString(stake.Unit, 10)
+	if !ok || unitBigInt.Cmp(big.NewInt(1)) == -1 || (maxOk && unitBigInt.Cmp(maxBigInt) == 1) {
+		errs = append(errs, errors.New("stake Unit must be a big.Int between 1 and Max value"))
+	}
+
+	// Validate Boost
+	if err := validatePercentageField(stake.Boost); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate Lock
+	lockBigInt, ok := big.NewInt(0).SetString(stake.Lock, 10)
+	if !ok || lockBigInt.Cmp(big.NewInt(1)) == -1 || lockBigInt.Cmp(big.NewInt(100000000)) == 1 {
+		errs = append(errs, errors.New("stake Lock must be a big.Int between 1 and 100000000"))
+	}
+
+	return errs
+}
+
+// feeIssues returns every requirement the Fee structure breaks.
+func feeIssues(fee Fee) []error {
+	var errs []error
+
+	// Validate Rate
+	if err := validatePercentageField(fee.Rate); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate To, an empty recipient is the genesis address
+	if len(fee.To) > 100 {
+		errs = append(errs, errors.New("fee To must not exceed 100 characters"))
+	}
+
+	return errs
 }
 
 // validatePercentageField checks if a string field can be converted to a percentage big.Int between 0 and 1000.
@@ -586,42 +746,44 @@ func validatePercentageField(field string) error {
 	return nil
 }
 
-// validateToken checks if the Token structure meets the defined requirements.
-func validateToken(token Token) error {
-	// Check if Tick is not more than 4 characters
-	if len(token.Tick) > 4 {
-		return errors.New("token Tick must be a string with a maximum of 4 characters")
+// tokenIssues returns every requirement the Token structure breaks.
+func tokenIssues(token Token, rules *ProtocolRules) []error {
+	var errs []error
+
+	// Check if Tick is not more than MaxTickLength characters
+	if len(token.Tick) > rules.MaxTickLength {
+		errs = append(errs, fmt.Errorf("token Tick must be a string with a maximum of %d characters", rules.MaxTickLength))
 	}
 
 	// Determine if token.Tick is all lowercase
 	if token.Tick != strings.ToLower(token.Tick) {
-		return errors.New("token Tick must be lowercase")
+		errs = append(errs, errors.New("token Tick must be lowercase"))
 	}
 
 	// Check if Total is a valid big.Int and does not exceed 100 characters
-	totalBigInt, ok := big.NewInt(0).SetString(token.Total, 10)
-	if !ok || len(token.Total) > 100 {
-		return errors.New("token Total must be a string convertible to big.Int and not exceed 100 characters")
+	totalBigInt, totalOk := big.NewInt(0).SetString(token.Total, 10)
+	if !totalOk || len(token.Total) > 100 {
+		errs = append(errs, errors.New("token Total must be a string convertible to big.Int and not exceed 100 characters"))
 	}
 
 	// Check if Beg is a valid big.Int, does not exceed 100 characters, and is less than or equal to Total
 	begBigInt, ok := big.NewInt(0).SetString(token.Beg, 10)
-	if !ok || len(token.Beg) > 100 || begBigInt.Cmp(totalBigInt) == 1 {
-		return errors.New("token Beg must be a string convertible to big.Int, not exceed 100 characters, and be less than or equal to Total")
+	if !ok || len(token.Beg) > 100 || (totalOk && begBigInt.Cmp(totalBigInt) == 1) {
+		errs = append(errs, errors.New("token Beg must be a string convertible to big.Int, not exceed 100 characters, and be less than or equal to Total"))
 	}
 
 	// Validate Halv as a big.Int between 1 and 100000000
 	halvBigInt, ok := big.NewInt(0).SetString(token.Halv, 10)
 	if !ok || halvBigInt.Cmp(big.NewInt(1)) == -1 || halvBigInt.Cmp(big.NewInt(100000000)) == 1 {
-		return errors.New("token Halv must be a string convertible to big.Int and between 1 and 100000000")
+		errs = append(errs, errors.New("token Halv must be a string convertible to big.Int and between 1 and 100000000"))
 	}
 
 	// Validate Dcr using validatePercentageField
 	if err := validatePercentageField(token.Dcr); err != nil {
-		return fmt.Errorf("token Dcr validation error: %s", err)
+		errs = append(errs, fmt.Errorf("token Dcr validation error: %s", err))
 	}
 
-	return nil
+	return errs
 }
 
 // validateMRC721SvgData checks if the SVG data contains non-empty 'mrc721' and 'mrc721id' attributes.
@@ -784,7 +946,7 @@ func ParseMRC721SvgProtocol(txn *badger.Txn, data []byte) (*MRC721Protocol, erro
 
 	var hookInscription HookInscription
 	err = item.Value(func(val []byte) error {
-		return jsoniter.Unmarshal(val, &hookInscription)
+		return unmarshalInscription(txn, val, &hookInscription)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal HookInscription: %v", err)
@@ -0,0 +1,213 @@
+// filePath: satmine/protocolregistry.go
+
+package satmine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// ProtocolHandler processes one inscription format. The block writer hands the content of every new inscription
+// to the first registered handler that detects it, so a new format is supported by registering a handler
+// instead of editing the block writer.
+type ProtocolHandler interface {
+	// Name returns the protocol type reported by ValidateProtocolData, e.g. "mrc-721".
+	Name() string
+	// Detect reports whether the content is in the format of the handler. It must be cheap and must not validate.
+	Detect(data []byte) bool
+	// Validate checks the content against the protocol rules in force.
+	Validate(data []byte, rules *ProtocolRules) error
+	// Apply writes a validated inscription, within the Badger transaction of the block.
+	// Content which cannot be applied is logged and skipped; a returned error aborts the block.
+	Apply(b *BTOrdIdx, txn *badger.Txn, block *HookBlock, inscr *HookInscription, rules *ProtocolRules) error
+	// Rollback is called after the transaction of a block was discarded, to forget any state
+	// the handler kept outside of the transaction.
+	Rollback(b *BTOrdIdx)
+}
+
+// protocolHandlers holds the registered handlers in detection order, the built-in formats first.
+var protocolHandlers []ProtocolHandler
+
+func init() {
+	for _, handler := range []ProtocolHandler{
+		mrc721HtmlHandler{},
+		mrc721SvgHandler{},
+		mrc721Handler{},
+		mrc20Handler{},
+	} {
+		if err := RegisterProtocolHandler(handler); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterProtocolHandler adds a handler after the ones already registered. It must be called before any block is
+// processed, and fails if a handler with the same name exists.
+func RegisterProtocolHandler(handler ProtocolHandler) error {
+	for _, registered := range protocolHandlers {
+		if registered.Name() == handler.Name() {
+			return fmt.Errorf("protocol handler already registered: %s", handler.Name())
+		}
+	}
+	protocolHandlers = append(protocolHandlers, handler)
+	return nil
+}
+
+// detectProtocolHandler returns the first handler detecting data, nil if none does.
+func detectProtocolHandler(data []byte) ProtocolHandler {
+	for _, handler := range protocolHandlers {
+		if handler.Detect(data) {
+			return handler
+		}
+	}
+	return nil
+}
+
+// rollbackProtocolHandlers calls the rollback hook of every handler.
+func (b *BTOrdIdx) rollbackProtocolHandlers() {
+	for _, handler := range protocolHandlers {
+		handler.Rollback(b)
+	}
+}
+
+// jsonProtocolName returns the "p" field of JSON content, empty if the content is not a JSON object.
+func jsonProtocolName(data []byte) string {
+	var p struct {
+		P string `json:"p"`
+	}
+	if err := jsoniter.Unmarshal(data, &p); err != nil {
+		return ""
+	}
+	return p.P
+}
+
+// applyMrc721 writes a parsed MRC-721 inscription, whatever format it came in.
+func (b *BTOrdIdx) applyMrc721(txn *badger.Txn, block *HookBlock, inscr *HookInscription, format string, mrc721Data *MRC721Protocol) error {
+	if err := b.writeMrc721(txn, block, inscr, mrc721Data); err != nil {
+		logger.Info(fmt.Sprintf("Failed to write %s data: ", format), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// rejectUnparsedMrc721 gives the verdict of a HTML or SVG miner which could not be parsed.
+func (b *BTOrdIdx) rejectUnparsedMrc721(err error) {
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		b.ignoreInscription(ReasonUnknownCollection, err.Error())
+		return
+	}
+	b.rejectInscription(ReasonParseError, err.Error())
+}
+
+// mrc721Handler handles the JSON MRC-721 genesis and miner inscriptions.
+type mrc721Handler struct{}
+
+func (mrc721Handler) Name() string { return "mrc-721" }
+
+func (mrc721Handler) Detect(data []byte) bool { return jsonProtocolName(data) == "mrc-721" }
+
+func (mrc721Handler) Validate(data []byte, rules *ProtocolRules) error {
+	_, _, err := validateMRC721Data(data, rules)
+	return err
+}
+
+func (mrc721Handler) Apply(b *BTOrdIdx, txn *badger.Txn, block *HookBlock, inscr *HookInscription, rules *ProtocolRules) error {
+	mrc721Data, err := parseMRC721Protocol(*inscr.ContentByte, rules)
+	if err != nil {
+		logger.Info("Failed to parse MRC-721 data: ", zap.Error(err))
+		b.rejectInscription(ReasonParseError, err.Error())
+		return nil
+	}
+	logger.Info("Parsed MRC-721 Data: ", zap.Reflect("mrc721Data", mrc721Data))
+	return b.applyMrc721(txn, block, inscr, "MRC-721", mrc721Data)
+}
+
+// Rollback does nothing: the parsed collections are dropped by the registry when the block fails.
+func (mrc721Handler) Rollback(b *BTOrdIdx) {}
+
+// mrc721HtmlHandler handles the MRC-721 miner inscriptions written as an HTML page.
+type mrc721HtmlHandler struct{}
+
+func (mrc721HtmlHandler) Name() string { return "mrc-721html" }
+
+func (mrc721HtmlHandler) Detect(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trimmed, []byte("<!DOCTYPE html>")) || bytes.HasPrefix(trimmed, []byte("<html>"))
+}
+
+func (mrc721HtmlHandler) Validate(data []byte, rules *ProtocolRules) error {
+	_, _, err := validateMRC721HtmlData(bytes.TrimSpace(data))
+	return err
+}
+
+func (mrc721HtmlHandler) Apply(b *BTOrdIdx, txn *badger.Txn, block *HookBlock, inscr *HookInscription, rules *ProtocolRules) error {
+	mrc721Data, err := b.parseMRC721HtmlProtocol(txn, *inscr.ContentByte)
+	if err != nil {
+		logger.Info("Failed to parse 721html data: ", zap.Error(err))
+		b.rejectUnparsedMrc721(err)
+		return nil
+	}
+	return b.applyMrc721(txn, block, inscr, "MRC-721html", mrc721Data)
+}
+
+func (mrc721HtmlHandler) Rollback(b *BTOrdIdx) {}
+
+// mrc721SvgHandler handles the MRC-721 miner inscriptions written as an SVG image.
+type mrc721SvgHandler struct{}
+
+func (mrc721SvgHandler) Name() string { return "mrc-721svg" }
+
+func (mrc721SvgHandler) Detect(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<svg"))
+}
+
+func (mrc721SvgHandler) Validate(data []byte, rules *ProtocolRules) error {
+	_, _, err := validateMRC721SvgData(bytes.TrimSpace(data))
+	return err
+}
+
+func (mrc721SvgHandler) Apply(b *BTOrdIdx, txn *badger.Txn, block *HookBlock, inscr *HookInscription, rules *ProtocolRules) error {
+	mrc721Data, err := b.parseMRC721SvgProtocol(txn, *inscr.ContentByte)
+	if err != nil {
+		logger.Info("Failed to parse 721svg data: ", zap.Error(err))
+		b.rejectUnparsedMrc721(err)
+		return nil
+	}
+	return b.applyMrc721(txn, block, inscr, "MRC-721svg", mrc721Data)
+}
+
+func (mrc721SvgHandler) Rollback(b *BTOrdIdx) {}
+
+// mrc20Handler handles the MRC-20 transfer and burn inscriptions.
+type mrc20Handler struct{}
+
+func (mrc20Handler) Name() string { return "mrc-20" }
+
+func (mrc20Handler) Detect(data []byte) bool { return jsonProtocolName(data) == "mrc-20" }
+
+func (mrc20Handler) Validate(data []byte, rules *ProtocolRules) error {
+	_, _, err := validateMRC20Data(data, rules)
+	return err
+}
+
+func (mrc20Handler) Apply(b *BTOrdIdx, txn *badger.Txn, block *HookBlock, inscr *HookInscription, rules *ProtocolRules) error {
+	mrc20Data, err := ParseMRC20Protocol(*inscr.ContentByte)
+	if err != nil {
+		logger.Info("Failed to parse MRC-20 data: ", zap.Error(err))
+		b.rejectInscription(ReasonParseError, err.Error())
+		return nil
+	}
+	logger.Info("Parsed MRC-20 Data: ", zap.Reflect("mrc20Data", mrc20Data))
+	if err := b.writeMrc20(txn, block, inscr, mrc20Data); err != nil {
+		logger.Info("Failed to write MRC-20 data: ", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (mrc20Handler) Rollback(b *BTOrdIdx) {}
@@ -0,0 +1,336 @@
+// filePath: satmine/registry.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// mrc721Collection is the cached state of one MRC-721 collection: the parsed genesis deploy, which never
+// changes once written, and the latest Mrc721GenesisData stored under "mrc721::geninsc::<name>".
+type mrc721Collection struct {
+	Protocol *MRC721Protocol   // Parsed protocol of the genesis inscription, must not be modified
+	Genesis  Mrc721GenesisData // Latest genesis data, callers work on a copy
+	Schedule *EmissionSchedule // Emission schedule of the collection, computed once from the protocol
+}
+
+// newMrc721Collection builds the cached state of a collection and its emission schedule.
+func newMrc721Collection(protocol *MRC721Protocol, genesisData Mrc721GenesisData) (*mrc721Collection, error) {
+	genesisHeight, err := strconv.ParseInt(genesisData.BlockHeight, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis height of %s: %w", genesisData.Name, err)
+	}
+	schedule, err := NewEmissionSchedule(protocol, genesisHeight)
+	if err != nil {
+		return nil, fmt.Errorf("emission schedule of %s: %w", genesisData.Name, err)
+	}
+	return &mrc721Collection{Protocol: protocol, Genesis: genesisData, Schedule: schedule}, nil
+}
+
+// mrc721Registry keeps the parsed protocol definitions of all MRC-721 collections in memory, so the
+// per-block loops do not have to reload and re-parse the genesis inscriptions.
+//
+// Changes made while a block is being written are staged and only become visible to later blocks once the
+// badger transaction has committed; a failed block discards them, keeping the cache coherent with the database.
+// The registry is only used on the write path, under the write lock of BTOrdIdx.
+type mrc721Registry struct {
+	loaded    bool                         // Whether all collections have been loaded from the database
+	committed map[string]*mrc721Collection // Collections as of the last committed block
+	staged    map[string]*mrc721Collection // Collections created or updated by the block being written
+	names     []string                     // Sorted names of the committed collections
+	rules     *ProtocolRules               // Protocol rules of the block being written
+}
+
+// newMrc721Registry returns an empty registry, filled lazily from the database.
+func newMrc721Registry() *mrc721Registry {
+	return &mrc721Registry{
+		committed: make(map[string]*mrc721Collection),
+		staged:    make(map[string]*mrc721Collection),
+	}
+}
+
+// commit makes the staged changes visible after the block transaction has been committed.
+func (r *mrc721Registry) commit() {
+	added := false
+	for name, collection := range r.staged {
+		if _, ok := r.committed[name]; !ok {
+			r.names = append(r.names, name)
+			added = true
+		}
+		r.committed[name] = collection
+	}
+	if added {
+		sort.Strings(r.names)
+	}
+	r.staged = make(map[string]*mrc721Collection)
+}
+
+// discard drops the staged changes of a block whose transaction failed. The rules are forgotten as well,
+// so a rule change whose effects were rolled back is applied again by the next block.
+func (r *mrc721Registry) discard() {
+	r.staged = make(map[string]*mrc721Collection)
+	r.rules = nil
+}
+
+// load reads every collection stored under "mrc721::geninsc::" into the registry.
+func (r *mrc721Registry) load(txn *badger.Txn) error {
+	prefix := []byte("mrc721::geninsc::")
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		name := string(it.Item().Key()[len(prefix):])
+		if _, ok := r.committed[name]; ok {
+			continue
+		}
+		if _, ok := r.staged[name]; ok {
+			continue
+		}
+
+		var genesisData Mrc721GenesisData
+		err := it.Item().Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &genesisData)
+		})
+		if err != nil {
+			logger.Error("Failed to unmarshal MRC-721 genesis genesisData: ", zap.Error(err))
+			return err
+		}
+
+		collection, err := loadMrc721Collection(txn, genesisData, r.rules)
+		if err != nil {
+			return err
+		}
+		r.committed[name] = collection
+		r.names = append(r.names, name)
+	}
+
+	sort.Strings(r.names)
+	r.loaded = true
+	return nil
+}
+
+// reset drops the cached collections after the protocol rules changed. Collections staged by the block being
+// written are parsed again under the new rules, the others are reloaded from the database when needed.
+func (r *mrc721Registry) reset(txn *badger.Txn) error {
+	r.committed = make(map[string]*mrc721Collection)
+	r.names = nil
+	r.loaded = false
+	for name, collection := range r.staged {
+		reloaded, err := loadMrc721Collection(txn, collection.Genesis, r.rules)
+		if err != nil {
+			return err
+		}
+		r.staged[name] = reloaded
+	}
+	return nil
+}
+
+// loadMrc721Collection parses the genesis inscription referenced by the genesis data under the given rules.
+func loadMrc721Collection(txn *badger.Txn, genesisData Mrc721GenesisData, rules *ProtocolRules) (*mrc721Collection, error) {
+	item, err := txn.Get([]byte("inscr::" + genesisData.ID))
+	if err != nil {
+		logger.Error("Error retrieving HookInscription: ", zap.Error(err))
+		return nil, err
+	}
+
+	var hookInscription HookInscription
+	err = item.Value(func(val []byte) error {
+		return unmarshalInscription(txn, val, &hookInscription)
+	})
+	if err != nil {
+		logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
+		return nil, err
+	}
+
+	protocol, err := parseMRC721Protocol(*hookInscription.ContentByte, rules)
+	if err != nil {
+		logger.Error("Failed to parse MRC721 protocol: ", zap.Error(err))
+		return nil, err
+	}
+
+	return newMrc721Collection(protocol, genesisData)
+}
+
+// mrc721Names returns the names of all MRC-721 collections visible to the block being written,
+// in the same order as the "mrc721::geninsc::" keys.
+func (b *BTOrdIdx) mrc721Names(txn *badger.Txn) ([]string, error) {
+	if !b.registry.loaded {
+		if err := b.registry.load(txn); err != nil {
+			return nil, err
+		}
+	}
+
+	names := b.registry.names
+	added := false
+	for name := range b.registry.staged {
+		if _, ok := b.registry.committed[name]; !ok {
+			if !added {
+				names = append([]string(nil), names...)
+				added = true
+			}
+			names = append(names, name)
+		}
+	}
+	if added {
+		sort.Strings(names)
+	}
+	return names, nil
+}
+
+// mrc721Collection returns the cached collection with the given upper-case name.
+// It returns badger.ErrKeyNotFound when the collection does not exist.
+func (b *BTOrdIdx) mrc721Collection(txn *badger.Txn, name string) (*mrc721Collection, error) {
+	if collection, ok := b.registry.staged[name]; ok {
+		return collection, nil
+	}
+	if collection, ok := b.registry.committed[name]; ok {
+		return collection, nil
+	}
+	if b.registry.loaded {
+		return nil, badger.ErrKeyNotFound
+	}
+
+	// Not loaded yet, read only this collection
+	item, err := txn.Get([]byte("mrc721::geninsc::" + name))
+	if err != nil {
+		return nil, err
+	}
+	var genesisData Mrc721GenesisData
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &genesisData)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := loadMrc721Collection(txn, genesisData, b.registry.rules)
+	if err != nil {
+		return nil, err
+	}
+	b.registry.committed[name] = collection
+	b.registry.names = append(b.registry.names, name)
+	sort.Strings(b.registry.names)
+	return collection, nil
+}
+
+// putMrc721Genesis writes the genesis data of a collection and stages it in the registry.
+// protocol is only needed when the collection is created; updates keep the cached protocol.
+func (b *BTOrdIdx) putMrc721Genesis(txn *badger.Txn, genesisData *Mrc721GenesisData, protocol *MRC721Protocol) error {
+	var collection *mrc721Collection
+	var err error
+	if protocol == nil {
+		collection, err = b.mrc721Collection(txn, genesisData.Name)
+		if err != nil {
+			return fmt.Errorf("unknown MRC-721 collection %s: %w", genesisData.Name, err)
+		}
+		// The protocol and the genesis height never change, keep the schedule
+		collection = &mrc721Collection{Protocol: collection.Protocol, Genesis: *genesisData, Schedule: collection.Schedule}
+	} else {
+		collection, err = newMrc721Collection(protocol, *genesisData)
+		if err != nil {
+			logger.Error("Failed to build the MRC-721 collection: ", zap.Error(err))
+			return err
+		}
+	}
+
+	genesisJSON, err := jsoniter.Marshal(genesisData)
+	if err != nil {
+		logger.Error("Failed to marshal MRC-721 genesis data: ", zap.Error(err))
+		return err
+	}
+	if err := setState(txn, []byte("mrc721::geninsc::"+genesisData.Name), genesisJSON); err != nil {
+		logger.Error("Failed to write MRC-721 genesis data: ", zap.Error(err))
+		return err
+	}
+
+	b.registry.staged[genesisData.Name] = collection
+	return nil
+}
+
+// useRules activates the protocol rules of the block about to be written. When a new rule set activates,
+// the cached collections are parsed again and the power of every miner is recomputed under the new rules.
+func (b *BTOrdIdx) useRules(txn *badger.Txn, height int64) error {
+	rules := RulesAt(height)
+	previous := b.registry.rules
+	if previous == nil {
+		// First block since startup, the database was written with the rules of the previous height
+		previous = RulesAt(height - 1)
+	}
+	b.registry.rules = rules
+	if rules == previous {
+		return nil
+	}
+
+	logger.Info("Protocol rules activated", zap.String("network", string(ActiveNetwork())), zap.Int64("height", rules.ActivationHeight))
+	if err := b.registry.reset(txn); err != nil {
+		return err
+	}
+	if rules.MaxMinerPower == previous.MaxMinerPower && rules.UnburntPower == previous.UnburntPower {
+		return nil
+	}
+
+	names, err := b.mrc721Names(txn)
+	if err != nil {
+		return err
+	}
+	for _, mrc721Name := range names {
+		collection, err := b.mrc721Collection(txn, mrc721Name)
+		if err != nil {
+			return err
+		}
+		if collection.Protocol.Burn == nil && collection.Protocol.Stake == nil {
+			continue
+		}
+		for _, inscriptionID := range collectionMiners(txn, mrc721Name) {
+			if err := b.updateMinerPower(txn, inscriptionID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseMRC721HtmlProtocol is ParseMRC721HtmlProtocol served from the registry.
+func (b *BTOrdIdx) parseMRC721HtmlProtocol(txn *badger.Txn, data []byte) (*MRC721Protocol, error) {
+	mrc721name, mrc721ID, err := HtmlToNameID(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %v", err)
+	}
+
+	if mrc721name == "" || mrc721ID == "" {
+		return nil, errors.New("required attributes not found")
+	}
+
+	collection, err := b.mrc721Collection(txn, mrc721name)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving Mrc721GenesisData: %w", err)
+	}
+	return collection.Protocol, nil
+}
+
+// parseMRC721SvgProtocol is ParseMRC721SvgProtocol served from the registry.
+func (b *BTOrdIdx) parseMRC721SvgProtocol(txn *badger.Txn, data []byte) (*MRC721Protocol, error) {
+	mrc721name, mrc721ID, err := SvgToNameID(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SVG: %v", err)
+	}
+
+	if mrc721name == "" || mrc721ID == "" {
+		return nil, errors.New("required attributes not found in SVG data")
+	}
+
+	collection, err := b.mrc721Collection(txn, mrc721name)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving Mrc721GenesisData: %w", err)
+	}
+	return collection.Protocol, nil
+}
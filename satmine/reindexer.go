@@ -0,0 +1,152 @@
+// filePath: satmine/reindexer.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// StoredBlockHeights returns the heights of all blocks saved under the "block::<height>" keys, in ascending order.
+func (b *BTOrdIdx) StoredBlockHeights() ([]int, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	var heights []int
+	prefix := []byte("block::")
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false // Only the keys are needed
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			height, err := strconv.Atoi(string(it.Item().Key()[len(prefix):]))
+			if err != nil {
+				logger.Info("Skipping malformed block key", zap.String("key", string(it.Item().Key())))
+				continue
+			}
+			heights = append(heights, height)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are ordered lexicographically, so sort them numerically
+	sort.Ints(heights)
+	return heights, nil
+}
+
+// ReindexFrom rebuilds the state of this (fresh) index by replaying the HookBlocks stored in src through the
+// current protocol code. Blocks are replayed in ascending height order up to and including targetHeight;
+// a targetHeight below zero replays every stored block. It returns the height of the last replayed block.
+func (b *BTOrdIdx) ReindexFrom(src *BTOrdIdx, targetHeight int) (lastHeight int, err error) {
+	if src == b {
+		return -1, errors.New("reindex source and destination must be different databases")
+	}
+
+	// The destination must not hold any derived state, otherwise the replay would be applied twice
+	if _, err := b.GetLastBlock(); err == nil {
+		return -1, errors.New("reindex destination is not empty")
+	}
+
+	heights, err := src.StoredBlockHeights()
+	if err != nil {
+		return -1, fmt.Errorf("failed to list stored blocks: %w", err)
+	}
+	if len(heights) == 0 {
+		return -1, errors.New("no stored blocks found in the source database")
+	}
+
+	lastHeight = -1
+	for i, height := range heights {
+		if targetHeight >= 0 && height > targetHeight {
+			break
+		}
+		if i > 0 && height != heights[i-1]+1 {
+			// WriteBlock fills the gap with empty blocks, just like during the live sync
+			logger.Info(fmt.Sprintf("reindex: gap between block %d and %d", heights[i-1], height))
+		}
+
+		block, err := src.GetBlockByHeight(strconv.Itoa(height))
+		if err != nil {
+			return lastHeight, fmt.Errorf("failed to load stored block %d: %w", height, err)
+		}
+
+		if err := b.WriteBlock(block); err != nil {
+			return lastHeight, fmt.Errorf("failed to replay block %d: %w", height, err)
+		}
+		lastHeight = height
+	}
+
+	if lastHeight < 0 {
+		return -1, fmt.Errorf("no stored blocks at or below height %d", targetHeight)
+	}
+
+	logger.Info("Reindex finished", zap.Int("lastHeight", lastHeight))
+	return lastHeight, nil
+}
+
+// WebBlockReplay is a stored block replayed on the state of the blocks before it.
+type WebBlockReplay struct {
+	BlockHeight string        `json:"block_height"`
+	Diff        WebBlockDiff  `json:"diff"`   // State changed by the replay
+	Stored      *WebBlockDiff `json:"stored"` // State changed when the block was indexed, nil if no diff was kept then
+	Match       bool          `json:"match"`  // The replay changed the same keys to the same values
+}
+
+// maxReplayBlocks is the number of blocks ReplayBlock rebuilds at most before the block it replays. The state before
+// a block cannot be restored from the stored diffs, which only hold the changed keys, so every replay indexes the
+// chain again from the first stored block: its cost grows with the height of the block, blocks further up the chain
+// are checked by a full reindex instead.
+const maxReplayBlocks = 10000
+
+// ReplayBlock rebuilds in this (fresh) index the state of src before the stored block at height, by replaying the
+// blocks stored below it, then dry-runs that block and compares the state it changes with the diff src kept when
+// it indexed the block. A block more than maxReplayBlocks blocks above the first stored block is refused.
+func (b *BTOrdIdx) ReplayBlock(src *BTOrdIdx, height int) (WebBlockReplay, error) {
+	replay := WebBlockReplay{BlockHeight: strconv.Itoa(height)}
+	block, err := src.GetBlockByHeight(replay.BlockHeight)
+	if err != nil {
+		return replay, fmt.Errorf("failed to load stored block %d: %w", height, err)
+	}
+
+	// The first stored block is applied on an empty state
+	heights, err := src.StoredBlockHeights()
+	if err != nil {
+		return replay, fmt.Errorf("failed to list stored blocks: %w", err)
+	}
+	if height-heights[0] > maxReplayBlocks {
+		return replay, fmt.Errorf("block %d is %d blocks above the first stored block %d, replays rebuild at most %d blocks", height, height-heights[0], heights[0], maxReplayBlocks)
+	}
+	if heights[0] < height {
+		if _, err := b.ReindexFrom(src, height-1); err != nil {
+			return replay, err
+		}
+	}
+
+	diffs, err := b.DryRunBlock(block)
+	if err != nil {
+		return replay, fmt.Errorf("failed to replay block %d: %w", height, err)
+	}
+	replay.Diff = diffs[len(diffs)-1]
+
+	stored, err := src.GetBlockDiff(replay.BlockHeight)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return replay, nil
+	}
+	if err != nil {
+		return replay, err
+	}
+	replay.Stored = &stored
+	replay.Match = stored.Digest == replay.Diff.Digest
+	return replay, nil
+}
@@ -0,0 +1,790 @@
+// filePath: satmine/rewardpool.go
+
+package satmine
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Mining rewards are accounted lazily. Every miner of a given power receives the same floor(M*power/P) share of a
+// block's emission M, so instead of crediting every miner on every block the collection keeps, per power class,
+// the cumulative reward of one miner of that power:
+//
+//	mrc721::reward_pool::<name>  -> Mrc721RewardPool
+//	mrc721::reward_ckpt::<id>    -> Mrc721MinerCheckpoint
+//
+// A miner's pending reward is the accumulator of its class minus the snapshot in its checkpoint. It is settled into
+// "mrc721::inscr_miner::<id>" and the owner's balance when the miner changes owner or power, before the owner's
+// balance is spent, and is added on the fly by the read APIs. The dust fallback of powerRewards, used when the
+// emission is smaller than the number of miners, is still credited immediately.
+
+// Mrc721PowerClass holds the miners of one power value.
+type Mrc721PowerClass struct {
+	Power  string `json:"power"`  // Mining power of the miners in this class
+	Miners int    `json:"miners"` // Number of miners with this power
+	Acc    string `json:"acc"`    // Cumulative reward of one miner of this class
+}
+
+// Mrc721RewardPool is the lazy reward state of a collection.
+type Mrc721RewardPool struct {
+	Classes []Mrc721PowerClass `json:"classes"` // Sorted by power
+}
+
+// Mrc721MinerCheckpoint records the power class of a miner and the class accumulator at its last settlement.
+type Mrc721MinerCheckpoint struct {
+	Name  string `json:"name"`  // Upper-case name of the collection
+	Power string `json:"power"` // Current mining power
+	Acc   string `json:"acc"`   // Accumulator of the power class when last settled
+}
+
+// rewardPoolKey returns the key of the reward pool of a collection.
+func rewardPoolKey(mrc721Name string) []byte {
+	return []byte("mrc721::reward_pool::" + mrc721Name)
+}
+
+// rewardCkptKey returns the key of the reward checkpoint of a miner.
+func rewardCkptKey(inscriptionID string) []byte {
+	return []byte("mrc721::reward_ckpt::" + inscriptionID)
+}
+
+// classIndex returns the index of the class with the given power, or -1.
+func (p *Mrc721RewardPool) classIndex(power string) int {
+	for i := range p.Classes {
+		if p.Classes[i].Power == power {
+			return i
+		}
+	}
+	return -1
+}
+
+// classAcc returns the accumulator of the class with the given power, zero if the class is empty.
+func (p *Mrc721RewardPool) classAcc(power string) *big.Int {
+	acc := big.NewInt(0)
+	if i := p.classIndex(power); i >= 0 {
+		acc.SetString(p.Classes[i].Acc, 10)
+	}
+	return acc
+}
+
+// addMiner adds a miner of the given power and returns the snapshot to record in its checkpoint.
+func (p *Mrc721RewardPool) addMiner(power *big.Int) string {
+	key := power.String()
+	if i := p.classIndex(key); i >= 0 {
+		p.Classes[i].Miners++
+		return p.Classes[i].Acc
+	}
+
+	// A new class starts from zero, it only matters relative to the snapshots
+	p.Classes = append(p.Classes, Mrc721PowerClass{Power: key, Miners: 1, Acc: "0"})
+	sort.Slice(p.Classes, func(i, j int) bool {
+		pi, _ := new(big.Int).SetString(p.Classes[i].Power, 10)
+		pj, _ := new(big.Int).SetString(p.Classes[j].Power, 10)
+		return pi.Cmp(pj) < 0
+	})
+	return "0"
+}
+
+// removeMiner removes a miner of the given power, dropping the class once it is empty.
+func (p *Mrc721RewardPool) removeMiner(power string) {
+	i := p.classIndex(power)
+	if i < 0 {
+		return
+	}
+	p.Classes[i].Miners--
+	if p.Classes[i].Miners <= 0 {
+		p.Classes = append(p.Classes[:i], p.Classes[i+1:]...)
+	}
+}
+
+// totals returns the number of miners and their total power.
+func (p *Mrc721RewardPool) totals() (miners int, totalPower *big.Int) {
+	totalPower = big.NewInt(0)
+	for _, class := range p.Classes {
+		power, _ := new(big.Int).SetString(class.Power, 10)
+		miners += class.Miners
+		totalPower.Add(totalPower, power.Mul(power, big.NewInt(int64(class.Miners))))
+	}
+	return miners, totalPower
+}
+
+// distribute credits floor(amount*power/totalPower) to one miner of every class, exactly like powerRewards,
+// and returns the amount allocated to all miners. The caller guarantees amount >= number of miners.
+func (p *Mrc721RewardPool) distribute(amount *big.Int) *big.Int {
+	_, totalPower := p.totals()
+	allocated := big.NewInt(0)
+	if totalPower.Sign() == 0 {
+		return allocated
+	}
+
+	for i := range p.Classes {
+		power, _ := new(big.Int).SetString(p.Classes[i].Power, 10)
+		share := new(big.Int).Mul(amount, power)
+		share.Div(share, totalPower)
+
+		acc, _ := new(big.Int).SetString(p.Classes[i].Acc, 10)
+		p.Classes[i].Acc = acc.Add(acc, share).String()
+		allocated.Add(allocated, share.Mul(share, big.NewInt(int64(p.Classes[i].Miners))))
+	}
+	return allocated
+}
+
+// pending returns the reward accrued by the miner since its last settlement.
+func (p *Mrc721RewardPool) pending(ckpt *Mrc721MinerCheckpoint) *big.Int {
+	snapshot, _ := new(big.Int).SetString(ckpt.Acc, 10)
+	return new(big.Int).Sub(p.classAcc(ckpt.Power), snapshot)
+}
+
+// getRewardPool reads the reward pool of a collection, badger.ErrKeyNotFound if it has not been created yet.
+func getRewardPool(txn *badger.Txn, mrc721Name string) (*Mrc721RewardPool, error) {
+	item, err := txn.Get(rewardPoolKey(mrc721Name))
+	if err != nil {
+		return nil, err
+	}
+	var pool Mrc721RewardPool
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &pool)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// putRewardPool writes the reward pool of a collection.
+func putRewardPool(txn *badger.Txn, mrc721Name string, pool *Mrc721RewardPool) error {
+	poolJSON, err := jsoniter.Marshal(pool)
+	if err != nil {
+		return err
+	}
+	return setState(txn, rewardPoolKey(mrc721Name), poolJSON)
+}
+
+// getMinerCheckpoint reads the reward checkpoint of a miner, badger.ErrKeyNotFound if it has none.
+func getMinerCheckpoint(txn *badger.Txn, inscriptionID string) (*Mrc721MinerCheckpoint, error) {
+	item, err := txn.Get(rewardCkptKey(inscriptionID))
+	if err != nil {
+		return nil, err
+	}
+	var ckpt Mrc721MinerCheckpoint
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &ckpt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ckpt, nil
+}
+
+// putMinerCheckpoint writes the reward checkpoint of a miner.
+func putMinerCheckpoint(txn *badger.Txn, inscriptionID string, ckpt *Mrc721MinerCheckpoint) error {
+	ckptJSON, err := jsoniter.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+	return setState(txn, rewardCkptKey(inscriptionID), ckptJSON)
+}
+
+// getBurnNum reads the number of tokens burnt for an inscription.
+func getBurnNum(txn *badger.Txn, inscriptionID string) (*big.Int, error) {
+	burnNum := big.NewInt(0)
+	item, err := txn.Get([]byte("mrc721::burn::" + inscriptionID))
+	if err == badger.ErrKeyNotFound {
+		return burnNum, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = item.Value(func(val []byte) error {
+		burnNum.SetBytes(val)
+		return nil
+	})
+	return burnNum, err
+}
+
+// legacyBurnNum is the burn number the legacy indexer read for a miner never burnt for: the bytes of the
+// default string "0" taken as a big-endian number, 48.
+var legacyBurnNum = new(big.Int).SetBytes([]byte("0"))
+
+// powerBurnNum reads the burn number the mining power of an inscription is computed from. Until rules.UnburntPower
+// a miner never burnt for counts legacyBurnNum tokens, so the powers match the ones of the legacy indexer.
+func powerBurnNum(rules *ProtocolRules, txn *badger.Txn, inscriptionID string) (*big.Int, error) {
+	burnNum, err := getBurnNum(txn, inscriptionID)
+	if err != nil || burnNum.Sign() != 0 || rules.UnburntPower {
+		return burnNum, err
+	}
+	return new(big.Int).Set(legacyBurnNum), nil
+}
+
+// getInscriptionAddress reads the current owner recorded in the inscription metadata.
+func getInscriptionAddress(txn *badger.Txn, inscriptionID string) (string, error) {
+	item, err := txn.Get([]byte("inscr::" + inscriptionID))
+	if err != nil {
+		return "", err
+	}
+	var hookInscription HookInscription
+	err = item.Value(func(val []byte) error {
+		return unmarshalInscriptionMeta(val, &hookInscription)
+	})
+	return hookInscription.Address, err
+}
+
+// addMinedAmount adds amount to "mrc721::inscr_miner::<id>".
+func addMinedAmount(txn *badger.Txn, inscriptionID string, amount *big.Int) error {
+	minerKey := []byte("mrc721::inscr_miner::" + inscriptionID)
+	existing := big.NewInt(0)
+	item, err := txn.Get(minerKey)
+	if err == nil {
+		err = item.Value(func(val []byte) error {
+			existing.SetBytes(val)
+			return nil
+		})
+		if err != nil {
+			logger.Error("Error retrieving existing mined amount: ", zap.Error(err))
+			return err
+		}
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+
+	if err := setState(txn, minerKey, existing.Add(existing, amount).Bytes()); err != nil {
+		logger.Error("Failed to update mined amount: ", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// addBalance adds amount to "mrc20::balance::<address>::<tick>".
+func addBalance(txn *badger.Txn, address, tick string, amount *big.Int) error {
+	balanceKey := []byte(fmt.Sprintf("mrc20::balance::%s::%s", address, tick))
+
+	// Retrieve the current balance
+	var currentBalance *big.Int
+	item, err := txn.Get(balanceKey)
+	if err == nil {
+		err = item.Value(func(val []byte) error {
+			currentBalance = new(big.Int)
+			currentBalance.SetString(string(val), 10)
+			return nil
+		})
+		if err != nil {
+			logger.Error("Error retrieving current balance: ", zap.Error(err))
+			return err
+		}
+	} else {
+		// If no current balance, initialize to zero
+		currentBalance = big.NewInt(0)
+	}
+
+	newBalance := new(big.Int).Add(currentBalance, amount)
+	if err := setState(txn, balanceKey, []byte(newBalance.String())); err != nil {
+		logger.Error("Failed to update balance: ", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ensureBalance creates a zero balance entry, so the address lists the token while its rewards are still pending.
+func ensureBalance(txn *badger.Txn, address, tick string) error {
+	balanceKey := []byte(fmt.Sprintf("mrc20::balance::%s::%s", address, tick))
+	_, err := txn.Get(balanceKey)
+	if err == badger.ErrKeyNotFound {
+		return setState(txn, balanceKey, []byte("0"))
+	}
+	return err
+}
+
+// parseBalance reads a balance stored as a decimal string. Burns indexed before rules.BurnTargets stored the
+// balance as big-endian bytes, a value which is not a decimal string is read that way.
+func parseBalance(val []byte) *big.Int {
+	if balance, ok := new(big.Int).SetString(string(val), 10); ok {
+		return balance
+	}
+	return new(big.Int).SetBytes(val)
+}
+
+// loadRewardPool returns the reward pool of a collection. Collections indexed before lazy accounting existed
+// have been credited up to the last block, their pool is built from the current miners with empty snapshots.
+func (b *BTOrdIdx) loadRewardPool(txn *badger.Txn, collection *mrc721Collection) (*Mrc721RewardPool, error) {
+	mrc721Name := collection.Genesis.Name
+	pool, err := getRewardPool(txn, mrc721Name)
+	if err != badger.ErrKeyNotFound {
+		return pool, err
+	}
+
+	pool = &Mrc721RewardPool{Classes: []Mrc721PowerClass{}}
+	minerIDs := collectionMiners(txn, mrc721Name)
+
+	for _, inscriptionID := range minerIDs {
+		if err := b.addRewardMiner(txn, collection, pool, inscriptionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putRewardPool(txn, mrc721Name, pool); err != nil {
+		return nil, err
+	}
+	logger.Info("Created reward pool", zap.String("name", mrc721Name), zap.Int("miners", len(minerIDs)))
+	return pool, nil
+}
+
+// addRewardMiner adds a miner to the pool with its current power and writes its checkpoint and power.
+func (b *BTOrdIdx) addRewardMiner(txn *badger.Txn, collection *mrc721Collection, pool *Mrc721RewardPool, inscriptionID string) error {
+	burnNum, err := powerBurnNum(b.registry.rules, txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	stakeNum, err := getStakeNum(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	power := computeMinerPower(b.registry.rules, collection.Protocol, burnNum, stakeNum)
+
+	ckpt := Mrc721MinerCheckpoint{Name: collection.Genesis.Name, Power: power.String(), Acc: pool.addMiner(power)}
+	if err := putMinerCheckpoint(txn, inscriptionID, &ckpt); err != nil {
+		return err
+	}
+	return setState(txn, []byte("mrc721::inscr_power::"+inscriptionID), power.Bytes())
+}
+
+// joinRewardPool registers a newly minted miner, it starts earning from the current block.
+func (b *BTOrdIdx) joinRewardPool(txn *badger.Txn, mrc721Name string, inscr *HookInscription) error {
+	collection, err := b.mrc721Collection(txn, mrc721Name)
+	if err != nil {
+		return err
+	}
+
+	pool, err := getRewardPool(txn, mrc721Name)
+	if err == badger.ErrKeyNotFound {
+		// The new miner is already listed under name_inscr and is picked up when the pool is built
+		_, err = b.loadRewardPool(txn, collection)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if err := b.addRewardMiner(txn, collection, pool, inscr.ID); err != nil {
+			return err
+		}
+		if err := putRewardPool(txn, mrc721Name, pool); err != nil {
+			return err
+		}
+	}
+
+	if collection.Genesis.TotalMinedTokens == collection.Protocol.Token.Total {
+		return nil
+	}
+	return ensureBalance(txn, inscr.Address, collection.Genesis.Tick)
+}
+
+// settleMinerWithPool credits the pending reward of a miner to inscr_miner and to its reward address, its
+// delegate or its current owner, and moves its snapshot forward. The caller writes the pool back if it changes it.
+func (b *BTOrdIdx) settleMinerWithPool(txn *badger.Txn, inscriptionID string, ckpt *Mrc721MinerCheckpoint, pool *Mrc721RewardPool) error {
+	pending := pool.pending(ckpt)
+	if pending.Sign() <= 0 {
+		return nil
+	}
+
+	collection, err := b.mrc721Collection(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	address, err := rewardAddress(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if err := addMinedAmount(txn, inscriptionID, pending); err != nil {
+		return err
+	}
+	if err := addBalance(txn, address, collection.Genesis.Tick, pending); err != nil {
+		return err
+	}
+
+	ckpt.Acc = pool.classAcc(ckpt.Power).String()
+	return putMinerCheckpoint(txn, inscriptionID, ckpt)
+}
+
+// settleMiner settles the pending reward of a miner. Inscriptions that are not lazy miners are ignored.
+func (b *BTOrdIdx) settleMiner(txn *badger.Txn, inscriptionID string) error {
+	ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pool, err := getRewardPool(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	return b.settleMinerWithPool(txn, inscriptionID, ckpt, pool)
+}
+
+// settleAddressMiners settles the miners rewarding address in the collection of tick, before its balance is spent.
+func (b *BTOrdIdx) settleAddressMiners(txn *badger.Txn, address, tick string) error {
+	item, err := txn.Get([]byte("mrc20::geninsc::" + tick))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var mrc721Name string
+	err = item.Value(func(val []byte) error {
+		mrc721Name = string(val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pool, err := getRewardPool(txn, mrc721Name)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, inscriptionID := range rewardedInscriptions(txn, address) {
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err == badger.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ckpt.Name != mrc721Name {
+			continue
+		}
+		if err := b.settleMinerWithPool(txn, inscriptionID, ckpt, pool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteMinerBalance rewrites the balance of an address rewarded by miners of the collection of tick, as crediting
+// every miner on every block did: a balance left in another format by the burn operation is read back and stored
+// as a decimal string before the next reward distribution.
+func (b *BTOrdIdx) rewriteMinerBalance(txn *badger.Txn, address, tick string) error {
+	item, err := txn.Get([]byte("mrc20::geninsc::" + tick))
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var mrc721Name string
+	err = item.Value(func(val []byte) error {
+		mrc721Name = string(val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	collection, err := b.mrc721Collection(txn, mrc721Name)
+	if err != nil {
+		return err
+	}
+	if collection.Genesis.TotalMinedTokens == collection.Protocol.Token.Total {
+		return nil
+	}
+
+	for _, inscriptionID := range rewardedInscriptions(txn, address) {
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err == badger.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ckpt.Name == mrc721Name {
+			return addBalance(txn, address, tick, big.NewInt(0))
+		}
+	}
+	return nil
+}
+
+// collectionMiners lists the inscriptions of a collection.
+func collectionMiners(txn *badger.Txn, mrc721Name string) []string {
+	prefix := []byte(fmt.Sprintf("mrc721::name_inscr::%s::", mrc721Name))
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var ids []string
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		ids = append(ids, string(it.Item().Key()[len(prefix):]))
+	}
+	return ids
+}
+
+// ownedInscriptions lists the MRC-721 inscriptions owned by an address.
+func ownedInscriptions(txn *badger.Txn, address string) []string {
+	prefix := []byte("mrc721::addr_inscr::" + address + "::")
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var ids []string
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		ids = append(ids, strings.TrimPrefix(string(it.Item().Key()), string(prefix)))
+	}
+	return ids
+}
+
+// transferMiner settles a miner to its previous owner before it changes hands.
+func (b *BTOrdIdx) transferMiner(txn *badger.Txn, inscriptionID, toAddress string) error {
+	ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pool, err := getRewardPool(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	if err := b.settleMinerWithPool(txn, inscriptionID, ckpt, pool); err != nil {
+		return err
+	}
+
+	collection, err := b.mrc721Collection(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	if collection.Genesis.TotalMinedTokens == collection.Protocol.Token.Total {
+		return nil
+	}
+	return ensureBalance(txn, toAddress, collection.Genesis.Tick)
+}
+
+// updateMinerPower settles a miner and moves it to the power class matching the tokens burnt for it.
+func (b *BTOrdIdx) updateMinerPower(txn *badger.Txn, inscriptionID string) error {
+	ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pool, err := getRewardPool(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	if err := b.settleMinerWithPool(txn, inscriptionID, ckpt, pool); err != nil {
+		return err
+	}
+
+	collection, err := b.mrc721Collection(txn, ckpt.Name)
+	if err != nil {
+		return err
+	}
+	burnNum, err := powerBurnNum(b.registry.rules, txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	stakeNum, err := getStakeNum(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	power := computeMinerPower(b.registry.rules, collection.Protocol, burnNum, stakeNum)
+	if power.String() == ckpt.Power {
+		return nil
+	}
+
+	pool.removeMiner(ckpt.Power)
+	ckpt.Power = power.String()
+	ckpt.Acc = pool.addMiner(power)
+	if err := putMinerCheckpoint(txn, inscriptionID, ckpt); err != nil {
+		return err
+	}
+	if err := putRewardPool(txn, ckpt.Name, pool); err != nil {
+		return err
+	}
+	return setState(txn, []byte("mrc721::inscr_power::"+inscriptionID), power.Bytes())
+}
+
+// distributeDust hands out an emission smaller than the number of miners one token at a time, like powerRewards:
+// by descending power, then ascending inscription number. These credits are immediate and do not touch the pool.
+func (b *BTOrdIdx) distributeDust(txn *badger.Txn, collection *mrc721Collection, amount *big.Int) error {
+	mrc721Name := collection.Genesis.Name
+	minerMap := Mrc721MinerMap{Data: make(map[string]*Mrc721MinerData)}
+
+	for _, inscriptionID := range collectionMiners(txn, mrc721Name) {
+		item, err := txn.Get([]byte("inscr::" + inscriptionID))
+		if err != nil {
+			logger.Error("Error retrieving HookInscription: ", zap.Error(err))
+			continue
+		}
+		var minerInscription HookInscription
+		err = item.Value(func(val []byte) error {
+			return unmarshalInscriptionMeta(val, &minerInscription)
+		})
+		if err != nil {
+			logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
+			continue
+		}
+
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err != nil {
+			return err
+		}
+		power, _ := new(big.Int).SetString(ckpt.Power, 10)
+
+		// Delegated miners are credited to their delegate
+		address := minerInscription.Address
+		if delegation, err := getMinerDelegation(txn, inscriptionID); err != nil {
+			return err
+		} else if delegation != nil {
+			address = delegation.Delegate
+		}
+
+		minerMap.Data[inscriptionID] = &Mrc721MinerData{
+			InscriptionsID:     inscriptionID,
+			InscriptionsNumber: minerInscription.Number,
+			Address:            address,
+			Tick:               collection.Genesis.Tick,
+			MinedAmount:        "0",
+			Power:              *power,
+		}
+	}
+
+	if _, err := powerRewards(*amount, &minerMap); err != nil {
+		return err
+	}
+
+	for _, minerData := range minerMap.Data {
+		if minerData.MinedAmount == "0" {
+			continue
+		}
+		minedAmount, _ := new(big.Int).SetString(minerData.MinedAmount, 10)
+		if err := addMinedAmount(txn, minerData.InscriptionsID, minedAmount); err != nil {
+			return err
+		}
+		if err := addBalance(txn, minerData.Address, minerData.Tick, minedAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distributeMiningRewards releases the emission of the block to the miners of a collection and returns
+// the amount actually mined, the rest of the emission stays unreleased.
+func (b *BTOrdIdx) distributeMiningRewards(txn *badger.Txn, collection *mrc721Collection, amount *big.Int) (*big.Int, error) {
+	pool, err := b.loadRewardPool(txn, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	miners, _ := pool.totals()
+	if miners == 0 || amount.Sign() == 0 {
+		return big.NewInt(0), nil
+	}
+
+	if amount.Cmp(big.NewInt(int64(miners))) < 0 {
+		// When the prize money is not enough to allocate to each inscription
+		if err := b.distributeDust(txn, collection, amount); err != nil {
+			return nil, err
+		}
+		return new(big.Int).Set(amount), nil
+	}
+
+	accs := make([]string, len(pool.Classes))
+	for i, class := range pool.Classes {
+		accs[i] = class.Acc
+	}
+	allocated := pool.distribute(amount)
+	noteMiningRewards(txn, collection.Genesis.Name, pool, accs)
+	if err := putRewardPool(txn, collection.Genesis.Name, pool); err != nil {
+		return nil, err
+	}
+	return allocated, nil
+}
+
+// unlockedPendingMinerReward returns the reward accrued by a miner and not settled yet.
+func unlockedPendingMinerReward(txn *badger.Txn, inscriptionID string) (*big.Int, error) {
+	ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+	if err == badger.ErrKeyNotFound {
+		return big.NewInt(0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pool, err := getRewardPool(txn, ckpt.Name)
+	if err != nil {
+		return nil, err
+	}
+	return pool.pending(ckpt), nil
+}
+
+// unlockedPendingBalance returns the rewards accrued by the miners rewarding an address in the collection of tick
+// and not settled into its balance yet.
+func unlockedPendingBalance(txn *badger.Txn, address, tick string) (*big.Int, error) {
+	total := big.NewInt(0)
+	item, err := txn.Get([]byte("mrc20::geninsc::" + tick))
+	if err == badger.ErrKeyNotFound {
+		return total, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var mrc721Name string
+	err = item.Value(func(val []byte) error {
+		mrc721Name = string(val)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := getRewardPool(txn, mrc721Name)
+	if err == badger.ErrKeyNotFound {
+		return total, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inscriptionID := range rewardedInscriptions(txn, address) {
+		ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+		if err == badger.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ckpt.Name == mrc721Name {
+			total.Add(total, pool.pending(ckpt))
+		}
+	}
+	return total, nil
+}
+
+// unlockedEffectiveBalance adds the pending mining rewards to a stored balance value.
+func unlockedEffectiveBalance(txn *badger.Txn, address, tick, balance string) string {
+	pending, err := unlockedPendingBalance(txn, address, tick)
+	if err != nil || pending.Sign() == 0 {
+		return balance
+	}
+	balanceBigInt, ok := new(big.Int).SetString(balance, 10)
+	if !ok {
+		return balance
+	}
+	return balanceBigInt.Add(balanceBigInt, pending).String()
+}
@@ -0,0 +1,240 @@
+package satmine
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// testBurnMinerContent deploys a collection whose burn section adds 48/10*0.5 = 2000 power to the miners never
+// burnt for under the legacy burn number.
+var testBurnMinerContent = []byte(`{"p":"mrc-721","miner":{"name":"delta","max":"100","lim":"5"},"token":{"tick":"delt","total":"21000000","beg":"1000","halv":"10","dcr":"0.1"},"burn":{"unit":"10","boost":"0.5"}}`)
+
+// legacyMinerMap builds the miners of a collection the way the legacy indexer did before calling CalculateMiningRewards:
+// the burn number is the raw value of the burn key, "0" when it is missing.
+func legacyMinerMap(t *testing.T, txn *badger.Txn, mrc721Name string) *Mrc721MinerMap {
+	t.Helper()
+	minerMap := &Mrc721MinerMap{Data: make(map[string]*Mrc721MinerData)}
+	for _, inscriptionID := range collectionMiners(txn, mrc721Name) {
+		var inscription HookInscription
+		item, err := txn.Get([]byte("inscr::" + inscriptionID))
+		if err == nil {
+			err = item.Value(func(val []byte) error {
+				return unmarshalInscriptionMeta(val, &inscription)
+			})
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		burnNum := "0"
+		if item, err := txn.Get([]byte("mrc721::burn::" + inscriptionID)); err == nil {
+			err = item.Value(func(val []byte) error {
+				if len(val) > 0 {
+					burnNum = string(val)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		minerMap.Data[inscriptionID] = &Mrc721MinerData{
+			InscriptionsID:     inscriptionID,
+			InscriptionsNumber: inscription.Number,
+			Address:            inscription.Address,
+			BurnNum:            burnNum,
+			StakeNum:           "0",
+			MinedAmount:        "0",
+			Power:              *big.NewInt(1000),
+		}
+	}
+	return minerMap
+}
+
+// TestLazyRewardsMatchLegacy checks the powers and the rewards of the lazy pools against the legacy
+// CalculateMiningRewards path, for miners never burnt for and for a burnt one.
+func TestLazyRewardsMatchLegacy(t *testing.T) {
+	b := openTestIndex(t)
+	var heights []string
+	write := func(inscriptions ...HookInscription) *HookBlock {
+		t.Helper()
+		height := 800000 + len(heights)
+		block := &HookBlock{
+			BlockHeight:  strconv.Itoa(height),
+			BlockHash:    "hash" + strconv.Itoa(height),
+			Timestamp:    int64(1700000000 + 600*len(heights)),
+			Inscriptions: inscriptions,
+		}
+		for i := range block.Inscriptions {
+			block.Inscriptions[i].BlockHeight = height
+		}
+		heights = append(heights, block.BlockHeight)
+		return block
+	}
+
+	protocol, err := ParseMRC721Protocol(testBurnMinerContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	burn := []byte(`{"p":"mrc-20","op":"burn","tick":"delt","amt":"60","insc":"test2i0"}`)
+	blocks := []*HookBlock{
+		write(testInscription(1, 0, "bc1qdeployer", testBurnMinerContent)),
+		write(testInscription(2, 0, "bc1qminer", testBurnMinerContent), testInscription(3, 0, "bc1qother", testBurnMinerContent)),
+		write(),
+		write(),
+		write(testInscription(4, 0, "bc1qminer", burn)),
+		write(),
+		write(),
+	}
+
+	compared := 0
+	for _, block := range blocks {
+		// The legacy path mines the block on the state left by the previous one
+		var calcResult MiningRewardCalculation
+		var minerMap *Mrc721MinerMap
+		err := b.db.View(func(txn *badger.Txn) error {
+			item, err := txn.Get([]byte("mrc721::geninsc::DELTA"))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			var genesisData Mrc721GenesisData
+			err = item.Value(func(val []byte) error {
+				return jsoniter.Unmarshal(val, &genesisData)
+			})
+			if err != nil {
+				return err
+			}
+			minerMap = legacyMinerMap(t, txn, "DELTA")
+			calcResult, err = CalculateMiningRewards(block.BlockHeight, &genesisData, protocol, minerMap)
+			if err != nil {
+				return err
+			}
+
+			// Every miner sits in the power class of its legacy power
+			for inscriptionID, minerData := range minerMap.Data {
+				ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+				if err != nil {
+					return err
+				}
+				if ckpt.Power != minerData.Power.String() {
+					t.Errorf("block %s: power of %s is %s, the legacy power is %s", block.BlockHeight, inscriptionID, ckpt.Power, minerData.Power.String())
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		before := snapshotBalances(t, b)
+		if err := b.WriteBlock(block); err != nil {
+			t.Fatalf("block %s: %v", block.BlockHeight, err)
+		}
+		after := snapshotBalances(t, b)
+		if minerMap == nil || len(minerMap.Data) == 0 || len(block.Inscriptions) > 0 {
+			continue
+		}
+
+		// Blocks without inscriptions only move the rewards, which must match the legacy split
+		compared++
+		for _, minerData := range minerMap.Data {
+			key := [2]string{minerData.Address, "delt"}
+			previous, _ := new(big.Int).SetString(before[key], 10)
+			if previous == nil {
+				previous = big.NewInt(0)
+			}
+			current, _ := new(big.Int).SetString(after[key], 10)
+			if current == nil {
+				t.Fatalf("block %s: balance of %s is %q", block.BlockHeight, minerData.Address, after[key])
+			}
+			if mined := current.Sub(current, previous).String(); mined != minerData.MinedAmount {
+				t.Errorf("block %s: %s mined %s, the legacy split gives %s (block total %s)", block.BlockHeight, minerData.InscriptionsID, mined, minerData.MinedAmount, calcResult.CurrentMiningAllNum)
+			}
+		}
+	}
+
+	if compared != 4 {
+		t.Fatalf("compared %d blocks with the legacy split, want 4", compared)
+	}
+
+	// The burn moved the miner out of the legacy default
+	err = b.db.View(func(txn *badger.Txn) error {
+		ckpt, err := getMinerCheckpoint(txn, "test2i0")
+		if err != nil {
+			return err
+		}
+		if ckpt.Power != "4000" {
+			t.Errorf("power of the burnt miner is %s, want 4000", ckpt.Power)
+		}
+		ckpt, err = getMinerCheckpoint(txn, "test3i0")
+		if err != nil {
+			return err
+		}
+		if ckpt.Power != "3000" {
+			t.Errorf("power of the unburnt miner is %s, want the legacy 3000", ckpt.Power)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Miners never burnt for drop the legacy burn number once rules.UnburntPower activates.
+func TestUnburntPowerActivation(t *testing.T) {
+	base := *rulesTables[Mainnet][0]
+	fixed := base
+	fixed.ActivationHeight = 800003
+	fixed.UnburntPower = true
+	rulesTables["unburnt-test"] = []*ProtocolRules{&base, &fixed}
+	previous := ActiveNetwork()
+	t.Cleanup(func() {
+		SetNetwork(string(previous))
+		delete(rulesTables, "unburnt-test")
+	})
+	if err := SetNetwork("unburnt-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	b := openTestIndex(t)
+	blocks := []*HookBlock{
+		{Inscriptions: []HookInscription{testInscription(1, 800000, "bc1qdeployer", testBurnMinerContent)}},
+		{Inscriptions: []HookInscription{testInscription(2, 800001, "bc1qminer", testBurnMinerContent)}},
+		{},
+		{},
+	}
+	for i, block := range blocks {
+		block.BlockHeight = strconv.Itoa(800000 + i)
+		block.BlockHash = "hash" + block.BlockHeight
+		block.Timestamp = int64(1700000000 + 600*i)
+		if err := b.WriteBlock(block); err != nil {
+			t.Fatalf("block %s: %v", block.BlockHeight, err)
+		}
+
+		want := "3000"
+		if block.BlockHeight == "800003" {
+			want = "1000"
+		}
+		err := b.db.View(func(txn *badger.Txn) error {
+			for _, inscriptionID := range collectionMiners(txn, "DELTA") {
+				ckpt, err := getMinerCheckpoint(txn, inscriptionID)
+				if err != nil {
+					return err
+				}
+				if ckpt.Power != want {
+					t.Errorf("block %s: power of %s is %s, want %s", block.BlockHeight, inscriptionID, ckpt.Power, want)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
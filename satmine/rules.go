@@ -0,0 +1,162 @@
+// filePath: satmine/rules.go
+
+package satmine
+
+import (
+	"fmt"
+	"math"
+)
+
+// Network selects the table of protocol rules of the chain being indexed.
+type Network string
+
+const (
+	Mainnet Network = "mainnet"
+	Testnet Network = "testnet"
+)
+
+// MinerOverride replaces the miner settings of one collection, whatever its genesis inscription says.
+type MinerOverride struct {
+	Max       string // Replacement for Miner.Max
+	Lim       string // Replacement for Miner.Lim
+	ImageSkip int    // Number of first inscriptions not used as the image of the collection
+	Reason    string // Why the override exists
+}
+
+// ProtocolRules is the complete set of protocol constants in force from ActivationHeight until the next set.
+// Rule sets are never edited once their height has been indexed: a fix or an override is scheduled by
+// appending a new set with a later activation height, so the history of the rules can be audited.
+type ProtocolRules struct {
+	ActivationHeight      int64                    // First block height the rules apply to
+	MaxTickLength         int                      // Maximum length of MRC-20 ticks
+	MaxMinerPower         int64                    // Maximum mining power of an inscription, burning and staking included
+	MinerOverrides        map[string]MinerOverride // Miner settings replaced by upper-case collection name
+	StrictJSON            bool                     // JSON inscriptions must pass the strict parser, see strictjson.go
+	MaxProtocolJSONLength int                      // Maximum length in bytes of a JSON inscription under the strict parser
+	Staking               bool                     // MRC-20 stake and unstake ops and the stake section of MRC-721 deploys are honoured
+	Delegation            bool                     // MRC-20 delegate ops redirect the rewards of miners
+	CreatorFee            bool                     // The fee section of MRC-721 deploys takes its share of the emission
+	LotteryDraws          bool                     // Lottery tiers, power weighting and draws past burnt miners, see lottery.go
+	BurnTargets           bool                     // MRC-20 burns must target a miner of the collection emitting their tick, see burnledger.go
+	UnburntPower          bool                     // Miners never burnt for gain no burn power, see powerBurnNum
+}
+
+// satmineOverride is the community decision on the SATMINE supply.
+// https://twitter.com/SatMineOfficial/status/1744347847208702031
+// Due to an unfortunate error in SATMINE's initial inscription process, an extra 32% of the supply was inscribed. Our indexer currently does not recognize these NFTs.
+// We fully value our community's input, so the handling of these additional NFTs will be put to a poll. We can either 1) continue to disregard these extra NFTs, or 2) include them and increase the total supply of SATMINE to 13263. Voting will last 24 hours.
+// Disregard the extra NFTs 34.1%    Include the extra NFTs  65.9%
+var satmineOverride = MinerOverride{
+	Max:       "13263",
+	Lim:       "100",
+	ImageSkip: 50,
+	Reason:    "Community vote to include the extra SATMINE inscriptions",
+}
+
+// rulesTables holds the rule sets of every network, sorted by activation height.
+var rulesTables = map[Network][]*ProtocolRules{
+	Mainnet: {
+		{
+			ActivationHeight: 0,
+			MaxTickLength:    4,
+			MaxMinerPower:    11000, // 11 times the base power, to avoid over-parameterization issues
+			MinerOverrides: map[string]MinerOverride{
+				"SATMINE": satmineOverride,
+			},
+			MaxProtocolJSONLength: 1024,
+
+			// Features are switched on for mainnet by appending a rule set, at a height agreed with other indexers
+			StrictJSON:   false,
+			Staking:      false,
+			Delegation:   false,
+			CreatorFee:   false,
+			LotteryDraws: false,
+			BurnTargets:  false,
+			UnburntPower: false,
+		},
+	},
+	Testnet: {
+		{
+			ActivationHeight: 0,
+			MaxTickLength:    4,
+			MaxMinerPower:    11000,
+			MinerOverrides: map[string]MinerOverride{
+				"SATMINE": satmineOverride, // Applied on every network by the legacy indexer
+			},
+			StrictJSON:            false,
+			MaxProtocolJSONLength: 1024,
+
+			// Features are tried on testnet from its first block, before mainnet switches them on
+			Staking:      true,
+			Delegation:   true,
+			CreatorFee:   true,
+			LotteryDraws: true,
+			BurnTargets:  true,
+			UnburntPower: true,
+		},
+	},
+}
+
+// activeNetwork is the network being indexed, set once at startup.
+var activeNetwork = Mainnet
+
+func init() {
+	for network, table := range rulesTables {
+		if len(table) == 0 || table[0].ActivationHeight != 0 {
+			panic(fmt.Sprintf("rules of %s must start at height 0", network))
+		}
+		for i := 1; i < len(table); i++ {
+			if table[i].ActivationHeight <= table[i-1].ActivationHeight {
+				panic(fmt.Sprintf("rules of %s are not sorted by activation height", network))
+			}
+		}
+	}
+}
+
+// SetNetwork selects the rules table used by the indexer. It must be called before any block is processed.
+func SetNetwork(network string) error {
+	if network == "" {
+		network = string(Mainnet)
+	}
+	if _, ok := rulesTables[Network(network)]; !ok {
+		return fmt.Errorf("unknown network: %s", network)
+	}
+	activeNetwork = Network(network)
+	return nil
+}
+
+// ActiveNetwork returns the network being indexed.
+func ActiveNetwork() Network {
+	return activeNetwork
+}
+
+// RulesAt returns the rules in force at the given block height.
+func RulesAt(height int64) *ProtocolRules {
+	table := rulesTables[activeNetwork]
+	rules := table[0]
+	for _, candidate := range table[1:] {
+		if candidate.ActivationHeight > height {
+			break
+		}
+		rules = candidate
+	}
+	return rules
+}
+
+// LatestRules returns the most recent rules, used by the read APIs which are not tied to a block height.
+func LatestRules() *ProtocolRules {
+	return RulesAt(math.MaxInt64)
+}
+
+// applyMinerOverride replaces the miner settings of a collection listed in MinerOverrides.
+func (r *ProtocolRules) applyMinerOverride(protocol *MRC721Protocol) {
+	if override, ok := r.MinerOverrides[protocol.Miner.GetUpperName()]; ok {
+		protocol.Miner.Max = override.Max
+		protocol.Miner.Lim = override.Lim
+	}
+}
+
+// imageSkip returns the number of first inscriptions of a collection not used as its image.
+func (r *ProtocolRules) imageSkip(mrc721Name string) int {
+	return r.MinerOverrides[mrc721Name].ImageSkip
+}
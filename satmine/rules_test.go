@@ -0,0 +1,22 @@
+package satmine
+
+import "testing"
+
+// The legacy indexer applied the SATMINE override whatever the network.
+func TestSatmineOverrideOnEveryNetwork(t *testing.T) {
+	defer SetNetwork(string(activeNetwork))
+	for network := range rulesTables {
+		if err := SetNetwork(string(network)); err != nil {
+			t.Fatal(err)
+		}
+		protocol, err := ParseMRC721Protocol([]byte(`{"p":"mrc-721","miner":{"name":"satmine","max":"10000","lim":"10"},"token":{"tick":"smt","total":"21000000","beg":"1000","halv":"10","dcr":"0.1"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules := RulesAt(0)
+		rules.applyMinerOverride(protocol)
+		if protocol.Miner.Max != "13263" || protocol.Miner.Lim != "100" || rules.imageSkip("SATMINE") != 50 {
+			t.Errorf("%s: SATMINE has max %s, lim %s and skips %d images", network, protocol.Miner.Max, protocol.Miner.Lim, rules.imageSkip("SATMINE"))
+		}
+	}
+}
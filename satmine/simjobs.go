@@ -0,0 +1,284 @@
+// filePath: satmine/simjobs.go
+
+package satmine
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Simulation job states
+const (
+	SimulationQueued    = "queued"
+	SimulationRunning   = "running"
+	SimulationDone      = "done"
+	SimulationFailed    = "failed"
+	SimulationCancelled = "cancelled"
+)
+
+const (
+	profitChartBatch     = 1000      // Heights of the profit chart computed between two cancellation checks
+	simulationQueueSize  = 64        // Jobs waiting for a worker
+	simulationCacheSize  = 256       // Finished jobs kept, the oldest are forgotten first
+	simulationCacheBytes = 128 << 20 // Bytes of results kept by the finished jobs, the newest job is kept whatever its size
+	maxSimulationHeights = 280000    // Heights of a profit chart, as many as the synchronous chart has always computed
+)
+
+var (
+	ErrSimulationQueueFull = errors.New("too many simulations queued, retry later")
+	ErrSimulationNotFound  = errors.New("simulation job not found")
+)
+
+// WebSimulationJob is the state of a simulation job. Result holds the profit chart once the job is done.
+type WebSimulationJob struct {
+	ID         string              `json:"id"`
+	Key        string              `json:"key"` // Hash of the parameters of the simulation, equal jobs share it
+	Status     string              `json:"status"`
+	Step       int                 `json:"step"`
+	Max        int                 `json:"max"`
+	Done       int64               `json:"done"`  // Heights computed
+	Total      int64               `json:"total"` // Heights to compute, known once the job runs
+	Error      string              `json:"error,omitempty"`
+	CreatedAt  int64               `json:"created_at"`
+	FinishedAt int64               `json:"finished_at,omitempty"`
+	Result     jsoniter.RawMessage `json:"result,omitempty"`
+}
+
+// simulationJob is a job of the simulation service, guarded by the lock of the service.
+type simulationJob struct {
+	WebSimulationJob
+	protocol *MRC721Protocol
+	waiters  int // Submissions still interested in the job, it is cancelled once they have all withdrawn
+	cancel   context.CancelFunc
+	finished chan struct{}
+}
+
+// SimulationJobs runs mining profit chart simulations on a bounded pool of workers. Jobs are identified by an ID
+// and cached by a hash of their parameters: submitting parameters already queued, running or done returns the
+// existing job. The finished jobs are kept up to simulationCacheSize jobs and simulationCacheBytes of results.
+type SimulationJobs struct {
+	lock        sync.Mutex
+	jobs        map[string]*simulationJob // By ID
+	byKey       map[string]*simulationJob // Live or done job of each key
+	finished    []string                  // IDs of the finished jobs, oldest first
+	resultBytes int                       // Bytes of the results of the finished jobs
+	queue       chan *simulationJob
+}
+
+// NewSimulationJobs starts a simulation service with the given number of workers, half the CPUs if not positive.
+func NewSimulationJobs(workers int) *SimulationJobs {
+	if workers <= 0 {
+		workers = runtime.NumCPU() / 2
+		if workers < 1 {
+			workers = 1
+		}
+	}
+	s := &SimulationJobs{
+		jobs:  make(map[string]*simulationJob),
+		byKey: make(map[string]*simulationJob),
+		queue: make(chan *simulationJob, simulationQueueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+	logger.Info("Simulation workers started", zap.Int("workers", workers))
+	return s
+}
+
+// simulationKey hashes the parameters deciding the result of a profit chart simulation.
+func simulationKey(protocol *MRC721Protocol, step, max int) string {
+	params := struct {
+		Token      Token  `json:"token"`
+		Pool       string `json:"pool"`
+		FeeRate    string `json:"fee_rate"`
+		CreatorFee bool   `json:"creator_fee"`
+		Step       int    `json:"step"`
+		Max        int    `json:"max"`
+	}{Token: protocol.Token, CreatorFee: LatestRules().CreatorFee, Step: step, Max: max}
+	if protocol.Ltry != nil {
+		params.Pool = protocol.Ltry.Pool
+	}
+	if protocol.Fee != nil {
+		params.FeeRate = protocol.Fee.Rate
+	}
+	paramsJSON, _ := jsoniter.Marshal(params)
+	hash := sha256.Sum256(paramsJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// Submit queues a profit chart simulation, or returns the job already simulating the same parameters. Every
+// submission returning a queued or running job counts as one waiter of the job, see Cancel.
+func (s *SimulationJobs) Submit(protocol *MRC721Protocol, step, max int) (WebSimulationJob, error) {
+	if step <= 0 || max <= 0 || max/step > maxSimulationHeights {
+		return WebSimulationJob{}, fmt.Errorf("invalid step %d or max %d", step, max)
+	}
+	if _, err := NewEmissionSchedule(protocol, 0); err != nil {
+		return WebSimulationJob{}, err
+	}
+	key := simulationKey(protocol, step, max)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if job, ok := s.byKey[key]; ok {
+		if job.Status == SimulationQueued || job.Status == SimulationRunning {
+			job.waiters++
+		}
+		return job.WebSimulationJob, nil
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return WebSimulationJob{}, err
+	}
+	protocolCopy := *protocol
+	job := &simulationJob{
+		WebSimulationJob: WebSimulationJob{
+			ID:        hex.EncodeToString(id),
+			Key:       key,
+			Status:    SimulationQueued,
+			Step:      step,
+			Max:       max,
+			CreatedAt: time.Now().Unix(),
+		},
+		protocol: &protocolCopy,
+		waiters:  1,
+		finished: make(chan struct{}),
+	}
+	select {
+	case s.queue <- job:
+	default:
+		return WebSimulationJob{}, ErrSimulationQueueFull
+	}
+	s.jobs[job.ID] = job
+	s.byKey[key] = job
+	return job.WebSimulationJob, nil
+}
+
+// Get returns a job by ID.
+func (s *SimulationJobs) Get(id string) (WebSimulationJob, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return WebSimulationJob{}, ErrSimulationNotFound
+	}
+	return job.WebSimulationJob, nil
+}
+
+// Wait waits until a job has finished or ctx is done, and returns it.
+func (s *SimulationJobs) Wait(ctx context.Context, id string) (WebSimulationJob, error) {
+	s.lock.Lock()
+	job, ok := s.jobs[id]
+	s.lock.Unlock()
+	if !ok {
+		return WebSimulationJob{}, ErrSimulationNotFound
+	}
+	select {
+	case <-job.finished:
+	case <-ctx.Done():
+		return WebSimulationJob{}, ctx.Err()
+	}
+	return s.Get(id)
+}
+
+// Cancel withdraws one waiter of a queued or running job, and cancels the job once no waiter is left: equal
+// submissions share a job, which goes on for the other ones. Cancelling a finished job has no effect.
+func (s *SimulationJobs) Cancel(id string) (WebSimulationJob, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return WebSimulationJob{}, ErrSimulationNotFound
+	}
+	if job.Status != SimulationQueued && job.Status != SimulationRunning {
+		return job.WebSimulationJob, nil
+	}
+	if job.waiters--; job.waiters > 0 {
+		return job.WebSimulationJob, nil
+	}
+
+	// New submissions of the parameters start another job
+	if s.byKey[job.Key] == job {
+		delete(s.byKey, job.Key)
+	}
+	switch job.Status {
+	case SimulationQueued:
+		// The worker taking the job skips it
+		s.finish(job, SimulationCancelled, "", context.Canceled)
+	case SimulationRunning:
+		job.cancel()
+	}
+	return job.WebSimulationJob, nil
+}
+
+// work runs the queued jobs.
+func (s *SimulationJobs) work() {
+	for job := range s.queue {
+		s.lock.Lock()
+		if job.Status != SimulationQueued {
+			s.lock.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		job.Status = SimulationRunning
+		job.cancel = cancel
+		s.lock.Unlock()
+
+		result, err := simulateMiningProfitChart(ctx, job.protocol, job.Step, job.Max, func(done, total int64) {
+			s.lock.Lock()
+			job.Done, job.Total = done, total
+			s.lock.Unlock()
+		})
+		cancel()
+
+		s.lock.Lock()
+		switch {
+		case errors.Is(err, context.Canceled):
+			s.finish(job, SimulationCancelled, "", err)
+		case err != nil:
+			s.finish(job, SimulationFailed, "", err)
+		default:
+			s.finish(job, SimulationDone, result, nil)
+		}
+		s.lock.Unlock()
+	}
+}
+
+// finish records the end of a job and forgets the oldest finished jobs past the cache size, in jobs or in bytes of
+// results. Only done jobs are kept as the cached job of their key. The lock must be held.
+func (s *SimulationJobs) finish(job *simulationJob, status, result string, err error) {
+	job.Status = status
+	job.FinishedAt = time.Now().Unix()
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if result != "" {
+		job.Result = jsoniter.RawMessage(result)
+	}
+	if status != SimulationDone && s.byKey[job.Key] == job {
+		delete(s.byKey, job.Key)
+	}
+	close(job.finished)
+
+	s.finished = append(s.finished, job.ID)
+	s.resultBytes += len(job.Result)
+	for len(s.finished) > simulationCacheSize || (len(s.finished) > 1 && s.resultBytes > simulationCacheBytes) {
+		oldest := s.jobs[s.finished[0]]
+		s.finished = s.finished[1:]
+		s.resultBytes -= len(oldest.Result)
+		delete(s.jobs, oldest.ID)
+		if s.byKey[oldest.Key] == oldest {
+			delete(s.byKey, oldest.Key)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package satmine
+
+import (
+	"strings"
+	"testing"
+)
+
+// newTestSimulationJobs returns a simulation service without workers, its jobs stay queued.
+func newTestSimulationJobs() *SimulationJobs {
+	return &SimulationJobs{
+		jobs:  make(map[string]*simulationJob),
+		byKey: make(map[string]*simulationJob),
+		queue: make(chan *simulationJob, simulationQueueSize),
+	}
+}
+
+// A job shared by equal submissions is only cancelled once every submission has withdrawn.
+func TestCancelSharedSimulation(t *testing.T) {
+	s := newTestSimulationJobs()
+	protocol, err := ParseMRC721Protocol(testBurnMinerContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.Submit(protocol, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Submit(protocol, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("equal submissions got jobs %s and %s", first.ID, second.ID)
+	}
+
+	job, err := s.Cancel(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != SimulationQueued {
+		t.Fatalf("job is %s after the first withdrawal, want %s", job.Status, SimulationQueued)
+	}
+	job, err = s.Cancel(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != SimulationCancelled {
+		t.Fatalf("job is %s after the last withdrawal, want %s", job.Status, SimulationCancelled)
+	}
+
+	// The parameters are simulated again by a new job
+	third, err := s.Submit(protocol, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.ID == first.ID || third.Status != SimulationQueued {
+		t.Fatalf("resubmission got job %s (%s), want a new queued job", third.ID, third.Status)
+	}
+}
+
+// The finished jobs are forgotten oldest first once their results pass simulationCacheBytes, the newest is kept.
+func TestSimulationCacheBytes(t *testing.T) {
+	s := newTestSimulationJobs()
+	result := `"` + strings.Repeat("x", simulationCacheBytes/4) + `"`
+	var ids []string
+	for i := 0; i < 4; i++ {
+		job := &simulationJob{
+			WebSimulationJob: WebSimulationJob{ID: string(rune('a' + i)), Key: string(rune('a' + i)), Status: SimulationQueued},
+			finished:         make(chan struct{}),
+		}
+		s.jobs[job.ID] = job
+		s.byKey[job.Key] = job
+		s.finish(job, SimulationDone, result, nil)
+		ids = append(ids, job.ID)
+	}
+	if _, err := s.Get(ids[0]); err != ErrSimulationNotFound {
+		t.Errorf("oldest job kept past the cache bytes: %v", err)
+	}
+	for _, id := range ids[1:] {
+		if _, err := s.Get(id); err != nil {
+			t.Errorf("job %s: %v", id, err)
+		}
+	}
+	if s.resultBytes > simulationCacheBytes {
+		t.Errorf("%d bytes of results cached, the bound is %d", s.resultBytes, simulationCacheBytes)
+	}
+
+	// A result larger than the cache is still kept alone
+	huge := &simulationJob{WebSimulationJob: WebSimulationJob{ID: "huge", Key: "huge"}, finished: make(chan struct{})}
+	s.jobs[huge.ID] = huge
+	s.finish(huge, SimulationDone, `"`+strings.Repeat("x", simulationCacheBytes)+`"`, nil)
+	if len(s.finished) != 1 || s.finished[0] != huge.ID {
+		t.Errorf("cached jobs are %v, want only the newest", s.finished)
+	}
+}
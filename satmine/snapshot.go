@@ -0,0 +1,129 @@
+// filePath: satmine/snapshot.go
+
+package satmine
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// StateSnapshot is the protocol state of the index, independent of how it is stored.
+// It is what the conformance vectors compare after replaying their blocks.
+type StateSnapshot struct {
+	Balances map[string]map[string]string `json:"balances"` // Address -> tick -> balance, unsettled mining rewards included
+	Owners   map[string]string            `json:"owners"`   // MRC-721 inscription ID -> owner address
+	Genesis  map[string]Mrc721GenesisData `json:"genesis"`  // Collection name -> genesis data
+	Lottery  map[string][]LotteryData     `json:"lottery"`  // Collection name -> lottery draws in round order
+}
+
+// Snapshot reads the protocol state of the index.
+// Balances which are not decimal strings are reported as "0x" followed by their bytes in hex.
+func (b *BTOrdIdx) Snapshot() (*StateSnapshot, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	snapshot := &StateSnapshot{
+		Balances: map[string]map[string]string{},
+		Owners:   map[string]string{},
+		Genesis:  map[string]Mrc721GenesisData{},
+		Lottery:  map[string][]LotteryData{},
+	}
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		// mrc20::balance::[address]::[tick] -> balance
+		err := scanPrefix(txn, "mrc20::balance::", func(key string, val []byte) error {
+			separator := strings.LastIndex(key, "::")
+			if separator < 0 {
+				return nil
+			}
+			address, tick := key[:separator], key[separator+2:]
+			balance := snapshotBalance(val)
+			if _, ok := new(big.Int).SetString(balance, 10); ok {
+				balance = unlockedEffectiveBalance(txn, address, tick, balance)
+			}
+			if snapshot.Balances[address] == nil {
+				snapshot.Balances[address] = map[string]string{}
+			}
+			snapshot.Balances[address][tick] = balance
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// mrc721::inscr_addr::[inscription_id]::[address]
+		err = scanPrefix(txn, "mrc721::inscr_addr::", func(key string, val []byte) error {
+			if separator := strings.Index(key, "::"); separator >= 0 {
+				snapshot.Owners[key[:separator]] = key[separator+2:]
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// mrc721::geninsc::[mrc721_name] -> Mrc721GenesisData
+		err = scanPrefix(txn, "mrc721::geninsc::", func(key string, val []byte) error {
+			var genesisData Mrc721GenesisData
+			if err := jsoniter.Unmarshal(val, &genesisData); err != nil {
+				return err
+			}
+			snapshot.Genesis[key] = genesisData
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// lottery::mrc721::[mrc721_name]::[round] -> LotteryData
+		err = scanPrefix(txn, "lottery::mrc721::", func(key string, val []byte) error {
+			var lotteryData LotteryData
+			if err := jsoniter.Unmarshal(val, &lotteryData); err != nil {
+				return err
+			}
+			name := key[:strings.LastIndex(key, "::")]
+			snapshot.Lottery[name] = append(snapshot.Lottery[name], lotteryData)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, draws := range snapshot.Lottery {
+			sort.Slice(draws, func(i, j int) bool { return draws[i].Round < draws[j].Round })
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// scanPrefix calls fn with the key, stripped of prefix, and the value of every key starting with prefix.
+func scanPrefix(txn *badger.Txn, prefix string, fn func(key string, val []byte) error) error {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+		item := it.Item()
+		key := strings.TrimPrefix(string(item.Key()), prefix)
+		if err := item.Value(func(val []byte) error {
+			return fn(key, val)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotBalance returns a stored balance, as "0x" followed by its bytes in hex if it is not a decimal string.
+func snapshotBalance(val []byte) string {
+	if _, ok := new(big.Int).SetString(string(val), 10); ok {
+		return string(val)
+	}
+	return "0x" + hex.EncodeToString(val)
+}
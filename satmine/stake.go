@@ -0,0 +1,279 @@
+// filePath: satmine/stake.go
+
+package satmine
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+// Staking locks MRC-20 tokens against a miner of the collection emitting them. Unlike a burn, the tokens go back to
+// the staker with an unstake op, once the lock of the collection has passed, and the power they add goes with them.
+// The stakes of an address on a miner are merged into one position, every new stake restarting its lock:
+//
+//	mrc721::stake::<target_inscription_id>::<staker> -> Mrc721StakePosition
+//	mrc721::stake_num::<target_inscription_id>      -> tokens staked on the miner, in decimal
+
+// Mrc721StakePosition is the tokens an address staked on a miner.
+type Mrc721StakePosition struct {
+	Staker       string `json:"staker"`
+	Target       string `json:"target"` // MRC-721 miner boosted by the stake
+	Tick         string `json:"tick"`
+	Amount       string `json:"amount"`
+	BlockHeight  string `json:"block_height"`  // Height of the last stake
+	UnlockHeight string `json:"unlock_height"` // First height at which the position can be unstaked
+}
+
+// WebMinerStakes lists the stakes on a miner.
+type WebMinerStakes struct {
+	StakeNum  string                `json:"stake_num"` // Tokens staked on the miner
+	Positions []Mrc721StakePosition `json:"positions"`
+}
+
+// stakePositionKey returns the key of the position of staker on target.
+func stakePositionKey(target, staker string) []byte {
+	return []byte("mrc721::stake::" + target + "::" + staker)
+}
+
+// getStakeNum reads the number of tokens staked on an inscription.
+func getStakeNum(txn *badger.Txn, inscriptionID string) (*big.Int, error) {
+	stakeNum := big.NewInt(0)
+	item, err := txn.Get([]byte("mrc721::stake_num::" + inscriptionID))
+	if err == badger.ErrKeyNotFound {
+		return stakeNum, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = item.Value(func(val []byte) error {
+		return stakeNum.UnmarshalText(val)
+	})
+	return stakeNum, err
+}
+
+// addStakeNum adds delta, which may be negative, to the tokens staked on an inscription.
+func addStakeNum(txn *badger.Txn, inscriptionID string, delta *big.Int) error {
+	stakeNum, err := getStakeNum(txn, inscriptionID)
+	if err != nil {
+		return err
+	}
+	stakeNum.Add(stakeNum, delta)
+	if stakeNum.Sign() == 0 {
+		return deleteState(txn, []byte("mrc721::stake_num::"+inscriptionID))
+	}
+	return setState(txn, []byte("mrc721::stake_num::"+inscriptionID), []byte(stakeNum.String()))
+}
+
+// getStakePosition reads the position of staker on target, nil if it has none.
+func getStakePosition(txn *badger.Txn, target, staker string) (*Mrc721StakePosition, error) {
+	item, err := txn.Get(stakePositionKey(target, staker))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var position Mrc721StakePosition
+	err = item.Value(func(val []byte) error {
+		return jsoniter.Unmarshal(val, &position)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// putStakePosition writes a position, deleting it once nothing is staked.
+func putStakePosition(txn *badger.Txn, position *Mrc721StakePosition) error {
+	if position.Amount == "0" {
+		return deleteState(txn, stakePositionKey(position.Target, position.Staker))
+	}
+	positionJSON, err := jsoniter.Marshal(position)
+	if err != nil {
+		return err
+	}
+	return setState(txn, stakePositionKey(position.Target, position.Staker), positionJSON)
+}
+
+// stakeMrc20 locks the amount of a stake inscription against the miner it names.
+func (b *BTOrdIdx) stakeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInscription, mrc20Data *MRC20Protocol) error {
+	if mrc20Data.Insc == nil {
+		b.rejectInscription(ReasonMissingTarget, "stake does not name an inscription")
+		return nil
+	}
+
+	// Check if the token exists
+	if _, err := txn.Get([]byte("mrc20::geninsc::" + mrc20Data.Tick)); err != nil {
+		b.ignoreInscription(ReasonUnknownTick, "no collection mines "+mrc20Data.Tick)
+		return nil // Token does not exist, no error, stop execution
+	}
+
+	// Only the miners of a collection with a stake section can be staked on
+	mrc721Name, err := tickMinerCollection(txn, mrc20Data.Tick, *mrc20Data.Insc)
+	if err != nil {
+		return err
+	}
+	if mrc721Name == "" {
+		b.rejectInscription(ReasonInvalidTarget, fmt.Sprintf("%s is not a miner of the collection emitting %s", *mrc20Data.Insc, mrc20Data.Tick))
+		return nil
+	}
+	collection, err := b.mrc721Collection(txn, mrc721Name)
+	if err != nil {
+		return err
+	}
+	if collection.Protocol.Stake == nil {
+		b.rejectInscription(ReasonStakeDisabled, mrc721Name+" has no stake section")
+		return nil
+	}
+
+	// Pending mining rewards are part of the spendable balance
+	if err := b.settleAddressMiners(txn, inscr.Address, mrc20Data.Tick); err != nil {
+		return err
+	}
+
+	balanceKey := "mrc20::balance::" + inscr.Address + "::" + mrc20Data.Tick
+	item, err := txn.Get([]byte(balanceKey))
+	if err == badger.ErrKeyNotFound {
+		b.rejectInscription(ReasonInsufficientBalance, "address holds no "+mrc20Data.Tick)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var balanceBigInt *big.Int
+	err = item.Value(func(val []byte) error {
+		balanceBigInt = parseBalance(val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	amountBigInt, _ := new(big.Int).SetString(mrc20Data.Amt, 10) // Already validated by validateMRC20Data
+	if amountBigInt.Cmp(balanceBigInt) > 0 {
+		b.rejectInscription(ReasonInsufficientBalance, fmt.Sprintf("balance %s is less than %s", balanceBigInt, amountBigInt))
+		return nil
+	}
+
+	// Move the amount from the balance to the position
+	newBalanceBigInt := new(big.Int).Sub(balanceBigInt, amountBigInt)
+	if err := setState(txn, []byte(balanceKey), []byte(newBalanceBigInt.String())); err != nil {
+		return err
+	}
+	if err := b.rewriteMinerBalance(txn, inscr.Address, mrc20Data.Tick); err != nil {
+		return err
+	}
+
+	position, err := getStakePosition(txn, *mrc20Data.Insc, inscr.Address)
+	if err != nil {
+		return err
+	}
+	staked := big.NewInt(0)
+	if position == nil {
+		position = &Mrc721StakePosition{Staker: inscr.Address, Target: *mrc20Data.Insc, Tick: mrc20Data.Tick}
+	} else {
+		staked.SetString(position.Amount, 10)
+	}
+	height, err := strconv.ParseInt(block.BlockHeight, 10, 64)
+	if err != nil {
+		return err
+	}
+	lock, _ := strconv.ParseInt(collection.Protocol.Stake.Lock, 10, 64) // Already validated by stakeIssues
+	position.Amount = staked.Add(staked, amountBigInt).String()
+	position.BlockHeight = block.BlockHeight
+	position.UnlockHeight = strconv.FormatInt(height+lock, 10)
+	if err := putStakePosition(txn, position); err != nil {
+		logger.Error("Failed to write the stake position: ", zap.Error(err))
+		return err
+	}
+	if err := addStakeNum(txn, *mrc20Data.Insc, amountBigInt); err != nil {
+		return err
+	}
+
+	// The stake raises the mining power of the inscription from the next distribution on
+	return b.updateMinerPower(txn, *mrc20Data.Insc)
+}
+
+// unstakeMrc20 gives back to its staker the amount of an unstake inscription, once the position is unlocked.
+func (b *BTOrdIdx) unstakeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInscription, mrc20Data *MRC20Protocol) error {
+	if mrc20Data.Insc == nil {
+		b.rejectInscription(ReasonMissingTarget, "unstake does not name an inscription")
+		return nil
+	}
+
+	position, err := getStakePosition(txn, *mrc20Data.Insc, inscr.Address)
+	if err != nil {
+		return err
+	}
+	if position == nil || position.Tick != mrc20Data.Tick {
+		b.rejectInscription(ReasonInsufficientStake, fmt.Sprintf("address staked no %s on %s", mrc20Data.Tick, *mrc20Data.Insc))
+		return nil
+	}
+	height, err := strconv.ParseInt(block.BlockHeight, 10, 64)
+	if err != nil {
+		return err
+	}
+	unlockHeight, err := strconv.ParseInt(position.UnlockHeight, 10, 64)
+	if err != nil {
+		return err
+	}
+	if height < unlockHeight {
+		b.rejectInscription(ReasonStakeLocked, "stake is locked until height "+position.UnlockHeight)
+		return nil
+	}
+	staked, _ := new(big.Int).SetString(position.Amount, 10)
+	amountBigInt, _ := new(big.Int).SetString(mrc20Data.Amt, 10) // Already validated by validateMRC20Data
+	if amountBigInt.Cmp(staked) > 0 {
+		b.rejectInscription(ReasonInsufficientStake, fmt.Sprintf("staked %s is less than %s", staked, amountBigInt))
+		return nil
+	}
+
+	// Move the amount from the position back to the balance
+	position.Amount = staked.Sub(staked, amountBigInt).String()
+	if err := putStakePosition(txn, position); err != nil {
+		logger.Error("Failed to write the stake position: ", zap.Error(err))
+		return err
+	}
+	if err := addStakeNum(txn, *mrc20Data.Insc, new(big.Int).Neg(amountBigInt)); err != nil {
+		return err
+	}
+	if err := addBalance(txn, inscr.Address, mrc20Data.Tick, amountBigInt); err != nil {
+		return err
+	}
+
+	// The miner loses the power of the stake from the next distribution on
+	return b.updateMinerPower(txn, *mrc20Data.Insc)
+}
+
+// GetMinerStakes returns the tokens staked on a miner and the positions holding them.
+func (b *BTOrdIdx) GetMinerStakes(inscriptionID string) (WebMinerStakes, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	inscriptionID = strings.TrimSpace(inscriptionID)
+	stakes := WebMinerStakes{Positions: []Mrc721StakePosition{}}
+	err := b.db.View(func(txn *badger.Txn) error {
+		stakeNum, err := getStakeNum(txn, inscriptionID)
+		if err != nil {
+			return err
+		}
+		stakes.StakeNum = stakeNum.String()
+		return scanPrefix(txn, "mrc721::stake::"+inscriptionID+"::", func(key string, val []byte) error {
+			var position Mrc721StakePosition
+			if err := jsoniter.Unmarshal(val, &position); err != nil {
+				return err
+			}
+			stakes.Positions = append(stakes.Positions, position)
+			return nil
+		})
+	})
+	if err != nil {
+		return WebMinerStakes{}, err
+	}
+	return stakes, nil
+}
@@ -0,0 +1,362 @@
+// filePath: satmine/statediff.go
+
+package satmine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// WebBalanceChange is the change of the stored MRC-20 balance of an address. Mining rewards not settled yet are
+// left out, they are reported per power class by WebRewardChange.
+type WebBalanceChange struct {
+	Address string `json:"address"`
+	Tick    string `json:"tick"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Delta   string `json:"delta"`
+}
+
+// WebOwnershipChange is an inscription changing hands. From is empty for an inscription indexed by the block, To is
+// empty for an inscription no longer owned by anyone.
+type WebOwnershipChange struct {
+	InscriptionID string `json:"inscription_id"`
+	Protocol      string `json:"protocol"` // mrc-721 or mrc-20
+	From          string `json:"from"`
+	To            string `json:"to"`
+}
+
+// WebPowerChange is the change of the power of a miner, Before is empty for a miner minted by the block.
+type WebPowerChange struct {
+	InscriptionID string `json:"inscription_id"`
+	Before        string `json:"before"`
+	After         string `json:"after"`
+}
+
+// WebGenesisChange is the change of the genesis data of a collection, Before is nil for a collection deployed by
+// the block.
+type WebGenesisChange struct {
+	Mrc721name string             `json:"mrc721name"`
+	Before     *Mrc721GenesisData `json:"before"`
+	After      *Mrc721GenesisData `json:"after"`
+}
+
+// WebRewardChange is the reward a block accrued to every miner of a power class of a collection. The reward stays in
+// the reward pool until it is settled into the balance of the owner or the delegate of each miner, a later block
+// settling it reports the balance change. Reward times Miners is what the class earned in the block.
+type WebRewardChange struct {
+	Mrc721name string `json:"mrc721name"`
+	Power      string `json:"power"`  // Mining power of the miners of the class
+	Miners     int    `json:"miners"` // Number of miners of the class
+	Reward     string `json:"reward"` // Reward of one miner of the class
+}
+
+// WebBlockDiff is the state a block changed. KeysChanged and Digest cover every key the block wrote, the typed
+// changes only the state users hold.
+type WebBlockDiff struct {
+	BlockHeight string               `json:"block_height"`
+	BlockHash   string               `json:"block_hash"`
+	KeysChanged int                  `json:"keys_changed"`
+	Digest      string               `json:"digest"` // SHA-256 of the keys changed with their values before and after
+	Balances    []WebBalanceChange   `json:"balances"`
+	Ownership   []WebOwnershipChange `json:"ownership"`
+	Powers      []WebPowerChange     `json:"powers"`
+	Genesis     []WebGenesisChange   `json:"genesis"`
+	Lottery     []LotteryData        `json:"lottery"` // Lottery wins of the block
+	Rewards     []WebRewardChange    `json:"rewards"` // Mining rewards accrued by the block and not settled yet
+}
+
+// stateValue is the value of a key, Found is false for a key which does not exist.
+type stateValue struct {
+	Value []byte
+	Found bool
+}
+
+// stateChanges is the set of keys written to a transaction while it is recorded, with their values before the
+// first write.
+type stateChanges struct {
+	keys    []string
+	before  map[string]stateValue
+	rewards []WebRewardChange // Mining rewards distributed to the reward pools, in order
+}
+
+// stateRecorders holds the transactions whose writes are recorded. The block write path writes through setState
+// and deleteState, which are plain writes for the other transactions.
+var stateRecorders = struct {
+	sync.Mutex
+	txns map[*badger.Txn]*stateChanges
+}{txns: make(map[*badger.Txn]*stateChanges)}
+
+// recordStateChanges starts recording the writes to txn.
+func recordStateChanges(txn *badger.Txn) {
+	stateRecorders.Lock()
+	defer stateRecorders.Unlock()
+	stateRecorders.txns[txn] = &stateChanges{before: make(map[string]stateValue)}
+}
+
+// stopStateChanges stops recording the writes to txn and returns them.
+func stopStateChanges(txn *badger.Txn) *stateChanges {
+	stateRecorders.Lock()
+	defer stateRecorders.Unlock()
+	changes := stateRecorders.txns[txn]
+	delete(stateRecorders.txns, txn)
+	return changes
+}
+
+// noteStateChange keeps the value of key before its first write, if the writes to txn are recorded.
+func noteStateChange(txn *badger.Txn, key []byte) error {
+	stateRecorders.Lock()
+	changes := stateRecorders.txns[txn]
+	stateRecorders.Unlock()
+	if changes == nil {
+		return nil
+	}
+	if _, ok := changes.before[string(key)]; ok {
+		return nil
+	}
+	value, err := getStateValue(txn, string(key))
+	if err != nil {
+		return err
+	}
+	changes.before[string(key)] = value
+	changes.keys = append(changes.keys, string(key))
+	return nil
+}
+
+// noteMiningRewards keeps the reward a distribution added to every power class of a collection, if the writes to
+// txn are recorded. accs are the accumulators of the classes of pool before the distribution.
+func noteMiningRewards(txn *badger.Txn, mrc721Name string, pool *Mrc721RewardPool, accs []string) {
+	stateRecorders.Lock()
+	changes := stateRecorders.txns[txn]
+	stateRecorders.Unlock()
+	if changes == nil {
+		return
+	}
+	for i, class := range pool.Classes {
+		acc, _ := new(big.Int).SetString(class.Acc, 10)
+		before, _ := new(big.Int).SetString(accs[i], 10)
+		if reward := acc.Sub(acc, before); reward.Sign() > 0 {
+			changes.rewards = append(changes.rewards, WebRewardChange{Mrc721name: mrc721Name, Power: class.Power, Miners: class.Miners, Reward: reward.String()})
+		}
+	}
+}
+
+// setState sets a key of the indexed state.
+func setState(txn *badger.Txn, key, value []byte) error {
+	if err := noteStateChange(txn, key); err != nil {
+		return err
+	}
+	return txn.Set(key, value)
+}
+
+// deleteState deletes a key of the indexed state.
+func deleteState(txn *badger.Txn, key []byte) error {
+	if err := noteStateChange(txn, key); err != nil {
+		return err
+	}
+	return txn.Delete(key)
+}
+
+// getStateValue reads the value of a key as txn sees it.
+func getStateValue(txn *badger.Txn, key string) (stateValue, error) {
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return stateValue{}, nil
+	}
+	if err != nil {
+		return stateValue{}, err
+	}
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return stateValue{}, err
+	}
+	return stateValue{Value: value, Found: true}, nil
+}
+
+// blockDiff compares the keys written by block with their values in txn.
+func (c *stateChanges) blockDiff(txn *badger.Txn, block *HookBlock) (WebBlockDiff, error) {
+	diff := WebBlockDiff{
+		BlockHeight: block.BlockHeight,
+		BlockHash:   block.BlockHash,
+		Balances:    []WebBalanceChange{},
+		Ownership:   []WebOwnershipChange{},
+		Powers:      []WebPowerChange{},
+		Genesis:     []WebGenesisChange{},
+		Lottery:     []LotteryData{},
+		Rewards:     append([]WebRewardChange{}, c.rewards...),
+	}
+	keys := append([]string(nil), c.keys...)
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	ownership := make(map[string]*WebOwnershipChange)
+	var owned []string
+	var balances []string
+	for _, key := range keys {
+		before := c.before[key]
+		after, err := getStateValue(txn, key)
+		if err != nil {
+			return WebBlockDiff{}, err
+		}
+		if before.Found == after.Found && bytes.Equal(before.Value, after.Value) {
+			continue // Written back unchanged
+		}
+		diff.KeysChanged++
+		fmt.Fprintf(hash, "%q %t %x %t %x\n", key, before.Found, before.Value, after.Found, after.Value)
+
+		switch {
+		case strings.HasPrefix(key, "mrc20::balance::"):
+			balances = append(balances, key)
+
+		case strings.HasPrefix(key, "mrc721::inscr_addr::"), strings.HasPrefix(key, "mrc20::inscr_addr::"):
+			// [protocol]::inscr_addr::[inscription_id]::[address], written on inscription and moved on transfer
+			protocol := "mrc-721"
+			if strings.HasPrefix(key, "mrc20::") {
+				protocol = "mrc-20"
+			}
+			rest := key[strings.Index(key, "::inscr_addr::")+len("::inscr_addr::"):]
+			split := strings.Index(rest, "::")
+			if split < 0 {
+				continue
+			}
+			inscriptionID, address := rest[:split], rest[split+2:]
+			change, ok := ownership[inscriptionID]
+			if !ok {
+				change = &WebOwnershipChange{InscriptionID: inscriptionID, Protocol: protocol}
+				ownership[inscriptionID] = change
+				owned = append(owned, inscriptionID)
+			}
+			if after.Found {
+				change.To = address
+			} else {
+				change.From = address
+			}
+
+		case strings.HasPrefix(key, "mrc721::inscr_power::"):
+			change := WebPowerChange{InscriptionID: strings.TrimPrefix(key, "mrc721::inscr_power::")}
+			if before.Found {
+				change.Before = new(big.Int).SetBytes(before.Value).String()
+			}
+			if after.Found {
+				change.After = new(big.Int).SetBytes(after.Value).String()
+			}
+			diff.Powers = append(diff.Powers, change)
+
+		case strings.HasPrefix(key, "mrc721::geninsc::"):
+			change := WebGenesisChange{Mrc721name: strings.TrimPrefix(key, "mrc721::geninsc::")}
+			if before.Found {
+				change.Before = new(Mrc721GenesisData)
+				if err := jsoniter.Unmarshal(before.Value, change.Before); err != nil {
+					return WebBlockDiff{}, err
+				}
+			}
+			if after.Found {
+				change.After = new(Mrc721GenesisData)
+				if err := jsoniter.Unmarshal(after.Value, change.After); err != nil {
+					return WebBlockDiff{}, err
+				}
+			}
+			diff.Genesis = append(diff.Genesis, change)
+
+		case strings.HasPrefix(key, "lottery::mrc721::") && after.Found:
+			var lotteryData LotteryData
+			if err := jsoniter.Unmarshal(after.Value, &lotteryData); err != nil {
+				return WebBlockDiff{}, err
+			}
+			diff.Lottery = append(diff.Lottery, lotteryData)
+		}
+	}
+	for _, inscriptionID := range owned {
+		diff.Ownership = append(diff.Ownership, *ownership[inscriptionID])
+	}
+	diff.Digest = hex.EncodeToString(hash.Sum(nil))
+
+	// Mining rewards accrue in the reward pools, the balances only change when the block settles them. Adding the
+	// unsettled rewards of every owner would read every miner of the collections mined by the block.
+	for _, key := range balances {
+		change, err := c.balanceChange(txn, key)
+		if err != nil {
+			return WebBlockDiff{}, err
+		}
+		if change != nil {
+			diff.Balances = append(diff.Balances, *change)
+		}
+	}
+	return diff, nil
+}
+
+// balanceChange returns the change of a stored balance key, nil for a balance created at zero.
+func (c *stateChanges) balanceChange(txn *badger.Txn, key string) (*WebBalanceChange, error) {
+	// mrc20::balance::[address]::[tick]
+	rest := strings.TrimPrefix(key, "mrc20::balance::")
+	split := strings.LastIndex(rest, "::")
+	if split < 0 {
+		return nil, nil
+	}
+	change := &WebBalanceChange{Address: rest[:split], Tick: rest[split+2:], Before: "0", After: "0"}
+
+	before := c.before[key]
+	after, err := getStateValue(txn, key)
+	if err != nil {
+		return nil, err
+	}
+	// Balances left as bytes by the burns indexed before rules.BurnTargets are reported as the snapshot does
+	if before.Found {
+		change.Before = snapshotBalance(before.Value)
+	}
+	if after.Found {
+		change.After = snapshotBalance(after.Value)
+	}
+	if change.Before == change.After {
+		return nil, nil
+	}
+	balanceBefore, okBefore := new(big.Int).SetString(change.Before, 10)
+	balanceAfter, okAfter := new(big.Int).SetString(change.After, 10)
+	if okBefore && okAfter {
+		change.Delta = new(big.Int).Sub(balanceAfter, balanceBefore).String()
+	}
+	return change, nil
+}
+
+// putBlockDiff writes the state changed by a block.
+// blockdiff::[block_height] -> WebBlockDiff{}
+func putBlockDiff(txn *badger.Txn, diff *WebBlockDiff) error {
+	diffJSON, err := jsoniter.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	return setState(txn, []byte("blockdiff::"+diff.BlockHeight), diffJSON)
+}
+
+// GetBlockDiff returns the state changed by a block. Blocks indexed before the diffs were kept have none.
+func (b *BTOrdIdx) GetBlockDiff(height string) (WebBlockDiff, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	var diff WebBlockDiff
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("blockdiff::" + strings.TrimSpace(height)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &diff)
+		})
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return WebBlockDiff{}, fmt.Errorf("no diff for block %s: %w", height, err)
+		}
+		return WebBlockDiff{}, err
+	}
+	return diff, nil
+}
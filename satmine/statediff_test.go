@@ -0,0 +1,142 @@
+package satmine
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// testMinerContent deploys a collection mining from the block after its deploy, its copies mint miners.
+var testMinerContent = []byte(`{"p":"mrc-721","miner":{"name":"gamma","max":"100","lim":"5"},"token":{"tick":"gamm","total":"21000000","beg":"1000","halv":"10","dcr":"0.1"}}`)
+
+// testInscription returns an inscription of content by address in a block.
+func testInscription(number, height int, address string, content []byte) HookInscription {
+	return HookInscription{
+		ID:            "test" + strconv.Itoa(number) + "i0",
+		Number:        number,
+		Address:       address,
+		BlockHeight:   height,
+		ContentByte:   &content,
+		ContentType:   "text/plain;charset=utf-8",
+		ContentLength: len(content),
+	}
+}
+
+// snapshotBalances flattens the balances of a snapshot by address and tick.
+func snapshotBalances(t *testing.T, b *BTOrdIdx) map[[2]string]string {
+	t.Helper()
+	snapshot, err := b.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances := make(map[[2]string]string)
+	for address, ticks := range snapshot.Balances {
+		for tick, balance := range ticks {
+			balances[[2]string{address, tick}] = balance
+		}
+	}
+	return balances
+}
+
+// storedBalances reads the stored balances by address and tick, mining rewards not settled yet left out.
+func storedBalances(t *testing.T, b *BTOrdIdx) map[[2]string]string {
+	t.Helper()
+	balances := make(map[[2]string]string)
+	err := b.db.View(func(txn *badger.Txn) error {
+		return scanPrefix(txn, "mrc20::balance::", func(key string, val []byte) error {
+			split := strings.LastIndex(key, "::")
+			balances[[2]string{key[:split], key[split+2:]}] = snapshotBalance(val)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balances
+}
+
+// sumBalances adds up the balances of tick.
+func sumBalances(balances map[[2]string]string, tick string) *big.Int {
+	total := big.NewInt(0)
+	for key, balance := range balances {
+		if amount, ok := new(big.Int).SetString(balance, 10); ok && key[1] == tick {
+			total.Add(total, amount)
+		}
+	}
+	return total
+}
+
+func TestBlockDiffMiningRewards(t *testing.T) {
+	b := openTestIndex(t)
+	blocks := []*HookBlock{
+		{Inscriptions: []HookInscription{testInscription(1, 800000, "bc1qdeployer", testMinerContent)}},
+		{Inscriptions: []HookInscription{testInscription(2, 800001, "bc1qminer", testMinerContent)}},
+		{},
+		{},
+	}
+
+	rewarded := false
+	for i, block := range blocks {
+		block.BlockHeight = strconv.Itoa(800000 + i)
+		block.BlockHash = "hash" + block.BlockHeight
+		block.Timestamp = int64(1700000000 + 600*i)
+
+		storedBefore, before := storedBalances(t, b), snapshotBalances(t, b)
+		if err := b.WriteBlock(block); err != nil {
+			t.Fatalf("block %s: %v", block.BlockHeight, err)
+		}
+		storedAfter, after := storedBalances(t, b), snapshotBalances(t, b)
+		diff, err := b.GetBlockDiff(block.BlockHeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The diff holds every stored balance the block changed
+		changes := make(map[[2]string]WebBalanceChange)
+		for _, change := range diff.Balances {
+			changes[[2]string{change.Address, change.Tick}] = change
+		}
+		for key, balance := range storedAfter {
+			previous, ok := storedBefore[key]
+			if !ok {
+				previous = "0"
+			}
+			change, ok := changes[key]
+			if previous == balance {
+				if ok {
+					t.Errorf("block %s: unchanged balance %v in the diff: %+v", block.BlockHeight, key, change)
+				}
+				continue
+			}
+			if !ok || change.Before != previous || change.After != balance {
+				t.Errorf("block %s: balance %v went from %s to %s, the diff has %+v", block.BlockHeight, key, previous, balance, change)
+			}
+			delete(changes, key)
+		}
+		for key, change := range changes {
+			t.Errorf("block %s: balance %v is not stored: %+v", block.BlockHeight, key, change)
+		}
+
+		// The rewards of the power classes are what the balances, unsettled rewards included, gained on top of the
+		// stored balances
+		accrued := big.NewInt(0)
+		for _, reward := range diff.Rewards {
+			amount, _ := new(big.Int).SetString(reward.Reward, 10)
+			accrued.Add(accrued, amount.Mul(amount, big.NewInt(int64(reward.Miners))))
+		}
+		gained := new(big.Int).Sub(sumBalances(after, "gamm"), sumBalances(before, "gamm"))
+		gained.Sub(gained, new(big.Int).Sub(sumBalances(storedAfter, "gamm"), sumBalances(storedBefore, "gamm")))
+		if gained.Cmp(accrued) != 0 {
+			t.Errorf("block %s: unsettled rewards grew by %s, the diff has %s: %+v", block.BlockHeight, gained, accrued, diff.Rewards)
+		}
+		if len(block.Inscriptions) == 0 && accrued.Sign() > 0 {
+			rewarded = true
+		}
+	}
+	if !rewarded {
+		t.Fatal("no block diff holds mining rewards")
+	}
+}
@@ -0,0 +1,252 @@
+// filePath: satmine/strictjson.go
+
+package satmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reasons for which the strict parser rejects a protocol inscription.
+const (
+	StrictTooLong       = "too_long"               // The content is longer than MaxProtocolJSONLength bytes
+	StrictInvalidUTF8   = "invalid_utf8"           // The content is not valid UTF-8
+	StrictSyntaxError   = "syntax_error"           // The content is not valid JSON
+	StrictNotObject     = "not_object"             // The document, or a field holding an object, is not a JSON object
+	StrictTrailingData  = "trailing_data"          // Something other than whitespace follows the document
+	StrictDuplicateKey  = "duplicate_key"          // A key appears twice in the same object
+	StrictUnknownField  = "unknown_field"          // A key is not a field of the protocol, keys are case-sensitive
+	StrictNotString     = "not_string"             // A field value is not a JSON string
+	StrictWhitespace    = "surrounding_whitespace" // A string value starts or ends with whitespace
+	StrictInvalidNumber = "invalid_number"         // A numeric field is not a plain decimal number
+	StrictLeadingZero   = "leading_zero"           // A numeric field has a leading zero
+)
+
+// StrictJSONError is the rejection of a protocol inscription by the strict parser.
+type StrictJSONError struct {
+	Reason string // One of the Strict* reasons
+	Field  string // Path of the offending field, e.g. "token.total", empty for the whole document
+	Detail string // Human readable detail
+}
+
+func (e *StrictJSONError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("strict json: %s: %s", e.Reason, e.Detail)
+	}
+	return fmt.Sprintf("strict json: %s at %s: %s", e.Reason, e.Field, e.Detail)
+}
+
+// strictKind is the kind of value a field holds.
+type strictKind int
+
+const (
+	strictText    strictKind = iota // Any string without surrounding whitespace
+	strictInteger                   // Non-negative integer: 0 or a digit 1-9 followed by digits
+	strictDecimal                   // Non-negative decimal: an integer optionally followed by '.' and digits
+	strictObject                    // Object with the fields listed in children
+)
+
+// strictField describes one field of a protocol document.
+type strictField struct {
+	name     string
+	kind     strictKind
+	children []strictField
+}
+
+// mrc721StrictFields are the fields of MRC-721 inscriptions, see MRC721Protocol.
+var mrc721StrictFields = []strictField{
+	{name: "p", kind: strictText},
+	{name: "miner", kind: strictObject, children: []strictField{
+		{name: "name", kind: strictText},
+		{name: "max", kind: strictInteger},
+		{name: "lim", kind: strictInteger},
+	}},
+	{name: "token", kind: strictObject, children: []strictField{
+		{name: "tick", kind: strictText},
+		{name: "total", kind: strictInteger},
+		{name: "beg", kind: strictInteger},
+		{name: "halv", kind: strictInteger},
+		{name: "dcr", kind: strictDecimal},
+	}},
+	{name: "ltry", kind: strictObject, children: []strictField{
+		{name: "pool", kind: strictDecimal},
+		{name: "intvl", kind: strictInteger},
+		{name: "winp", kind: strictDecimal},
+		{name: "dist", kind: strictDecimal},
+		{name: "tiers", kind: strictText},
+		{name: "weight", kind: strictText},
+	}},
+	{name: "burn", kind: strictObject, children: []strictField{
+		{name: "unit", kind: strictInteger},
+		{name: "boost", kind: strictDecimal},
+	}},
+	{name: "stake", kind: strictObject, children: []strictField{
+		{name: "unit", kind: strictInteger},
+		{name: "boost", kind: strictDecimal},
+		{name: "lock", kind: strictInteger},
+	}},
+	{name: "fee", kind: strictObject, children: []strictField{
+		{name: "rate", kind: strictDecimal},
+		{name: "to", kind: strictText},
+	}},
+}
+
+// mrc20StrictFields are the fields of MRC-20 inscriptions, see MRC20Protocol.
+var mrc20StrictFields = []strictField{
+	{name: "p", kind: strictText},
+	{name: "op", kind: strictText},
+	{name: "tick", kind: strictText},
+	{name: "amt", kind: strictInteger},
+	{name: "dec", kind: strictInteger},
+	{name: "insc", kind: strictText},
+	{name: "to", kind: strictText},
+}
+
+var (
+	strictIntegerPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+	strictDecimalPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+	strictDigitsPattern  = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// ParseMRC721ProtocolStrict parses MRC-721 data after checking it with the strict parser and the latest rules.
+// A rejection is returned as a *StrictJSONError.
+func ParseMRC721ProtocolStrict(data []byte) (*MRC721Protocol, error) {
+	if err := checkStrictJSON(data, mrc721StrictFields, LatestRules().MaxProtocolJSONLength); err != nil {
+		return nil, err
+	}
+	return ParseMRC721Protocol(data)
+}
+
+// ParseMRC20ProtocolStrict parses MRC-20 data after checking it with the strict parser and the latest rules.
+// A rejection is returned as a *StrictJSONError.
+func ParseMRC20ProtocolStrict(data []byte) (*MRC20Protocol, error) {
+	if err := checkStrictJSON(data, mrc20StrictFields, LatestRules().MaxProtocolJSONLength); err != nil {
+		return nil, err
+	}
+	return ParseMRC20Protocol(data)
+}
+
+// checkStrict applies the strict parser to data when the rules require it.
+func (r *ProtocolRules) checkStrict(data []byte, fields []strictField) error {
+	if !r.StrictJSON {
+		return nil
+	}
+	return checkStrictJSON(data, fields, r.MaxProtocolJSONLength)
+}
+
+// checkStrictJSON accepts data only if it is a single JSON object, at most maxLength bytes of UTF-8, whose keys are
+// unique fields of the protocol and whose values are strings, numeric fields holding plain decimal numbers.
+// Whitespace is allowed around the document and between tokens only.
+func checkStrictJSON(data []byte, fields []strictField, maxLength int) error {
+	if len(data) > maxLength {
+		return &StrictJSONError{Reason: StrictTooLong, Detail: fmt.Sprintf("%d bytes, at most %d", len(data), maxLength)}
+	}
+	if !utf8.Valid(data) {
+		return &StrictJSONError{Reason: StrictInvalidUTF8, Detail: "content is not valid UTF-8"}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return &StrictJSONError{Reason: StrictSyntaxError, Detail: err.Error()}
+	}
+	if token != json.Delim('{') {
+		return &StrictJSONError{Reason: StrictNotObject, Detail: "document is not an object"}
+	}
+	if err := checkStrictObject(decoder, "", fields); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return &StrictJSONError{Reason: StrictTrailingData, Detail: "data after the document"}
+	}
+	return nil
+}
+
+// checkStrictObject checks the members of an object whose opening brace was read, up to its closing brace.
+func checkStrictObject(decoder *json.Decoder, path string, fields []strictField) error {
+	seen := make(map[string]bool)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return &StrictJSONError{Reason: StrictSyntaxError, Field: path, Detail: err.Error()}
+		}
+		key, _ := token.(string)
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		if seen[key] {
+			return &StrictJSONError{Reason: StrictDuplicateKey, Field: fieldPath, Detail: "key appears more than once"}
+		}
+		seen[key] = true
+
+		field := findStrictField(fields, key)
+		if field == nil {
+			return &StrictJSONError{Reason: StrictUnknownField, Field: fieldPath, Detail: "not a field of the protocol"}
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return &StrictJSONError{Reason: StrictSyntaxError, Field: fieldPath, Detail: err.Error()}
+		}
+		if field.kind == strictObject {
+			if token != json.Delim('{') {
+				return &StrictJSONError{Reason: StrictNotObject, Field: fieldPath, Detail: "field must hold an object"}
+			}
+			if err := checkStrictObject(decoder, fieldPath, field.children); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := token.(string)
+		if !ok {
+			return &StrictJSONError{Reason: StrictNotString, Field: fieldPath, Detail: "field must hold a string"}
+		}
+		if err := checkStrictValue(fieldPath, field.kind, value); err != nil {
+			return err
+		}
+	}
+
+	// Closing brace
+	if _, err := decoder.Token(); err != nil {
+		return &StrictJSONError{Reason: StrictSyntaxError, Field: path, Detail: err.Error()}
+	}
+	return nil
+}
+
+// checkStrictValue checks a string value against the kind of its field.
+func checkStrictValue(fieldPath string, kind strictKind, value string) error {
+	if strings.TrimSpace(value) != value {
+		return &StrictJSONError{Reason: StrictWhitespace, Field: fieldPath, Detail: "value starts or ends with whitespace"}
+	}
+	pattern := strictIntegerPattern
+	switch kind {
+	case strictText:
+		return nil
+	case strictDecimal:
+		pattern = strictDecimalPattern
+	}
+	if pattern.MatchString(value) {
+		return nil
+	}
+	if strictDigitsPattern.MatchString(value) && (kind == strictDecimal || !strings.Contains(value, ".")) {
+		return &StrictJSONError{Reason: StrictLeadingZero, Field: fieldPath, Detail: fmt.Sprintf("%q has a leading zero", value)}
+	}
+	return &StrictJSONError{Reason: StrictInvalidNumber, Field: fieldPath, Detail: fmt.Sprintf("%q is not a plain decimal number", value)}
+}
+
+// findStrictField returns the field named key, nil if there is none.
+func findStrictField(fields []strictField, key string) *strictField {
+	for i := range fields {
+		if fields[i].name == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
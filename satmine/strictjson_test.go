@@ -0,0 +1,145 @@
+package satmine
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCheckStrictJSON(t *testing.T) {
+	deploy := `{"p":"mrc-721","miner":{"name":"gamma","max":"100","lim":"5"},"token":{"tick":"gamm","total":"21000000","beg":"1000","halv":"10","dcr":"0.1"}}`
+	tests := []struct {
+		name   string
+		data   string
+		fields []strictField
+		reason string // Expected rejection, empty if the data is accepted
+		field  string
+	}{
+		{name: "deploy", data: deploy, fields: mrc721StrictFields},
+		{name: "surrounding whitespace", data: " \n" + deploy + "\n ", fields: mrc721StrictFields},
+		{name: "transfer", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"300"}`, fields: mrc20StrictFields},
+
+		// Duplicate keys
+		{name: "duplicate key", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"300","amt":"1"}`, fields: mrc20StrictFields, reason: StrictDuplicateKey, field: "amt"},
+		{name: "duplicate nested key", data: `{"p":"mrc-721","miner":{"name":"gamma","max":"100","max":"200","lim":"5"}}`, fields: mrc721StrictFields, reason: StrictDuplicateKey, field: "miner.max"},
+		{name: "duplicate object", data: `{"p":"mrc-721","miner":{"name":"gamma"},"miner":{"name":"delta"}}`, fields: mrc721StrictFields, reason: StrictDuplicateKey, field: "miner"},
+		{name: "same key in two objects", data: `{"p":"mrc-721","burn":{"unit":"10","boost":"0.5"},"stake":{"unit":"10","boost":"0.5","lock":"6"}}`, fields: mrc721StrictFields},
+
+		// Trailing data
+		{name: "second document", data: deploy + deploy, fields: mrc721StrictFields, reason: StrictTrailingData},
+		{name: "trailing text", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"300"}x`, fields: mrc20StrictFields, reason: StrictTrailingData},
+		{name: "trailing brace", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"300"}}`, fields: mrc20StrictFields, reason: StrictTrailingData},
+
+		// Non-string numbers
+		{name: "number", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":300}`, fields: mrc20StrictFields, reason: StrictNotString, field: "amt"},
+		{name: "nested number", data: `{"p":"mrc-721","token":{"tick":"gamm","total":21000000}}`, fields: mrc721StrictFields, reason: StrictNotString, field: "token.total"},
+		{name: "boolean", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":true}`, fields: mrc20StrictFields, reason: StrictNotString, field: "amt"},
+		{name: "null", data: `{"p":"mrc-20","op":"transfer","tick":null}`, fields: mrc20StrictFields, reason: StrictNotString, field: "tick"},
+		{name: "exponent", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"3e2"}`, fields: mrc20StrictFields, reason: StrictInvalidNumber, field: "amt"},
+		{name: "negative", data: `{"p":"mrc-721","token":{"beg":"-1"}}`, fields: mrc721StrictFields, reason: StrictInvalidNumber, field: "token.beg"},
+		{name: "decimal integer", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"1.5"}`, fields: mrc20StrictFields, reason: StrictInvalidNumber, field: "amt"},
+		{name: "leading zero", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"0300"}`, fields: mrc20StrictFields, reason: StrictLeadingZero, field: "amt"},
+		{name: "decimal leading zero", data: `{"p":"mrc-721","token":{"dcr":"00.1"}}`, fields: mrc721StrictFields, reason: StrictLeadingZero, field: "token.dcr"},
+		{name: "padded number", data: `{"p":"mrc-20","op":"transfer","tick":"gamm","amt":" 300"}`, fields: mrc20StrictFields, reason: StrictWhitespace, field: "amt"},
+
+		// Other rejections
+		{name: "too long", data: `{"p":"mrc-20","op":"` + strings.Repeat("x", 1024) + `"}`, fields: mrc20StrictFields, reason: StrictTooLong},
+		{name: "invalid utf8", data: "{\"p\":\"mrc-20\",\"op\":\"\xff\"}", fields: mrc20StrictFields, reason: StrictInvalidUTF8},
+		{name: "array", data: `["mrc-20"]`, fields: mrc20StrictFields, reason: StrictNotObject},
+		{name: "unknown field", data: `{"p":"mrc-20","Op":"transfer"}`, fields: mrc20StrictFields, reason: StrictUnknownField, field: "Op"},
+		{name: "syntax error", data: `{"p":"mrc-20",}`, fields: mrc20StrictFields, reason: StrictSyntaxError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkStrictJSON([]byte(test.data), test.fields, 1024)
+			if test.reason == "" {
+				if err != nil {
+					t.Fatalf("rejected: %v", err)
+				}
+				return
+			}
+			var strictErr *StrictJSONError
+			if !errors.As(err, &strictErr) {
+				t.Fatalf("got %v, want a %s rejection", err, test.reason)
+			}
+			if strictErr.Reason != test.reason || strictErr.Field != test.field {
+				t.Fatalf("got %s at %q, want %s at %q", strictErr.Reason, strictErr.Field, test.reason, test.field)
+			}
+		})
+	}
+}
+
+// The strict parser only applies under the rules that switch it on.
+func TestCheckStrictGate(t *testing.T) {
+	duplicate := []byte(`{"p":"mrc-20","op":"transfer","tick":"gamm","amt":"300","amt":"1"}`)
+	for network, table := range rulesTables {
+		for _, rules := range table {
+			err := rules.checkStrict(duplicate, mrc20StrictFields)
+			if rules.StrictJSON && err == nil {
+				t.Errorf("%s at %d: duplicate key accepted with StrictJSON", network, rules.ActivationHeight)
+			}
+			if !rules.StrictJSON && err != nil {
+				t.Errorf("%s at %d: rejected without StrictJSON: %v", network, rules.ActivationHeight, err)
+			}
+		}
+	}
+
+	strict := *LatestRules()
+	strict.StrictJSON = true
+	if err := strict.checkStrict(duplicate, mrc20StrictFields); err == nil {
+		t.Error("duplicate key accepted with StrictJSON")
+	}
+	strict.MaxProtocolJSONLength = 10
+	var strictErr *StrictJSONError
+	if err := strict.checkStrict([]byte(`{"p":"mrc-20"}`), mrc20StrictFields); !errors.As(err, &strictErr) || strictErr.Reason != StrictTooLong {
+		t.Errorf("got %v, want a %s rejection under the length of the rules", err, StrictTooLong)
+	}
+}
+
+// A duplicated key is indexed until the rules switching the strict parser on activate, and rejected from their height.
+func TestStrictJSONActivation(t *testing.T) {
+	base := *rulesTables[Mainnet][0]
+	strict := base
+	strict.ActivationHeight = 800002
+	strict.StrictJSON = true
+	rulesTables["strict-test"] = []*ProtocolRules{&base, &strict}
+	previous := ActiveNetwork()
+	t.Cleanup(func() {
+		SetNetwork(string(previous))
+		delete(rulesTables, "strict-test")
+	})
+	if err := SetNetwork("strict-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	b := openTestIndex(t)
+	deploy := func(name string) []byte {
+		return []byte(`{"p":"mrc-721","p":"mrc-721","miner":{"name":"` + name + `","max":"100","lim":"5"},"token":{"tick":"` + name + `","total":"21000000","beg":"1000","halv":"10","dcr":"0.1"}}`)
+	}
+	for i, name := range []string{"alfa", "beta", "gamm"} {
+		height := 800000 + i
+		block := &HookBlock{
+			BlockHeight:  strconv.Itoa(height),
+			BlockHash:    "hash" + strconv.Itoa(height),
+			Timestamp:    int64(1700000000 + 600*i),
+			Inscriptions: []HookInscription{testInscription(i+1, height, "bc1qdeployer", deploy(name))},
+		}
+		if err := b.WriteBlock(block); err != nil {
+			t.Fatalf("block %d: %v", height, err)
+		}
+	}
+
+	for id, want := range map[string]string{"test1i0": VerdictAccepted, "test2i0": VerdictAccepted, "test3i0": VerdictRejected} {
+		verdict, err := b.GetInscriptionVerdict(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verdict.Status != want {
+			t.Errorf("%s at %s is %s (%s), want %s", id, verdict.BlockHeight, verdict.Status, verdict.Reason, want)
+		}
+		if want == VerdictRejected && verdict.Reason != StrictDuplicateKey {
+			t.Errorf("%s is rejected for %s, want %s", id, verdict.Reason, StrictDuplicateKey)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// filePath: satmine/verdicts.go
+
+package satmine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Verdict statuses of a protocol inscription.
+const (
+	VerdictAccepted = "accepted" // The inscription took effect
+	VerdictRejected = "rejected" // The inscription breaks a protocol rule and had no effect
+	VerdictIgnored  = "ignored"  // The inscription refers to a token or collection this index does not know
+)
+
+// Verdict reasons, machine readable. Rejections by the strict JSON parser use the Strict* reasons instead.
+const (
+	ReasonInvalidData         = "invalid_data"         // The content fails the validation of its protocol
+	ReasonParseError          = "parse_error"          // The content could not be parsed
+	ReasonUnknownTick         = "unknown_tick"         // No collection mines the MRC-20 tick
+	ReasonUnknownCollection   = "unknown_collection"   // No collection has the MRC-721 name
+	ReasonTickTaken           = "tick_taken"           // A new collection uses the tick of an existing one
+	ReasonGenesisMismatch     = "genesis_mismatch"     // The miner differs from the genesis inscription of its collection
+	ReasonCollectionFull      = "collection_full"      // The collection reached its maximum number of miners
+	ReasonAddressLimit        = "address_limit"        // The address reached the number of miners it may inscribe
+	ReasonInsufficientBalance = "insufficient_balance" // The address holds less than the amount
+	ReasonMissingTarget       = "missing_target"       // A burn or a stake does not name the inscription it boosts
+	ReasonInvalidTarget       = "invalid_target"       // A burn or a stake names an inscription that is not a miner of the collection emitting the tick
+	ReasonStakeDisabled       = "stake_disabled"       // The collection emitting the tick has no stake section
+	ReasonInsufficientStake   = "insufficient_stake"   // The address staked less than the amount of the tick on the inscription
+	ReasonStakeLocked         = "stake_locked"         // The stake cannot be withdrawn before its unlock height
+	ReasonNotOwner            = "not_owner"            // A delegation is inscribed by another address than the owner of the miner
+)
+
+// InscriptionVerdict records what became of a protocol inscription when it was indexed.
+type InscriptionVerdict struct {
+	InscriptionID string `json:"inscription_id"`
+	BlockHeight   string `json:"block_height"`
+	Protocol      string `json:"protocol"`         // Protocol type of the content, e.g. "mrc-20"
+	Status        string `json:"status"`           // One of the Verdict* statuses
+	Reason        string `json:"reason,omitempty"` // Why the inscription was rejected or ignored
+	Detail        string `json:"detail,omitempty"` // Human readable detail
+}
+
+// beginVerdict starts the verdict of an inscription about to be applied, accepted until a rule says otherwise.
+func (b *BTOrdIdx) beginVerdict(block *HookBlock, inscr *HookInscription, protocol string) {
+	b.verdict = &InscriptionVerdict{
+		InscriptionID: inscr.ID,
+		BlockHeight:   block.BlockHeight,
+		Protocol:      protocol,
+		Status:        VerdictAccepted,
+	}
+}
+
+// rejectInscription marks the inscription being applied as rejected, the first reason given wins.
+func (b *BTOrdIdx) rejectInscription(reason, detail string) {
+	b.judgeInscription(VerdictRejected, reason, detail)
+}
+
+// ignoreInscription marks the inscription being applied as ignored, the first reason given wins.
+func (b *BTOrdIdx) ignoreInscription(reason, detail string) {
+	b.judgeInscription(VerdictIgnored, reason, detail)
+}
+
+// rejectInvalidInscription rejects the inscription being applied for failing the validation of its protocol.
+func (b *BTOrdIdx) rejectInvalidInscription(err error) {
+	var strictErr *StrictJSONError
+	if errors.As(err, &strictErr) {
+		b.rejectInscription(strictErr.Reason, err.Error())
+		return
+	}
+	b.rejectInscription(ReasonInvalidData, err.Error())
+}
+
+// judgeInscription sets the status of the verdict being built, if any.
+func (b *BTOrdIdx) judgeInscription(status, reason, detail string) {
+	if b.verdict == nil || b.verdict.Status != VerdictAccepted {
+		return
+	}
+	b.verdict.Status = status
+	b.verdict.Reason = reason
+	b.verdict.Detail = detail
+}
+
+// endVerdict stores the verdict being built.
+// verdict::[inscription_id] -> InscriptionVerdict
+func (b *BTOrdIdx) endVerdict(txn *badger.Txn) error {
+	verdict := b.verdict
+	b.verdict = nil
+	if verdict == nil {
+		return nil
+	}
+	verdictJSON, err := jsoniter.Marshal(verdict)
+	if err != nil {
+		return err
+	}
+	return setState(txn, []byte("verdict::"+verdict.InscriptionID), verdictJSON)
+}
+
+// GetInscriptionVerdict returns the verdict of a protocol inscription.
+func (b *BTOrdIdx) GetInscriptionVerdict(inscriptionID string) (InscriptionVerdict, error) {
+	b.rwLock.RLock()
+	defer b.rwLock.RUnlock()
+
+	var verdict InscriptionVerdict
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("verdict::" + strings.TrimSpace(inscriptionID)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return jsoniter.Unmarshal(val, &verdict)
+		})
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return InscriptionVerdict{}, fmt.Errorf("no verdict for inscription %s: %w", inscriptionID, err)
+		}
+		return InscriptionVerdict{}, err
+	}
+	return verdict, nil
+}
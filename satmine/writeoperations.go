@@ -31,96 +31,45 @@ func (b *BTOrdIdx) addInscriptionList(txn *badger.Txn, block *HookBlock) (err er
 			return err
 		}
 		// Serialize the inscription to JSON using jsoniter
-		inscriptionJSON, err := jsoniter.Marshal(inscription)
+		inscriptionJSON, err := marshalInscription(txn, &inscription)
 		if err != nil {
 			logger.Error("Failed to marshal inscription: ", zap.Error(err))
 			return err
 		}
 		// Write the serialized inscription to the database
-		if err := txn.Set([]byte(inscriptionKey), inscriptionJSON); err != nil {
+		if err := setState(txn, []byte(inscriptionKey), inscriptionJSON); err != nil {
 			logger.Error("Failed to write inscription to database: ", zap.Error(err))
 			return err
 		}
 
 		// inscr::number::[inscription.Number] -> [inscription.ID]
 		inscriptionNumberKey := fmt.Sprintf("inscr::number::%d", inscription.Number)
-		if err := txn.Set([]byte(inscriptionNumberKey), []byte(inscription.ID)); err != nil {
+		if err := setState(txn, []byte(inscriptionNumberKey), []byte(inscription.ID)); err != nil {
 			logger.Error("Failed to write inscription number to database: ", zap.Error(err))
 			return err
 		}
 
 		// Check if ContentByte is not nil
 		if inscription.ContentByte != nil {
-			// Use ValidateProtocolData to check the data type (mrc-721 or mrc-20)
-			isValid, protocolType, err := ValidateProtocolData(*inscription.ContentByte)
-			//fmt.Println("---ValidateProtocolData protocolType=", isValid, protocolType, err)
+			// Hand the content to the first registered protocol handler detecting it
+			handler := detectProtocolHandler(*inscription.ContentByte)
+			if handler == nil {
+				continue // Not an inscription of a known protocol
+			}
 
-			if err != nil {
+			// Validate under the rules of the block
+			b.beginVerdict(block, &inscription, handler.Name())
+			if err := handler.Validate(*inscription.ContentByte, b.registry.rules); err != nil {
 				//logger.Error("Failed to validate protocol data: ", zap.Error(err))
-				continue // Skip to next inscription on error
+				b.rejectInvalidInscription(err)
+			} else if err := handler.Apply(b, txn, block, &inscription, b.registry.rules); err != nil {
+				return err
 			}
 
-			if isValid {
-				// Log the identified protocol type
-				//logger.Info("Valid protocol data identified: ", zap.String("protocolType", protocolType))
-
-				// Depending on the protocol type, call the respective parsing function
-				switch protocolType {
-				case "mrc-721":
-					mrc721Data, err := ParseMRC721Protocol(*inscription.ContentByte)
-					if err != nil {
-						logger.Info("Failed to parse MRC-721 data: ", zap.Error(err))
-					} else {
-						logger.Info("Parsed MRC-721 Data: ", zap.Reflect("mrc721Data", mrc721Data))
-						err := b.writeMrc721(txn, block, &inscription, mrc721Data)
-						if err != nil {
-							logger.Info("Failed to write MRC-721 data: ", zap.Error(err))
-							return err
-						}
-					}
-				case "mrc-721html":
-					mrc721Data, err := ParseMRC721HtmlProtocol(txn, *inscription.ContentByte)
-					if err != nil {
-						logger.Info("Failed to parse 721html data: ", zap.Error(err))
-					} else {
-						//logger.Info("Parsed MRC-721html Data: ", zap.Reflect("mrc721Data", mrc721Data))
-						err := b.writeMrc721(txn, block, &inscription, mrc721Data)
-						if err != nil {
-							logger.Info("Failed to write MRC-721html data: ", zap.Error(err))
-							return err
-						}
-					}
-				case "mrc-721svg":
-					mrc721Data, err := ParseMRC721SvgProtocol(txn, *inscription.ContentByte)
-					if err != nil {
-						logger.Info("Failed to parse 721svg data: ", zap.Error(err))
-					} else {
-						//logger.Info("Parsed MRC-721svg Data: ", zap.Reflect("mrc721Data", mrc721Data))
-						err := b.writeMrc721(txn, block, &inscription, mrc721Data)
-						if err != nil {
-							logger.Info("Failed to write MRC-721svg data: ", zap.Error(err))
-							return err
-						}
-					}
-
-				case "mrc-20":
-					mrc20Data, err := ParseMRC20Protocol(*inscription.ContentByte)
-					//fmt.Println("ParseMRC20Protocol =", block.BlockHeight, mrc20Data)
-					if err != nil {
-						logger.Info("Failed to parse MRC-20 data: ", zap.Error(err))
-					} else {
-						logger.Info("Parsed MRC-20 Data: ", zap.Reflect("mrc20Data", mrc20Data))
-						err := b.writeMrc20(txn, block, &inscription, mrc20Data)
-						if err != nil {
-							logger.Info("Failed to write MRC-20 data: ", zap.Error(err))
-							return err
-						}
-					}
-				default:
-					logger.Info("Unknown protocol type")
-				}
-			} else {
-				logger.Info("Invalid protocol data")
+			// Keep what became of the inscription, for the users who inscribed it
+			if err := b.endVerdict(txn); err != nil {
+				logger.Error("Failed to write inscription verdict: ", zap.Error(err))
+				return err
 			}
 		}
 	}
@@ -130,22 +79,20 @@ func (b *BTOrdIdx) addInscriptionList(txn *badger.Txn, block *HookBlock) (err er
 
 // Writing MRC-721 inscriptions, can only be used within Badger's Update operation.
 func (b *BTOrdIdx) writeMrc721(txn *badger.Txn, block *HookBlock, inscr *HookInscription, mrc721Data *MRC721Protocol) (err error) {
-	// Construct the key using the inscription ID
-
-	// mrc721::geninsc::[inscription_name]  -> inscription_id
-	geninsc_key := fmt.Sprintf("mrc721::geninsc::%s", mrc721Data.Miner.GetUpperName())
+	// mrc721::geninsc::[inscription_name]  -> Mrc721GenesisData
+	mrc721Name := mrc721Data.Miner.GetUpperName()
 	geninsc20_key := fmt.Sprintf("mrc20::geninsc::%s", mrc721Data.Token.GetLowerTick())
-	// Search for the key in the transaction
-	item, err := txn.Get([]byte(geninsc_key))
+	// Look the collection up in the registry
+	collection, err := b.mrc721Collection(txn, mrc721Name)
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
 			// Check if the MRC20 genesis inscription key exists
-			mrc20GenInscKey := fmt.Sprintf("mrc20::geninsc::%s", mrc721Data.Token.GetLowerTick())
-			_, mrc20Err := txn.Get([]byte(mrc20GenInscKey))
+			_, mrc20Err := txn.Get([]byte(geninsc20_key))
 
 			// If the MRC20 genesis inscription key exists, log the information and return nil
 			if mrc20Err == nil {
-				logger.Info("MRC20 genesis inscription key already exists", zap.String("key", mrc20GenInscKey))
+				logger.Info("MRC20 genesis inscription key already exists", zap.String("key", geninsc20_key))
+				b.rejectInscription(ReasonTickTaken, "tick "+mrc721Data.Token.GetLowerTick()+" is mined by another collection")
 				return nil
 			}
 
@@ -159,7 +106,7 @@ func (b *BTOrdIdx) writeMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIns
 			mrc721GenesisInscription := Mrc721GenesisData{
 				ID:                   inscr.ID,
 				Number:               inscr.Number,
-				Name:                 mrc721Data.Miner.GetUpperName(),
+				Name:                 mrc721Name,
 				PrevName:             mrc721Data.Miner.Name,
 				BlockHeight:          block.BlockHeight,
 				GenesisAddress:       inscr.Address,
@@ -176,21 +123,14 @@ func (b *BTOrdIdx) writeMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIns
 				TotalBurn:            "0",
 			}
 
-			// Serialize Mrc721GenesisData to JSON
-			mrc721GenesisInscriptionJSON, err := jsoniter.Marshal(mrc721GenesisInscription)
-			if err != nil {
-				logger.Error("Failed to marshal MRC-721 genesis inscription: ", zap.Error(err))
-				return err
-			}
-
-			// Set the new value in the database
-			err = txn.Set([]byte(geninsc_key), mrc721GenesisInscriptionJSON)
+			// Write the new genesis data and register the collection
+			err = b.putMrc721Genesis(txn, &mrc721GenesisInscription, mrc721Data)
 			if err != nil {
 				logger.Error("Error setting new MRC-721 genesis inscription: ", zap.Error(err))
 				return err
 			}
 			// Set the new value in the database
-			err = txn.Set([]byte(geninsc20_key), []byte(mrc721Data.Miner.GetUpperName()))
+			err = setState(txn, []byte(geninsc20_key), []byte(mrc721Name))
 			if err != nil {
 				logger.Error("Error setting new MRC-20 genesis inscription: ", zap.Error(err))
 				return err
@@ -208,115 +148,50 @@ func (b *BTOrdIdx) writeMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIns
 			return err
 		}
 	} else {
-		// Key found, update the existing Mrc721GenesisData
-		var genesisData Mrc721GenesisData
-		err := item.Value(func(val []byte) error {
-			return jsoniter.Unmarshal(val, &genesisData)
-		})
-		if err != nil {
-			logger.Error("Failed to unmarshal existing MRC-721 genesis inscription: ", zap.Error(err))
-			return err
-		}
-
-		// Read the existing HookInscription using the key 'inscr::genesisData.ID'
-		existingInscrKey := fmt.Sprintf("inscr::%s", genesisData.ID)
-		var existingHookInscription HookInscription
-		item, err = txn.Get([]byte(existingInscrKey))
-		if err != nil {
-			if err != badger.ErrKeyNotFound {
-				// If there's an error other than key not found, log it and return
-				logger.Error("Error reading existing HookInscription: ", zap.Error(err))
-				return err
-			}
-			// If the key is not found, proceed without incrementing the count
-			logger.Info("Existing HookInscription not found, proceeding without incrementing count")
-			return err
+		// Collection found, update a copy of the existing Mrc721GenesisData
+		genesisData := collection.Genesis
+		firstMrc721 := collection.Protocol
+
+		// Compare the current inscription with the genesis protocol
+		if !isEqual721Inscription(genesisData.ID, firstMrc721, inscr, b.registry.rules) {
+			// If the data is not equal, log a message and proceed
+			logger.Info("Existing and current HookInscription data are not identical, proceeding")
+			logger.Info("existingHookInscription.ID=" + genesisData.ID + " inscr.ID=" + inscr.ID)
+			b.rejectInscription(ReasonGenesisMismatch, "inscription differs from the genesis inscription "+genesisData.ID)
 		} else {
-			// Unmarshal the existing HookInscription
-			err = item.Value(func(val []byte) error {
-				return jsoniter.Unmarshal(val, &existingHookInscription)
-			})
+			// Compare firstMrc721.Miner.Max with genesisData.InscriptionsCount
+			maxInscriptions, err := strconv.Atoi(firstMrc721.Miner.Max)
 			if err != nil {
-				logger.Error("Failed to unmarshal existing HookInscription: ", zap.Error(err))
+				logger.Error("Failed to convert firstMrc721.Miner.Max to integer: ", zap.Error(err))
 				return err
 			}
+			// Check if the maximum number of inscriptions specified by the miner is less than the current inscriptions count
+			if genesisData.InscriptionsCount >= maxInscriptions {
+				logger.Error("Max inscriptions limit exceeded", zap.String("firstMrc721.Miner.Name", firstMrc721.Miner.Name))
+				b.rejectInscription(ReasonCollectionFull, fmt.Sprintf("collection %s has its %d miners", mrc721Name, maxInscriptions))
+				return nil
+			}
 
-			// Compare the content byte using IsEqual721Data function
-			//if !IsEqual721Data(existingHookInscription.ContentByte, inscr.ContentByte) {
-			if !IsEqual721Data(&existingHookInscription, inscr) {
-				// If the data is not equal, log a message and proceed
-				logger.Info("Existing and current HookInscription data are not identical, proceeding")
-				logger.Info("existingHookInscription.ID=" + existingHookInscription.ID + " inscr.ID=" + inscr.ID)
-			} else {
-
-				// Retrieve HookInscription using the key 'inscr::genesisData.ID'
-				inscrKey := fmt.Sprintf("inscr::%s", genesisData.ID)
-				hookInscrItem, err := txn.Get([]byte(inscrKey))
-				if err != nil {
-					logger.Error("Error retrieving HookInscription: ", zap.Error(err))
-					return err
-				}
-
-				// Decode the HookInscription
-				var hookInscription HookInscription
-				err = hookInscrItem.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
-				})
-				if err != nil {
-					logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
-					return err
-				}
-
-				// Parse the MRC721 protocol data from ContentByte
-				firstMrc721, err := ParseMRC721Protocol(*hookInscription.ContentByte)
-				if err != nil {
-					logger.Error("Failed to parse MRC721 protocol: ", zap.Error(err))
-					return err
-				}
-				// Compare firstMrc721.Miner.Max with genesisData.InscriptionsCount
-				maxInscriptions, err := strconv.Atoi(firstMrc721.Miner.Max)
-				if err != nil {
-					logger.Error("Failed to convert firstMrc721.Miner.Max to integer: ", zap.Error(err))
-					return err
-				}
-				// Check if the maximum number of inscriptions specified by the miner is less than the current inscriptions count
-				if genesisData.InscriptionsCount >= maxInscriptions {
-					logger.Error("Max inscriptions limit exceeded", zap.String("firstMrc721.Miner.Name", firstMrc721.Miner.Name))
-					return nil
-				}
-
-				// this add code..
-
-				err = b.addNewMrc721(txn, block, inscr, mrc721Data, genesisData.InscriptionsCount)
-				if err != nil {
-					logger.Error("Error addNewMrc721 MRC-721 genesis inscription: ", zap.Error(err))
-					return nil
-				}
-
-				genesisData.EndID = inscr.ID
-				genesisData.EndBlockHeight = block.BlockHeight
-				genesisData.EndTimestamp = block.Timestamp
+			err = b.addNewMrc721(txn, block, inscr, mrc721Data, genesisData.InscriptionsCount)
+			if err != nil {
+				logger.Error("Error addNewMrc721 MRC-721 genesis inscription: ", zap.Error(err))
+				return nil
+			}
 
-				// Increment the inscriptions count if data is identical
-				genesisData.InscriptionsCount++
+			genesisData.EndID = inscr.ID
+			genesisData.EndBlockHeight = block.BlockHeight
+			genesisData.EndTimestamp = block.Timestamp
 
-				// Serialize the updated Mrc721GenesisData
-				updatedInscriptionJSON, err := jsoniter.Marshal(genesisData)
-				if err != nil {
-					logger.Error("Failed to marshal updated MRC-721 genesis inscription: ", zap.Error(err))
-					return err
-				}
-
-				// Update the value in the database
-				err = txn.Set([]byte(geninsc_key), updatedInscriptionJSON)
-				if err != nil {
-					logger.Error("Error updating MRC-721 genesis inscription: ", zap.Error(err))
-					return err
-				}
+			// Increment the inscriptions count if data is identical
+			genesisData.InscriptionsCount++
 
+			// Update the genesis data in the database and the registry
+			err = b.putMrc721Genesis(txn, &genesisData, nil)
+			if err != nil {
+				logger.Error("Error updating MRC-721 genesis inscription: ", zap.Error(err))
+				return err
 			}
 		}
-
 	}
 
 	return nil
@@ -332,7 +207,7 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
 			// Key not found, create a new entry with count 1
-			err = txn.Set([]byte(addrNumKey), []byte("1"))
+			err = setState(txn, []byte(addrNumKey), []byte("1"))
 			if err != nil {
 				logger.Error("Error setting new inscription count: ", zap.Error(err))
 				return err
@@ -365,13 +240,14 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 		if count < maxInscriptions {
 			// Increment the count and update the database
 			count++
-			err = txn.Set([]byte(addrNumKey), []byte(strconv.Itoa(count)))
+			err = setState(txn, []byte(addrNumKey), []byte(strconv.Itoa(count)))
 			if err != nil {
 				logger.Error("Error updating inscription count: ", zap.Error(err))
 				return err
 			}
 		} else {
 			// Limit reached, do not increment
+			b.rejectInscription(ReasonAddressLimit, fmt.Sprintf("address %s inscribed its %d miners", inscr.Address, maxInscriptions))
 			return errors.New("inscription limit reached")
 		}
 	}
@@ -380,7 +256,7 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	// mrc721::name_inscr::[mrc721_name]::[inscription_id] -> nil
 	// This key maps the MRC-721 name to the inscription ID, used for storing a particular name with a lot of inscriptions underneath it.
 	keyNameAddr := fmt.Sprintf("mrc721::name_inscr::%s::%s", mrc721Data.Miner.GetUpperName(), inscr.ID)
-	if err := txn.Set([]byte(keyNameAddr), nil); err != nil {
+	if err := setState(txn, []byte(keyNameAddr), nil); err != nil {
 		logger.Error("Failed to write name to inscription mapping: ", zap.Error(err))
 		return err
 	}
@@ -388,7 +264,7 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	// mrc721::addr_inscr::[user_addr]::[inscription_id] -> nil
 	// This key maps the user address to the inscription ID, used for storing all inscriptions owned by a user.
 	keyAddrInscr := fmt.Sprintf("mrc721::addr_inscr::%s::%s", inscr.Address, inscr.ID)
-	if err := txn.Set([]byte(keyAddrInscr), nil); err != nil {
+	if err := setState(txn, []byte(keyAddrInscr), nil); err != nil {
 		logger.Error("Failed to write address to inscription mapping: ", zap.Error(err))
 		return err
 	}
@@ -396,7 +272,7 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	// mrc721::inscr_addr::[inscription_id]::[user_addr] -> nil
 	// This key maps the inscription ID to the user address.
 	keyInscrAddr := fmt.Sprintf("mrc721::inscr_addr::%s::%s", inscr.ID, inscr.Address)
-	if err := txn.Set([]byte(keyInscrAddr), nil); err != nil {
+	if err := setState(txn, []byte(keyInscrAddr), nil); err != nil {
 		logger.Error("Failed to write inscription to address mapping: ", zap.Error(err))
 		return err
 	}
@@ -404,7 +280,7 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	// New key for mapping MRC-721 series count to inscription ID
 	// Format: mrc721::count_inscr::[mrc721_name]::[mrc721_count] -> inscription_id
 	keyCountInscr := fmt.Sprintf("mrc721::count_inscr::%s::%d", mrc721Data.Miner.GetUpperName(), mrc721Count)
-	if err := txn.Set([]byte(keyCountInscr), []byte(inscr.ID)); err != nil {
+	if err := setState(txn, []byte(keyCountInscr), []byte(inscr.ID)); err != nil {
 		logger.Error("Failed to write series count to inscription mapping: ", zap.Error(err))
 		return err
 	}
@@ -412,11 +288,17 @@ func (b *BTOrdIdx) addNewMrc721(txn *badger.Txn, block *HookBlock, inscr *HookIn
 	// Format: mrc721::count_inscr::[mrc721_name]::[inscription_id] -> mrc721_count
 	keyInscrCount := fmt.Sprintf("mrc721::inscr_count::%s::%s", mrc721Data.Miner.GetUpperName(), inscr.ID)
 	mrc721CountStr := strconv.Itoa(mrc721Count)
-	if err := txn.Set([]byte(keyInscrCount), []byte(mrc721CountStr)); err != nil {
+	if err := setState(txn, []byte(keyInscrCount), []byte(mrc721CountStr)); err != nil {
 		logger.Error("Failed to write  mrc721Count", zap.Error(err))
 		return err
 	}
 
+	// The new inscription mines from the current block on
+	if err := b.joinRewardPool(txn, mrc721Data.Miner.GetUpperName(), inscr); err != nil {
+		logger.Error("Failed to join the reward pool: ", zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
@@ -433,7 +315,13 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 			toAddress = transferItem.ToAddress
 		} else if transferItem.Type == "burnt" {
 			// Default processing to black hole address after combustion
-			toAddress = "1BitcoinEaterAddressDontSendf59kuE"
+			toAddress = burnAddress
+		} else if transferItem.Type == "spent_in_fees" {
+			// The inscription stays with its inscriber until its next move
+			if err := markMrc20TransferInTransit(txn, block, transferItem.ID); err != nil {
+				return fmt.Errorf("error recording MRC-20 transfer in transit: %w", err)
+			}
+			continue
 		} else {
 			// Consider removing the error when formalizing your use here
 			logger.Info(fmt.Sprintf("unknown transfer type %s %s", transferItem.Type, transferItem.ToAddress))
@@ -465,8 +353,18 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 				//old address not found"
 				//logger.Info(fmt.Sprintf("Key not found for transfer ID: %s from address: %s", transferItem.ID, toAddress))
 			} else {
+				// Rewards mined so far belong to the previous owner
+				if err := b.transferMiner(txn, transferItem.ID, toAddress); err != nil {
+					return fmt.Errorf("error settling miner rewards: %w", err)
+				}
+
+				// A delegation ends when the miner changes hands
+				if err := revokeMinerDelegation(txn, transferItem.ID); err != nil {
+					return fmt.Errorf("error revoking miner delegation: %w", err)
+				}
+
 				// Delete old key-value pair
-				err = txn.Delete([]byte(oldKey))
+				err = deleteState(txn, []byte(oldKey))
 				if err != nil {
 					return fmt.Errorf("error deleting old key: %w", err)
 				}
@@ -474,7 +372,7 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 
 				// Add new key-value pair
 				newKey := fmt.Sprintf("mrc721::inscr_addr::%s::%s", transferItem.ID, toAddress)
-				err = txn.Set([]byte(newKey), nil)
+				err = setState(txn, []byte(newKey), nil)
 				if err != nil {
 					return fmt.Errorf("error setting new key: %w", err)
 				}
@@ -490,14 +388,14 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 				}
 
 				// Delete old key-value pair
-				err = txn.Delete(oldKeyValue)
+				err = deleteState(txn, oldKeyValue)
 				if err != nil {
 					return fmt.Errorf("error deleting old key-value pair: %w", err)
 				}
 
 				// Add new key-value pair
 				newKeyValue := fmt.Sprintf("mrc721::addr_inscr::%s::%s", toAddress, transferItem.ID)
-				err = txn.Set([]byte(newKeyValue), nil)
+				err = setState(txn, []byte(newKeyValue), nil)
 				if err != nil {
 					return fmt.Errorf("error setting new key-value pair: %w", err)
 				}
@@ -511,7 +409,7 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 
 				var hookInscription HookInscription
 				err = item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &hookInscription)
+					return unmarshalInscriptionMeta(val, &hookInscription)
 				})
 				if err != nil {
 					return fmt.Errorf("error unmarshalling HookInscription: %w", err)
@@ -521,11 +419,11 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 				hookInscription.Address = toAddress
 
 				// Marshal the updated HookInscription and write it back to the database.
-				updatedInscrBytes, err := jsoniter.Marshal(hookInscription)
+				updatedInscrBytes, err := marshalInscription(txn, &hookInscription)
 				if err != nil {
 					return fmt.Errorf("error marshalling updated HookInscription: %w", err)
 				}
-				err = txn.Set([]byte(inscrKey), updatedInscrBytes)
+				err = setState(txn, []byte(inscrKey), updatedInscrBytes)
 				if err != nil {
 					return fmt.Errorf("error writing updated HookInscription back to database: %w", err)
 				}
@@ -568,7 +466,7 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 
 				var mrc20Inscription HookInscription
 				err = item.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &mrc20Inscription)
+					return unmarshalInscription(txn, val, &mrc20Inscription)
 				})
 				if err != nil {
 					return err
@@ -592,8 +490,8 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 					}
 				} else {
 					err = item.Value(func(val []byte) error {
-						currentBalance = new(big.Int)
-						return currentBalance.UnmarshalText(val)
+						currentBalance = parseBalance(val)
+						return nil
 					})
 					if err != nil {
 						return err
@@ -608,7 +506,7 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 				//fmt.Println("addTransferList newBalance=", newBalance)
 				//fmt.Println("addTransferList balanceKey=", balanceKey)
 				balanceBytes := newBalance.Text(10)
-				err = txn.Set([]byte(balanceKey), []byte(balanceBytes))
+				err = setState(txn, []byte(balanceKey), []byte(balanceBytes))
 				if err != nil {
 					return err
 				}
@@ -620,11 +518,17 @@ func (b *BTOrdIdx) addTransferList(txn *badger.Txn, block *HookBlock) (err error
 					oldKey,
 				}
 				for _, key := range keysToDelete {
-					err := txn.Delete([]byte(key))
+					err := deleteState(txn, []byte(key))
 					if err != nil {
 						return fmt.Errorf("error deleting key %s: %v", key, err)
 					}
 				}
+
+				// The transfer is over, record where the amount went
+				err = moveMrc20Transfer(txn, block, transferItem.ID, oldAddr, mrc20Data, settledTransferState(oldAddr, toAddress), toAddress)
+				if err != nil {
+					return fmt.Errorf("error recording MRC-20 transfer: %w", err)
+				}
 			}
 		}
 	}
@@ -642,6 +546,7 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		_, err := txn.Get([]byte(mrc721nameKey))
 		if err != nil {
 			fmt.Println("Token does not exist")
+			b.ignoreInscription(ReasonUnknownTick, "no collection mines "+mrc20Data.Tick)
 			return nil // Token does not exist, no error, stop execution
 		}
 		// var mrc721name string
@@ -653,6 +558,11 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		// 	return err
 		// }
 
+		// Pending mining rewards are part of the spendable balance
+		if err := b.settleAddressMiners(txn, inscr.Address, mrc20Data.Tick); err != nil {
+			return err
+		}
+
 		// Retrieve the balance for the address and convert it to a big.Int
 		balanceKey := "mrc20::balance::" + inscr.Address + "::" + mrc20Data.Tick
 
@@ -660,7 +570,8 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		item, err := txn.Get([]byte(balanceKey))
 		if err != nil {
 			fmt.Println("Error retrieving balance")
-			return nil
+			b.rejectInscription(ReasonInsufficientBalance, "address holds no "+mrc20Data.Tick)
+			return startMrc20Transfer(txn, block, inscr, mrc20Data, TransferRejectedInsufficient)
 		}
 		//var balanceBigInt *big.Int
 		balanceBigInt := new(big.Int)
@@ -680,7 +591,8 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		amountBigInt.SetString(mrc20Data.Amt, 10) // Assuming mrc20Data.Amt is a base 10 string
 		if amountBigInt.Cmp(balanceBigInt) > 0 {
 			//fmt.Println("Insufficient balance for the transaction")
-			return nil // Balance is less than amount, no error, stop execution
+			b.rejectInscription(ReasonInsufficientBalance, fmt.Sprintf("balance %s is less than %s", balanceBigInt, amountBigInt))
+			return startMrc20Transfer(txn, block, inscr, mrc20Data, TransferRejectedInsufficient) // Balance is less than amount, no error, stop execution
 		}
 
 		//fmt.Println("writeMrc20 inscr=", inscr.ID)
@@ -688,8 +600,8 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		//fmt.Println("writeMrc20 balanceBigInt=", balanceBigInt.String())
 		// Update the balance and write back to the database
 		newBalanceBigInt := new(big.Int).Sub(balanceBigInt, amountBigInt)
-		//err = txn.Set([]byte(balanceKey), newBalanceBigInt.Bytes())
-		err = txn.Set([]byte(balanceKey), []byte(newBalanceBigInt.String()))
+		//err = setState(txn, []byte(balanceKey), newBalanceBigInt.Bytes())
+		err = setState(txn, []byte(balanceKey), []byte(newBalanceBigInt.String()))
 		if err != nil {
 			return err
 		}
@@ -697,22 +609,22 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		//fmt.Println("writeMrc20 newBalanceBigInt=", newBalanceBigInt.String())
 
 		// Write the additional key-value pairs as required
-		err = txn.Set([]byte("mrc20::name_inscr::"+mrc20Data.Tick+"::"+inscr.ID), nil)
+		err = setState(txn, []byte("mrc20::name_inscr::"+mrc20Data.Tick+"::"+inscr.ID), nil)
 		if err != nil {
 			return err
 		}
-		err = txn.Set([]byte("mrc20::addr_inscr::"+inscr.Address+"::"+inscr.ID), nil)
+		err = setState(txn, []byte("mrc20::addr_inscr::"+inscr.Address+"::"+inscr.ID), nil)
 		if err != nil {
 			return err
 		}
-		err = txn.Set([]byte("mrc20::inscr_addr::"+inscr.ID+"::"+inscr.Address), nil)
+		err = setState(txn, []byte("mrc20::inscr_addr::"+inscr.ID+"::"+inscr.Address), nil)
 		if err != nil {
 			return err
 		}
 
 		// Serialize the HookInscription instance to JSON.
 		// This is done to convert the complex structure into a format suitable for storage.
-		inscrBytes, err := jsoniter.Marshal(inscr)
+		inscrBytes, err := marshalInscription(txn, inscr)
 		if err != nil {
 			// If there is an error during serialization, log the error and return.
 			zap.L().Error("Failed to serialize HookInscription", zap.Error(err))
@@ -721,30 +633,78 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 
 		// Store the serialized HookInscription in the database with the key formed by prefixing 'inscr::' to the Inscription ID.
 		// This allows for easy retrieval of HookInscription by its ID.
-		err = txn.Set([]byte("inscr::"+inscr.ID), inscrBytes)
+		err = setState(txn, []byte("inscr::"+inscr.ID), inscrBytes)
 		if err != nil {
 			// If there is an error while setting the value in the database, log the error and return.
 			zap.L().Error("Failed to store serialized HookInscription", zap.Error(err))
 			return err
 		}
 
+		// The amount is locked in the inscription until it moves
+		if err := startMrc20Transfer(txn, block, inscr, mrc20Data, TransferInscribed); err != nil {
+			return err
+		}
+
 	} else if mrc20Data.Op == "burn" {
 		//fmt.Println("burn", mrc20Data)
 		if mrc20Data.Insc == nil {
 			logger.Info("mrc20Data.Insc is nil, inscr.ID=" + inscr.ID)
+			b.rejectInscription(ReasonMissingTarget, "burn does not name an inscription")
 			return nil
 		}
 
+		// Before rules.BurnTargets any inscription could be boosted, with the balance read and written as bytes
+		burnTargets := b.registry.rules.BurnTargets
+		var mrc721Name string
+		if burnTargets {
+			// Check if the token exists
+			if _, err := txn.Get([]byte("mrc20::geninsc::" + mrc20Data.Tick)); err != nil {
+				b.ignoreInscription(ReasonUnknownTick, "no collection mines "+mrc20Data.Tick)
+				return nil // Token does not exist, no error, stop execution
+			}
+
+			// Only the miners of the collection emitting the tick can be boosted
+			mrc721Name, err = tickMinerCollection(txn, mrc20Data.Tick, *mrc20Data.Insc)
+			if err != nil {
+				return err
+			}
+			if mrc721Name == "" {
+				b.rejectInscription(ReasonInvalidTarget, fmt.Sprintf("%s is not a miner of the collection emitting %s", *mrc20Data.Insc, mrc20Data.Tick))
+				return nil
+			}
+		} else if item, err := txn.Get([]byte("mrc20::geninsc::" + mrc20Data.Tick)); err == nil {
+			// Kept in the ledger, the target may not be one of its miners
+			err = item.Value(func(val []byte) error {
+				mrc721Name = string(val)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Pending mining rewards are part of the spendable balance
+		if err := b.settleAddressMiners(txn, inscr.Address, mrc20Data.Tick); err != nil {
+			return err
+		}
+
 		// Retrieve the balance for the address and convert it to a big.Int
 		balanceKey := "mrc20::balance::" + inscr.Address + "::" + mrc20Data.Tick
 		item, err := txn.Get([]byte(balanceKey))
+		if err == badger.ErrKeyNotFound {
+			b.rejectInscription(ReasonInsufficientBalance, "address holds no "+mrc20Data.Tick)
+			return nil
+		}
 		if err != nil {
-			fmt.Println("Error retrieving balance")
 			return err
 		}
 		var balanceBigInt *big.Int
 		err = item.Value(func(val []byte) error {
-			balanceBigInt = new(big.Int).SetBytes(val)
+			if burnTargets {
+				balanceBigInt = parseBalance(val)
+			} else {
+				balanceBigInt = new(big.Int).SetBytes(val)
+			}
 			return nil
 		})
 		if err != nil {
@@ -756,114 +716,49 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 		amountBigInt.SetString(mrc20Data.Amt, 10) // Assuming mrc20Data.Amt is a base 10 string
 		if amountBigInt.Cmp(balanceBigInt) > 0 {
 			fmt.Println("Insufficient balance for the burn")
+			b.rejectInscription(ReasonInsufficientBalance, fmt.Sprintf("balance %s is less than %s", balanceBigInt, amountBigInt))
 			return nil // Balance is less than amount, no error, stop execution
 		}
 
 		// Update the balance and write back to the database
 		newBalanceBigInt := new(big.Int).Sub(balanceBigInt, amountBigInt)
-		err = txn.Set([]byte(balanceKey), newBalanceBigInt.Bytes())
-		if err != nil {
-			return err
+		newBalance := []byte(newBalanceBigInt.String())
+		if !burnTargets {
+			newBalance = newBalanceBigInt.Bytes()
 		}
-
-		// Convert mrc20Data.Insc to a string for the key
-		burnKey := fmt.Sprintf("mrc721::burn::%s", *mrc20Data.Insc)
-		item, err = txn.Get([]byte(burnKey))
-		var totalBurnt *big.Int
-
-		// Check if the burn record exists
-		if err == badger.ErrKeyNotFound {
-			// If not found, initialize totalBurnt to zero
-			totalBurnt = big.NewInt(0)
-		} else if err != nil {
-			// On other errors, return the error
+		err = setState(txn, []byte(balanceKey), newBalance)
+		if err != nil {
 			return err
-		} else {
-			// Read the existing burn value
-			err = item.Value(func(val []byte) error {
-				totalBurnt = new(big.Int).SetBytes(val)
-				return nil
-			})
-			if err != nil {
-				return err
-			}
 		}
-
-		// Add amountBigInt to totalBurnt and write back
-		totalBurnt.Add(totalBurnt, amountBigInt)
-		err = txn.Set([]byte(burnKey), totalBurnt.Bytes())
-		if err != nil {
+		if err := b.rewriteMinerBalance(txn, inscr.Address, mrc20Data.Tick); err != nil {
 			return err
 		}
 
-		// Retrieve the name associated with the MRC20 token using its ticker.
-		to721Item, to721Eerr := txn.Get([]byte("mrc20::geninsc::" + mrc20Data.Tick))
-		if to721Eerr != nil {
-			// Handle error if the key does not exist or any other error occurs
-			fmt.Println("Failed to retrieve MRC721 name:", to721Eerr)
-			return to721Eerr
+		// Record the burn and add it to the totals of the target and its collection
+		record := &Mrc20BurnRecord{
+			InscriptionID: inscr.ID,
+			Burner:        inscr.Address,
+			Target:        *mrc20Data.Insc,
+			Mrc721name:    mrc721Name,
+			Tick:          mrc20Data.Tick,
+			Amount:        amountBigInt.String(),
+			BlockHeight:   block.BlockHeight,
 		}
-
-		var mrc721Name string
-		// Extract the value from the item and assign it to mrc721Name
-		err = to721Item.Value(func(val []byte) error {
-			mrc721Name = string(val)
-			return nil
-		})
-		if err != nil {
-			// Handle error if unable to extract the value
-			fmt.Println("Failed to extract MRC721 name:", err)
+		if err := b.recordBurn(txn, record, amountBigInt); err != nil {
+			logger.Error("Failed to record the burn: ", zap.Error(err))
 			return err
 		}
 
-		// Print the name associated with the MRC20 token.
-		//fmt.Println("TotalBurn test code MRC721 Name:", mrc721Name)
-
-		geninsc_key := fmt.Sprintf("mrc721::geninsc::%s", mrc721Name)
-		genItem, genErr := txn.Get([]byte(geninsc_key))
-		if genErr != nil {
-			// Key found, update the existing Mrc721GenesisData
-			var genesisData Mrc721GenesisData
-			err := genItem.Value(func(val []byte) error {
-				return jsoniter.Unmarshal(val, &genesisData)
-			})
-			if err != nil {
-				logger.Error("Failed to unmarshal existing MRC-721 genesis inscription: ", zap.Error(err))
-				return err
-			}
-
-			// this add code
-			// Convert the TotalBurn value from string to *big.Int for manipulation
-			totalBurnBigInt := new(big.Int)
-			_, ok := totalBurnBigInt.SetString(genesisData.TotalBurn, 10)
-			if !ok {
-				// Handle error if the string conversion to big.Int fails
-				logger.Error("Failed to convert TotalBurn to big.Int", zap.String("TotalBurn", genesisData.TotalBurn))
-				return errors.New("failed to convert TotalBurn to big.Int")
-			}
-
-			// Add the amount from amountBigInt to the totalBurnBigInt
-			totalBurnBigInt = totalBurnBigInt.Add(totalBurnBigInt, amountBigInt)
-
-			// Convert the updated total burn value back to a string
-			genesisData.TotalBurn = totalBurnBigInt.String()
-
-			// Serialize the updated Mrc721GenesisData
-			updatedInscriptionJSON, err := jsoniter.Marshal(genesisData)
-			if err != nil {
-				logger.Error("Failed to marshal updated MRC-721 genesis inscription: ", zap.Error(err))
-				return err
-			}
-
-			// Update the value in the database
-			err = txn.Set([]byte(geninsc_key), updatedInscriptionJSON)
-			if err != nil {
-				logger.Error("Error updating MRC-721 genesis inscription: ", zap.Error(err))
-				return err
-			}
-
+		// The burn raises the mining power of the inscription from the next distribution on
+		if err := b.updateMinerPower(txn, *mrc20Data.Insc); err != nil {
+			return err
 		}
-
+	} else if mrc20Data.Op == "stake" {
+		return b.stakeMrc20(txn, block, inscr, mrc20Data)
+	} else if mrc20Data.Op == "unstake" {
+		return b.unstakeMrc20(txn, block, inscr, mrc20Data)
+	} else if mrc20Data.Op == "delegate" {
+		return b.delegateMrc20(txn, block, inscr, mrc20Data)
 	}
 
 	return nil
@@ -871,55 +766,25 @@ func (b *BTOrdIdx) writeMrc20(txn *badger.Txn, block *HookBlock, inscr *HookInsc
 
 // Mining using MRC721 inscriptions.
 func (b *BTOrdIdx) mineWithMrc721Inscription(txn *badger.Txn, block *HookBlock) (err error) {
-	// Define the prefix for MRC-721 genesis inscriptions
-	prefix := []byte("mrc721::geninsc::")
-
-	// Use the Badger iterator to iterate over all keys with the specified prefix
-	opts := badger.DefaultIteratorOptions
-	opts.PrefetchValues = true
-	it := txn.NewIterator(opts)
-	defer it.Close()
-
-	// Iterate over keys
-	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-		item := it.Item()
-		key := item.Key()
-
-		// Extract the mrc721 name from the key
-		mrc721Name := string(key[len(prefix):])
-
-		// Retrieve the value (Mrc721GenesisData)
-		err := item.Value(func(val []byte) error {
-			var genesisData Mrc721GenesisData
-			if err := jsoniter.Unmarshal(val, &genesisData); err != nil {
-				logger.Error("Failed to unmarshal MRC-721 genesis genesisData: ", zap.Error(err))
-				return err
-			}
-
-			// Retrieve HookInscription using the key 'inscr::genesisData.ID'
-			inscrKey := fmt.Sprintf("inscr::%s", genesisData.ID)
-			hookInscrItem, err := txn.Get([]byte(inscrKey))
-			if err != nil {
-				logger.Error("Error retrieving HookInscription: ", zap.Error(err))
-				return err
-			}
+	// Collections are served by the registry, in the order of the "mrc721::geninsc::" keys
+	names, err := b.mrc721Names(txn)
+	if err != nil {
+		logger.Error("Failed to load MRC-721 collections: ", zap.Error(err))
+		return err
+	}
 
-			// Decode the HookInscription
-			var hookInscription HookInscription
-			err = hookInscrItem.Value(func(val []byte) error {
-				return jsoniter.Unmarshal(val, &hookInscription)
-			})
-			if err != nil {
-				logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
-				return err
-			}
+	// Iterate over collections
+	for _, mrc721Name := range names {
+		collection, err := b.mrc721Collection(txn, mrc721Name)
+		if err != nil {
+			logger.Error("Failed to load MRC-721 collection: ", zap.String("name", mrc721Name), zap.Error(err))
+			return err
+		}
 
-			// Parse the MRC721 protocol data from ContentByte
-			firstMrc721, err := ParseMRC721Protocol(*hookInscription.ContentByte)
-			if err != nil {
-				logger.Error("Failed to parse MRC721 protocol: ", zap.Error(err))
-				return err
-			}
+		err = func() error {
+			// Work on a copy of the genesis data, the parsed protocol is shared
+			genesisData := collection.Genesis
+			firstMrc721 := collection.Protocol
 
 			// Print the mrc721 name and the genesisData details
 			//logger.Info(fmt.Sprintf("MRC-721 Name: %s, Inscription: %+v", mrc721Name, genesisData))
@@ -933,170 +798,39 @@ func (b *BTOrdIdx) mineWithMrc721Inscription(txn *badger.Txn, block *HookBlock)
 
 			// ------------
 
-			// Create an instance of Mrc721MinerMap
-			minerMap := Mrc721MinerMap{Data: make(map[string]*Mrc721MinerData)}
-			// Perform prefix search for mrc721::name_inscr::mrc721Name
-			nameInscrPrefix := []byte(fmt.Sprintf("mrc721::name_inscr::%s::", mrc721Name))
-			it2 := txn.NewIterator(opts)
-			for it2.Seek(nameInscrPrefix); it2.ValidForPrefix(nameInscrPrefix); it2.Next() {
-				item := it2.Item()
-				inscriptionID := string(item.Key())[len(nameInscrPrefix):]
-
-				// Retrieve HookInscription using inscriptionID
-				inscrKey := fmt.Sprintf("inscr::%s", inscriptionID)
-				hookInscrItem, err := txn.Get([]byte(inscrKey))
-				if err != nil {
-					logger.Error("Error retrieving HookInscription: ", zap.Error(err))
-					continue
-				}
-
-				// Decode the HookInscription
-				var minerInscription HookInscription
-				err = hookInscrItem.Value(func(val []byte) error {
-					return jsoniter.Unmarshal(val, &minerInscription)
-				})
-				if err != nil {
-					logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
-					continue
-				}
-
-				// Retrieve the burn number from mrc721::burn::[inscription_id]
-				burnKey := fmt.Sprintf("mrc721::burn::%s", inscriptionID)
-				burnItem, err := txn.Get([]byte(burnKey))
-				var burnNum string = "0" // Default value if the key is not found or is empty
-				if err == nil {
-					err = burnItem.Value(func(val []byte) error {
-						if len(val) > 0 {
-							burnNum = string(val)
-						}
-						return nil
-					})
-					if err != nil {
-						return err
-					}
-				}
-
-				// Populate minerMap
-				minerMap.Data[inscriptionID] = &Mrc721MinerData{
-					InscriptionsID:     inscriptionID,
-					InscriptionsNumber: minerInscription.Number,
-					Address:            minerInscription.Address,
-					BurnNum:            burnNum,
-					Tick:               genesisData.Tick,
-					MinedAmount:        "0", // To be calculated and filled later
-					Power:              *big.NewInt(1000),
-				}
-			}
-			it2.Close()
-			// // Print minerMap in JSON format
-			// minerMapJSON, err := jsoniter.Marshal(minerMap)
-			// if err != nil {
-			// 	logger.Error("Failed to marshal minerMap: ", zap.Error(err))
-			// 	return err
-			// }
-			// logger.Info(fmt.Sprintf("Mrc721MinerMap: %s", string(minerMapJSON)))
-
 			// mining
-			calcResult, err := CalculateMiningRewards(block.BlockHeight, &genesisData, firstMrc721, &minerMap)
+			calcResult, currentBlockMining, err := calculateBlockEmission(block.BlockHeight, &genesisData, firstMrc721, collection.Schedule)
 			if err != nil {
 				logger.Error("Failed to calculate mining rewards: ", zap.Error(err))
 				return err
 			}
-			//fmt.Printf("calcResult %v", calcResult)
 
 			if !calcResult.IsMiningEnd {
 
-				// Iterate over the minerMap to update user balances
-				for _, minerData := range minerMap.Data {
-
-					// Retrieve the existing mined amount for the miner
-					minerKey := fmt.Sprintf("mrc721::inscr_miner::%s", minerData.InscriptionsID)
-					var existingMinedAmountBigInt *big.Int
-					item, err := txn.Get([]byte(minerKey))
-					if err == nil {
-						err = item.Value(func(val []byte) error {
-							existingMinedAmountBigInt = new(big.Int)
-							existingMinedAmountBigInt.SetBytes(val)
-							return nil
-						})
-						if err != nil {
-							logger.Error("Error retrieving existing mined amount: ", zap.Error(err))
-							return err
-						}
-					} else {
-						// If no existing mined amount, initialize to zero
-						existingMinedAmountBigInt = big.NewInt(0)
-					}
-
-					// Convert minerData.MinedAmount to big.Int and add it to the existing mined amount
-					minedAmountBigInt := new(big.Int)
-					_, ok := minedAmountBigInt.SetString(minerData.MinedAmount, 10)
-					if !ok {
-						logger.Error("Failed to parse mined amount to big.Int")
-						return fmt.Errorf("failed to parse mined amount to big.Int")
-					}
-					updatedMinedAmountBigInt := new(big.Int).Add(existingMinedAmountBigInt, minedAmountBigInt)
-
-					// Write the updated mined amount to the database
-					err = txn.Set([]byte(minerKey), updatedMinedAmountBigInt.Bytes())
-					if err != nil {
-						logger.Error("Failed to update mined amount: ", zap.Error(err))
-						return err
+				// Credit the emission to the power classes of the collection, miners settle it lazily
+				minedAmount, err := b.distributeMiningRewards(txn, collection, currentBlockMining)
+				if err != nil {
+					logger.Error("Failed to distribute mining rewards: ", zap.Error(err))
+					return err
+				}
+				calcResult.CurrentMiningAllNum = minedAmount.String()
+
+				// Credit the creator fee, it is mined like the rewards of the miners
+				currentFeeAllNumBigInt, _ := new(big.Int).SetString(calcResult.CurrentFeeAllNum, 10)
+				if currentFeeAllNumBigInt.Sign() > 0 {
+					feeAddress := firstMrc721.Fee.To
+					if feeAddress == "" {
+						feeAddress = genesisData.GenesisAddress
 					}
-
-					// minerData.Power is already a big.Int (assumed), so we use it directly
-					powerBigInt := minerData.Power
-
-					powerKey := fmt.Sprintf("mrc721::inscr_power::%s", minerData.InscriptionsID)
-
-					// Write the power data to the database
-					err = txn.Set([]byte(powerKey), powerBigInt.Bytes())
-					if err != nil {
-						logger.Error("Failed to set power data: ", zap.Error(err))
+					if err := addBalance(txn, feeAddress, genesisData.Tick, currentFeeAllNumBigInt); err != nil {
+						logger.Error("Failed to credit the creator fee: ", zap.Error(err))
 						return err
 					}
-
-					// -----
-					balanceKey := fmt.Sprintf("mrc20::balance::%s::%s", minerData.Address, minerData.Tick)
-
-					// Retrieve the current balance
-					var currentBalance *big.Int
-					item, err = txn.Get([]byte(balanceKey))
-					if err == nil {
-						err = item.Value(func(val []byte) error {
-							currentBalance = new(big.Int)
-							currentBalance.SetString(string(val), 10)
-							return nil
-						})
-						if err != nil {
-							logger.Error("Error retrieving current balance: ", zap.Error(err))
-							return err
-						}
-					} else {
-						// If no current balance, initialize to zero
-						currentBalance = big.NewInt(0)
-					}
-
-					// Parse the mined amount
-					minedAmount := new(big.Int)
-					_, ok = minedAmount.SetString(minerData.MinedAmount, 10)
-					if !ok {
-						logger.Error("Failed to parse mined amount")
-						return fmt.Errorf("failed to parse mined amount")
-					}
-
-					// Add the mined amount to the current balance
-					newBalance := new(big.Int).Add(currentBalance, minedAmount)
-
-					// Serialize the new balance
-					newBalanceBytes := []byte(newBalance.String())
-
-					// Write the updated balance to the database
-					err = txn.Set([]byte(balanceKey), newBalanceBytes)
-					if err != nil {
-						logger.Error("Failed to update balance: ", zap.Error(err))
-						return err
+					totalFeeTokensBigInt, _ := new(big.Int).SetString(genesisData.TotalFeeTokens, 10)
+					if totalFeeTokensBigInt == nil {
+						totalFeeTokensBigInt = big.NewInt(0)
 					}
+					genesisData.TotalFeeTokens = totalFeeTokensBigInt.Add(totalFeeTokensBigInt, currentFeeAllNumBigInt).String()
 				}
 
 				// Convert genesisData values to big.Int using new(big.Int).SetString()
@@ -1111,6 +845,7 @@ func (b *BTOrdIdx) mineWithMrc721Inscription(txn *badger.Txn, block *HookBlock)
 				// Perform the addition operations
 				prizePoolTokensBigInt.Add(prizePoolTokensBigInt, currentPrizePoolAllNumBigInt)
 				totalMinedTokensBigInt.Add(totalMinedTokensBigInt, currentMiningAllNumBigInt)
+				totalMinedTokensBigInt.Add(totalMinedTokensBigInt, currentFeeAllNumBigInt)
 				totalPrizePoolTokensBigInt.Add(totalPrizePoolTokensBigInt, currentPrizePoolAllNumBigInt)
 
 				// Convert the big.Int results back to strings
@@ -1118,16 +853,8 @@ func (b *BTOrdIdx) mineWithMrc721Inscription(txn *badger.Txn, block *HookBlock)
 				genesisData.TotalMinedTokens = totalMinedTokensBigInt.String()
 				genesisData.TotalPrizePoolTokens = totalPrizePoolTokensBigInt.String()
 
-				// Serialize the updated genesisData to JSON
-				updatedGenesisDataJSON, err := jsoniter.Marshal(genesisData)
-				if err != nil {
-					logger.Error("Failed to marshal updated genesisData: ", zap.Error(err))
-					return err
-				}
-
-				// Write the updated genesisData to the database
-				genesisDataKey := fmt.Sprintf("mrc721::geninsc::%s", mrc721Name)
-				err = txn.Set([]byte(genesisDataKey), updatedGenesisDataJSON)
+				// Write the updated genesisData to the database and the registry
+				err = b.putMrc721Genesis(txn, &genesisData, nil)
 				if err != nil {
 					logger.Error("Failed to update genesisData in the database: ", zap.Error(err))
 					return err
@@ -1136,7 +863,7 @@ func (b *BTOrdIdx) mineWithMrc721Inscription(txn *badger.Txn, block *HookBlock)
 			}
 
 			return nil
-		})
+		}()
 
 		if err != nil {
 			logger.Error("Error processing item: ", zap.Error(err))
@@ -1154,59 +881,31 @@ func (b *BTOrdIdx) lotteryWithBlockHash(txn *badger.Txn, block *HookBlock) (err
 		return nil
 	}
 
-	// Define the prefix for MRC-721 genesis inscriptions
-	prefix := []byte("mrc721::geninsc::")
-	// Use the Badger iterator to iterate over all keys with the specified prefix
-	opts := badger.DefaultIteratorOptions
-	opts.PrefetchValues = true
-	it := txn.NewIterator(opts)
-	defer it.Close()
+	// Collections are served by the registry, in the order of the "mrc721::geninsc::" keys
+	names, err := b.mrc721Names(txn)
+	if err != nil {
+		logger.Error("Failed to load MRC-721 collections: ", zap.Error(err))
+		return err
+	}
 
-	// Iterate over keys
-	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-		item := it.Item()
-		key := item.Key()
+	// Iterate over collections
+	for _, mrc721Name := range names {
+		collection, err := b.mrc721Collection(txn, mrc721Name)
+		if err != nil {
+			logger.Error("Failed to load MRC-721 collection: ", zap.String("name", mrc721Name), zap.Error(err))
+			return err
+		}
 
-		// Extract the mrc721 name from the key
-		mrc721Name := string(key[len(prefix):])
-
-		// Retrieve the value (Mrc721GenesisData)
-		err := item.Value(func(val []byte) error {
-			var genesisData Mrc721GenesisData
-			if err := jsoniter.Unmarshal(val, &genesisData); err != nil {
-				logger.Error("Failed to unmarshal MRC-721 genesis genesisData: ", zap.Error(err))
-				return err
-			}
+		err = func() error {
+			// Work on a copy of the genesis data, the parsed protocol is shared
+			genesisData := collection.Genesis
 
 			if genesisData.PrizePoolTokens == "0" {
 				//logger.Info(fmt.Sprintf("MRC-721 Name: %s,  lottery tokens is 0. ", mrc721Name))
 				return nil
 			}
 
-			// Retrieve HookInscription using the key 'inscr::genesisData.ID'
-			inscrKey := fmt.Sprintf("inscr::%s", genesisData.ID)
-			hookInscrItem, err := txn.Get([]byte(inscrKey))
-			if err != nil {
-				logger.Error("Error retrieving HookInscription: ", zap.Error(err))
-				return err
-			}
-
-			// Decode the HookInscription
-			var hookInscription HookInscription
-			err = hookInscrItem.Value(func(val []byte) error {
-				return jsoniter.Unmarshal(val, &hookInscription)
-			})
-			if err != nil {
-				logger.Error("Failed to unmarshal HookInscription: ", zap.Error(err))
-				return err
-			}
-
-			// Parse the MRC721 protocol data from ContentByte
-			firstMrc721, err := ParseMRC721Protocol(*hookInscription.ContentByte)
-			if err != nil {
-				logger.Error("Failed to parse MRC721 protocol: ", zap.Error(err))
-				return err
-			}
+			firstMrc721 := collection.Protocol
 
 			if firstMrc721.Ltry == nil {
 				logger.Info(fmt.Sprintf("MRC-721 Name: %s,  lottery is nil. ", mrc721Name))
@@ -1234,148 +933,146 @@ func (b *BTOrdIdx) lotteryWithBlockHash(txn *badger.Txn, block *HookBlock) (err
 
 				// Perform lottery logic if randomWinp is less than or equal to winpBigInt
 				if randomWinp.Cmp(winpBigInt) <= 0 {
-					// Convert InscriptionsCount to int64 and decrement by 1 for lottery
+					// Convert InscriptionsCount to int64 for lottery
 					inscriptionsCount := int64(genesisData.InscriptionsCount)
-					luckNumLimit := inscriptionsCount // - 1
+					rules := RulesAt(currentHeightBigInt.Int64())
 
-					// Call convertHashToBigInt with the new limit
-					luckNum, err := convertHashToBigInt(block.BlockHash, luckNumLimit)
-					if err != nil {
-
-						logger.Error("Failed to convert hash to big.Int for luckNum: block.BlockHash="+block.BlockHash, zap.Error(err))
-						return err
-					}
-
-					// Print the luckNum
-					logger.Info("Lottery luck number: ", zap.String("LuckNum", luckNum.String()))
-
-					// Calculate the actual amount of prize money to be distributed
+					// Calculate the actual amount of prize money to be distributed, then split it between the tiers
 					prizePoolTokensBigInt, _ := new(big.Int).SetString(genesisData.PrizePoolTokens, 10)
-					actualPrizeAmount := new(big.Int).Mul(prizePoolTokensBigInt, distBigInt)
-					actualPrizeAmount.Div(actualPrizeAmount, big.NewInt(1000))
+					drawPrizeAmount := new(big.Int).Mul(prizePoolTokensBigInt, distBigInt)
+					drawPrizeAmount.Div(drawPrizeAmount, big.NewInt(1000))
 
-					// this code add ...
-					// Read the inscription ID using the 'mrc721::count_inscr::[mrc721Name]::[luckNum]' key
-					luckInscriptionIDKey := fmt.Sprintf("mrc721::count_inscr::%s::%s", mrc721Name, luckNum.String())
-					item, err := txn.Get([]byte(luckInscriptionIDKey))
-					if err != nil {
-						logger.Error("Failed to get luck inscription ID: ", zap.Error(err))
-						return err
-					}
-
-					var luckInscriptionID string
-					err = item.Value(func(val []byte) error {
-						luckInscriptionID = string(val)
-						return nil
-					})
-					if err != nil {
-						logger.Error("Failed to read luck inscription ID value: ", zap.Error(err))
-						return err
-					}
+					tierShares := lotteryTierShares(rules, firstMrc721.Ltry)
+					var paid []string
+					for tier, share := range tierShares {
+						winner, proof, err := drawLotteryWinner(txn, rules, mrc721Name, inscriptionsCount, firstMrc721.Ltry, block.BlockHash, tier, paid)
+						if err != nil {
+							logger.Error("Failed to draw the lottery winner: block.BlockHash="+block.BlockHash, zap.Error(err))
+							return err
+						}
+						if winner == nil {
+							logger.Info("No eligible lottery winner: ", zap.String("name", mrc721Name), zap.Int("tier", tier))
+							continue
+						}
+						paid = append(paid, winner.InscriptionID)
 
-					// Find the user address associated with the lucky inscription ID
-					prefix := fmt.Sprintf("mrc721::inscr_addr::%s::", luckInscriptionID)
-					var luckAddress string
-					it := txn.NewIterator(badger.DefaultIteratorOptions)
-					for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
-						item := it.Item()
-						key := item.Key()
-						luckAddress = string(key[len(prefix):])
-						break
-					}
-					it.Close()
+						// Print the luckNum
+						logger.Info("Lottery luck number: ", zap.String("LuckNum", winner.LuckNum))
 
-					if luckAddress == "" {
-						logger.Error("No luck address found")
-						return errors.New("no luck address found")
-					}
+						actualPrizeAmount := new(big.Int).Mul(drawPrizeAmount, share)
+						actualPrizeAmount.Div(actualPrizeAmount, big.NewInt(1000))
+						luckAddress := winner.Owner
 
-					var balanceStr string
-					balanceStr = "0"
-					// Retrieve the balance of the lucky address for the specific token
-					balanceKey := fmt.Sprintf("mrc20::balance::%s::%s", luckAddress, firstMrc721.Token.Tick)
-					balanceItem, err := txn.Get([]byte(balanceKey))
-					if err != nil {
-						logger.Info("Failed to get balance: ", zap.Error(err))
-						//return err
-					} else {
-						err = balanceItem.Value(func(val []byte) error {
-							balanceStr = string(val)
-							return nil
-						})
+						var balanceStr string
+						balanceStr = "0"
+						// Retrieve the balance of the lucky address for the specific token
+						balanceKey := fmt.Sprintf("mrc20::balance::%s::%s", luckAddress, firstMrc721.Token.Tick)
+						balanceItem, err := txn.Get([]byte(balanceKey))
 						if err != nil {
-							logger.Error("Failed to read balance value: ", zap.Error(err))
-							return err
+							logger.Info("Failed to get balance: ", zap.Error(err))
+							//return err
+						} else {
+							err = balanceItem.Value(func(val []byte) error {
+								balanceStr = string(val)
+								return nil
+							})
+							if err != nil {
+								logger.Error("Failed to read balance value: ", zap.Error(err))
+								return err
+							}
 						}
-					}
 
-					// Convert the balance to big.Int
-					balanceBigInt, ok := new(big.Int).SetString(balanceStr, 10)
-					if !ok {
-						logger.Error("Invalid balance format")
-						return errors.New("invalid balance format")
-					}
+						// Convert the balance to big.Int
+						balanceBigInt, ok := new(big.Int).SetString(balanceStr, 10)
+						if !ok {
+							logger.Error("Invalid balance format")
+							return errors.New("invalid balance format")
+						}
 
-					// Add the prize amount to the balance
-					updatedBalance := new(big.Int).Add(balanceBigInt, actualPrizeAmount)
+						// Add the prize amount to the balance
+						updatedBalance := new(big.Int).Add(balanceBigInt, actualPrizeAmount)
 
-					// Write the updated balance back to the KV store
-					if err := txn.Set([]byte(balanceKey), []byte(updatedBalance.String())); err != nil {
-						logger.Error("Failed to write updated balance back to KV store: ", zap.Error(err))
-						return err
-					}
+						// Write the updated balance back to the KV store
+						if err := setState(txn, []byte(balanceKey), []byte(updatedBalance.String())); err != nil {
+							logger.Error("Failed to write updated balance back to KV store: ", zap.Error(err))
+							return err
+						}
 
-					// Subtract the actual prize amount from the prize pool
-					updatedPrizePool := new(big.Int).Sub(prizePoolTokensBigInt, actualPrizeAmount)
+						// Subtract the actual prize amount from the prize pool
+						currentPrizePool, _ := new(big.Int).SetString(genesisData.PrizePoolTokens, 10)
+						updatedPrizePool := new(big.Int).Sub(currentPrizePool, actualPrizeAmount)
 
-					oldPrizePoolTokens := genesisData.PrizePoolTokens
-					// Update the genesisData with the new prize pool amount
-					genesisData.PrizePoolTokens = updatedPrizePool.String()
-					genesisData.TotalPrizeRound += 1
+						oldPrizePoolTokens := genesisData.PrizePoolTokens
+						// Update the genesisData with the new prize pool amount
+						genesisData.PrizePoolTokens = updatedPrizePool.String()
+						genesisData.TotalPrizeRound += 1
 
-					// Serialize the updated genesisData to JSON using jsoniter
-					updatedGenesisDataJSON, err := jsoniter.Marshal(genesisData)
-					if err != nil {
-						logger.Error("Failed to marshal updated genesisData: ", zap.Error(err))
-						return err
-					}
+						// Write the updated genesisData back to the KV store and the registry
+						if err := b.putMrc721Genesis(txn, &genesisData, nil); err != nil {
+							logger.Error("Failed to write updated genesisData back to KV store: ", zap.Error(err))
+							return err
+						}
 
-					// Write the updated genesisData back to the KV store
-					if err := txn.Set([]byte(fmt.Sprintf("mrc721::geninsc::%s", mrc721Name)), updatedGenesisDataJSON); err != nil {
-						logger.Error("Failed to write updated genesisData back to KV store: ", zap.Error(err))
-						return err
-					}
+						// Create a new LotteryData structure to record the details of the lottery win
+						lotteryData := LotteryData{
+							BlockHeight:    block.BlockHeight,
+							BlockHash:      block.BlockHash,
+							BlockTimestamp: block.Timestamp,
+							Address:        luckAddress,
+							InscriptionID:  winner.InscriptionID,
+							Number:         0,
+							Mrc721name:     mrc721Name,
+							WinAmount:      actualPrizeAmount.String(),
+							JackpotAccum:   oldPrizePoolTokens,          // Accumulated jackpot before the win
+							Round:          genesisData.TotalPrizeRound, // Current round of the lottery
+							Winp:           firstMrc721.Ltry.Winp,
+							Dist:           firstMrc721.Ltry.Dist,
+						}
+						if len(tierShares) > 1 {
+							lotteryData.Tier = tier + 1
+						}
 
-					// Create a new LotteryData structure to record the details of the lottery win
-					lotteryData := LotteryData{
-						BlockHeight:    block.BlockHeight,
-						BlockHash:      block.BlockHash,
-						BlockTimestamp: block.Timestamp,
-						Address:        luckAddress,
-						InscriptionID:  luckInscriptionID,
-						Number:         0,
-						Mrc721name:     mrc721Name,
-						WinAmount:      actualPrizeAmount.String(),
-						JackpotAccum:   oldPrizePoolTokens,          // Accumulated jackpot before the win
-						Round:          genesisData.TotalPrizeRound, // Current round of the lottery
-						Winp:           firstMrc721.Ltry.Winp,
-						Dist:           firstMrc721.Ltry.Dist,
-					}
+						// Serialize the LotteryData to JSON
+						lotteryDataJSON, err := jsoniter.Marshal(lotteryData)
+						if err != nil {
+							logger.Error("Failed to marshal lotteryData: ", zap.Error(err))
+							return err
+						}
 
-					// Serialize the LotteryData to JSON
-					lotteryDataJSON, err := jsoniter.Marshal(lotteryData)
-					if err != nil {
-						logger.Error("Failed to marshal lotteryData: ", zap.Error(err))
-						return err
-					}
+						// Generate the key for the new lottery win entry
+						lotteryKey := fmt.Sprintf("lottery::mrc721::%s::%d", mrc721Name, genesisData.TotalPrizeRound)
 
-					// Generate the key for the new lottery win entry
-					lotteryKey := fmt.Sprintf("lottery::mrc721::%s::%d", mrc721Name, genesisData.TotalPrizeRound)
+						// Write the LotteryData to the KV store
+						if err := setState(txn, []byte(lotteryKey), lotteryDataJSON); err != nil {
+							logger.Error("Failed to write lotteryData to KV store: ", zap.Error(err))
+							return err
+						}
 
-					// Write the LotteryData to the KV store
-					if err := txn.Set([]byte(lotteryKey), lotteryDataJSON); err != nil {
-						logger.Error("Failed to write lotteryData to KV store: ", zap.Error(err))
-						return err
+						if err := setState(txn, lotteryAddressKey(luckAddress, mrc721Name, genesisData.TotalPrizeRound), nil); err != nil {
+							logger.Error("Failed to index the lottery win: ", zap.Error(err))
+							return err
+						}
+
+						// Record how the win was derived, so it can be audited with VerifyLotteryDraw
+						proof.Round = genesisData.TotalPrizeRound
+						proof.BlockHeight = block.BlockHeight
+						proof.RandomWinp = randomWinp.String()
+						proof.Winp = winpBigInt.String()
+						proof.InscriptionID = winner.InscriptionID
+						proof.Address = luckAddress
+						proof.PrizePool = prizePoolTokensBigInt.String()
+						proof.Dist = distBigInt.String()
+						proof.Share = share.String()
+						proof.WinAmount = actualPrizeAmount.String()
+						proofJSON, err := jsoniter.Marshal(proof)
+						if err != nil {
+							logger.Error("Failed to marshal the lottery proof: ", zap.Error(err))
+							return err
+						}
+						if err := setState(txn, lotteryProofKey(mrc721Name, proof.Round), proofJSON); err != nil {
+							logger.Error("Failed to write the lottery proof to KV store: ", zap.Error(err))
+							return err
+						}
 					}
 
 				}
@@ -1386,7 +1083,7 @@ func (b *BTOrdIdx) lotteryWithBlockHash(txn *badger.Txn, block *HookBlock) (err
 			}
 
 			return nil
-		})
+		}()
 
 		if err != nil {
 			logger.Error("Error processing item: ", zap.Error(err))
@@ -16,6 +16,7 @@ import (
 type Store struct {
 	OrdIdx  *satmine.BTOrdIdx        // Index for orders
 	RecIdx  *satmine.BTRecIdx        // Index for records
+	SimJobs *satmine.SimulationJobs  // Mining simulations run in the background
 	clients map[*websocket.Conn]bool // Active WebSocket connections
 
 	// Upgrader for WebSocket connections, allows for custom configurations